@@ -0,0 +1,44 @@
+-- Ledger of every stock change. products.stock is the running balance and is
+-- only changed together with a row here. product_id carries no foreign key so
+-- history outlives deleted products.
+CREATE TABLE IF NOT EXISTS stock_movements (
+    id             BIGSERIAL PRIMARY KEY,
+    product_id     INT         NOT NULL,
+    quantity       INT         NOT NULL,
+    reason         TEXT        NOT NULL CHECK (reason IN ('sale', 'refund', 'receipt', 'adjustment', 'transfer', 'waste')),
+    reference_type TEXT        NOT NULL DEFAULT '',
+    reference_id   INT,
+    balance        INT         NOT NULL,
+    note           TEXT        NOT NULL DEFAULT '',
+    actor          TEXT        NOT NULL DEFAULT '',
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_movements_product ON stock_movements (product_id, created_at, id);
+CREATE INDEX IF NOT EXISTS idx_stock_movements_reference ON stock_movements (reference_type, reference_id);
+
+-- Seed the ledger with the stock each product already has.
+INSERT INTO stock_movements (product_id, quantity, reason, balance, note, actor)
+SELECT id, stock, 'adjustment', stock, 'Opening balance', 'system'
+FROM products
+WHERE stock <> 0
+  AND NOT EXISTS (SELECT 1 FROM stock_movements);
+
+CREATE TABLE IF NOT EXISTS refunds (
+    id             SERIAL PRIMARY KEY,
+    transaction_id INT         NOT NULL REFERENCES transactions (id),
+    total_amount   INT         NOT NULL,
+    reason         TEXT        NOT NULL DEFAULT '',
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS refund_items (
+    id                    SERIAL PRIMARY KEY,
+    refund_id             INT NOT NULL REFERENCES refunds (id),
+    transaction_detail_id INT NOT NULL REFERENCES transaction_details (id),
+    product_id            INT NOT NULL,
+    quantity              INT NOT NULL CHECK (quantity > 0),
+    amount                INT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_refund_items_detail ON refund_items (transaction_detail_id);
@@ -0,0 +1,38 @@
+CREATE TABLE IF NOT EXISTS stocktakes (
+    id           SERIAL PRIMARY KEY,
+    status       TEXT        NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'finalized', 'cancelled')),
+    note         TEXT        NOT NULL DEFAULT '',
+    created_by   TEXT        NOT NULL DEFAULT '',
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    finalized_by TEXT        NOT NULL DEFAULT '',
+    finalized_at TIMESTAMPTZ
+);
+
+-- Only one session may be counting at a time.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_stocktakes_single_open ON stocktakes (status) WHERE status = 'open';
+
+-- Expected quantities captured when the session opened. unit_value is frozen
+-- when the session is finalized.
+CREATE TABLE IF NOT EXISTS stocktake_items (
+    stocktake_id      INT NOT NULL REFERENCES stocktakes (id),
+    product_id        INT NOT NULL,
+    snapshot_quantity INT NOT NULL,
+    unit_value        INT,
+    PRIMARY KEY (stocktake_id, product_id)
+);
+
+-- Individual count entries. Several clerks may count the same product in
+-- different locations; the entries are summed. system_quantity is the book
+-- stock at the moment of counting and is what the count is reconciled against.
+CREATE TABLE IF NOT EXISTS stocktake_counts (
+    id              SERIAL PRIMARY KEY,
+    stocktake_id    INT         NOT NULL REFERENCES stocktakes (id),
+    product_id      INT         NOT NULL,
+    quantity        INT         NOT NULL CHECK (quantity >= 0),
+    location        TEXT        NOT NULL DEFAULT '',
+    counted_by      TEXT        NOT NULL DEFAULT '',
+    system_quantity INT         NOT NULL,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_stocktake_counts_product ON stocktake_counts (stocktake_id, product_id, id);
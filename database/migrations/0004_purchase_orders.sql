@@ -0,0 +1,46 @@
+CREATE TABLE IF NOT EXISTS suppliers (
+    id      SERIAL PRIMARY KEY,
+    name    TEXT NOT NULL,
+    phone   TEXT NOT NULL DEFAULT '',
+    email   TEXT NOT NULL DEFAULT '',
+    address TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS purchase_orders (
+    id          SERIAL PRIMARY KEY,
+    supplier_id INT         NOT NULL REFERENCES suppliers (id),
+    status      TEXT        NOT NULL DEFAULT 'draft' CHECK (status IN ('draft', 'sent', 'partially_received', 'received', 'closed')),
+    note        TEXT        NOT NULL DEFAULT '',
+    created_by  TEXT        NOT NULL DEFAULT '',
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    sent_at     TIMESTAMPTZ,
+    closed_at   TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_purchase_orders_supplier ON purchase_orders (supplier_id, status);
+
+CREATE TABLE IF NOT EXISTS purchase_order_lines (
+    id                SERIAL PRIMARY KEY,
+    purchase_order_id INT NOT NULL REFERENCES purchase_orders (id) ON DELETE CASCADE,
+    product_id        INT NOT NULL REFERENCES products (id),
+    quantity          INT NOT NULL CHECK (quantity > 0),
+    unit_cost         INT NOT NULL CHECK (unit_cost >= 0),
+    received_quantity INT NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS goods_receipts (
+    id                SERIAL PRIMARY KEY,
+    purchase_order_id INT         NOT NULL REFERENCES purchase_orders (id),
+    note              TEXT        NOT NULL DEFAULT '',
+    received_by       TEXT        NOT NULL DEFAULT '',
+    created_at        TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS goods_receipt_lines (
+    id                     SERIAL PRIMARY KEY,
+    goods_receipt_id       INT NOT NULL REFERENCES goods_receipts (id),
+    purchase_order_line_id INT NOT NULL REFERENCES purchase_order_lines (id),
+    product_id             INT NOT NULL,
+    quantity               INT NOT NULL CHECK (quantity > 0),
+    unit_cost              INT NOT NULL
+);
@@ -0,0 +1,23 @@
+ALTER TABLE products ADD COLUMN IF NOT EXISTS category_id INT REFERENCES categories (id) ON DELETE SET NULL;
+ALTER TABLE products ADD COLUMN IF NOT EXISTS cost INT NOT NULL DEFAULT 0;
+ALTER TABLE products ADD COLUMN IF NOT EXISTS costing_method TEXT NOT NULL DEFAULT 'average' CHECK (costing_method IN ('average', 'fifo'));
+
+-- Open FIFO layers. Products costed by moving average have no layers.
+CREATE TABLE IF NOT EXISTS cost_layers (
+    id         BIGSERIAL PRIMARY KEY,
+    product_id INT         NOT NULL,
+    quantity   INT         NOT NULL,
+    remaining  INT         NOT NULL CHECK (remaining >= 0),
+    unit_cost  INT         NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_cost_layers_open ON cost_layers (product_id, id) WHERE remaining > 0;
+
+-- Stock value moved by each ledger row.
+ALTER TABLE stock_movements ADD COLUMN IF NOT EXISTS cost_amount INT NOT NULL DEFAULT 0;
+
+-- Cost of goods sold snapshotted at checkout. Sales made before this
+-- migration have no recorded cost.
+ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS cost_amount INT NOT NULL DEFAULT 0;
+ALTER TABLE refund_items ADD COLUMN IF NOT EXISTS cost_amount INT NOT NULL DEFAULT 0;
@@ -0,0 +1,6 @@
+ALTER TABLE products ADD COLUMN IF NOT EXISTS min_stock INT NOT NULL DEFAULT 0 CHECK (min_stock >= 0);
+ALTER TABLE products ADD COLUMN IF NOT EXISTS reorder_qty INT NOT NULL DEFAULT 0 CHECK (reorder_qty >= 0);
+-- Preferred supplier used when turning low stock into purchase orders.
+ALTER TABLE products ADD COLUMN IF NOT EXISTS supplier_id INT REFERENCES suppliers (id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_products_low_stock ON products (id) WHERE min_stock > 0 AND stock <= min_stock;
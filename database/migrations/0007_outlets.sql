@@ -0,0 +1,62 @@
+CREATE TABLE IF NOT EXISTS outlets (
+    id         SERIAL PRIMARY KEY,
+    name       TEXT        NOT NULL,
+    address    TEXT        NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Terminals authenticate with an API key; only its SHA-256 hash is stored.
+CREATE TABLE IF NOT EXISTS terminals (
+    id           SERIAL PRIMARY KEY,
+    outlet_id    INT         NOT NULL REFERENCES outlets (id),
+    name         TEXT        NOT NULL,
+    api_key_hash TEXT        NOT NULL UNIQUE,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Per-outlet inventory and optional price override. products.stock is kept
+-- as the total across outlets.
+CREATE TABLE IF NOT EXISTS outlet_stocks (
+    outlet_id  INT NOT NULL REFERENCES outlets (id),
+    product_id INT NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    stock      INT NOT NULL DEFAULT 0,
+    price      INT CHECK (price >= 0),
+    PRIMARY KEY (outlet_id, product_id)
+);
+
+-- Existing stock and history belong to the first outlet.
+INSERT INTO outlets (name)
+SELECT 'Main'
+WHERE NOT EXISTS (SELECT 1 FROM outlets);
+
+INSERT INTO outlet_stocks (outlet_id, product_id, stock)
+SELECT (SELECT MIN(id) FROM outlets), id, stock
+FROM products
+ON CONFLICT DO NOTHING;
+
+ALTER TABLE stock_movements ADD COLUMN IF NOT EXISTS outlet_id INT;
+UPDATE stock_movements SET outlet_id = (SELECT MIN(id) FROM outlets) WHERE outlet_id IS NULL;
+ALTER TABLE stock_movements ALTER COLUMN outlet_id SET NOT NULL;
+DROP INDEX IF EXISTS idx_stock_movements_product;
+CREATE INDEX IF NOT EXISTS idx_stock_movements_product ON stock_movements (product_id, outlet_id, created_at, id);
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS outlet_id INT REFERENCES outlets (id);
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS terminal_id INT REFERENCES terminals (id);
+UPDATE transactions SET outlet_id = (SELECT MIN(id) FROM outlets) WHERE outlet_id IS NULL;
+ALTER TABLE transactions ALTER COLUMN outlet_id SET NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_transactions_outlet ON transactions (outlet_id, created_at);
+
+ALTER TABLE refunds ADD COLUMN IF NOT EXISTS outlet_id INT REFERENCES outlets (id);
+UPDATE refunds SET outlet_id = (SELECT MIN(id) FROM outlets) WHERE outlet_id IS NULL;
+ALTER TABLE refunds ALTER COLUMN outlet_id SET NOT NULL;
+
+ALTER TABLE stocktakes ADD COLUMN IF NOT EXISTS outlet_id INT REFERENCES outlets (id);
+UPDATE stocktakes SET outlet_id = (SELECT MIN(id) FROM outlets) WHERE outlet_id IS NULL;
+ALTER TABLE stocktakes ALTER COLUMN outlet_id SET NOT NULL;
+DROP INDEX IF EXISTS idx_stocktakes_single_open;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_stocktakes_single_open ON stocktakes (outlet_id) WHERE status = 'open';
+
+-- Purchase orders are delivered to, and received at, one outlet.
+ALTER TABLE purchase_orders ADD COLUMN IF NOT EXISTS outlet_id INT REFERENCES outlets (id);
+UPDATE purchase_orders SET outlet_id = (SELECT MIN(id) FROM outlets) WHERE outlet_id IS NULL;
+ALTER TABLE purchase_orders ALTER COLUMN outlet_id SET NOT NULL;
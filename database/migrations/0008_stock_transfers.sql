@@ -0,0 +1,31 @@
+CREATE TABLE IF NOT EXISTS stock_transfers (
+    id                    SERIAL PRIMARY KEY,
+    source_outlet_id      INT         NOT NULL REFERENCES outlets (id),
+    destination_outlet_id INT         NOT NULL REFERENCES outlets (id),
+    status                TEXT        NOT NULL DEFAULT 'requested' CHECK (status IN ('requested', 'in_transit', 'received', 'cancelled')),
+    note                  TEXT        NOT NULL DEFAULT '',
+    requested_by          TEXT        NOT NULL DEFAULT '',
+    created_at            TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    dispatched_by         TEXT        NOT NULL DEFAULT '',
+    dispatched_at         TIMESTAMPTZ,
+    received_by           TEXT        NOT NULL DEFAULT '',
+    received_at           TIMESTAMPTZ,
+    cancelled_at          TIMESTAMPTZ,
+    CHECK (source_outlet_id <> destination_outlet_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_transfers_status ON stock_transfers (status);
+
+-- unit_cost is fixed at dispatch so goods in transit keep the value they
+-- left the source with and arrive at that same value.
+CREATE TABLE IF NOT EXISTS stock_transfer_lines (
+    id                  SERIAL PRIMARY KEY,
+    stock_transfer_id   INT  NOT NULL REFERENCES stock_transfers (id) ON DELETE CASCADE,
+    product_id          INT  NOT NULL REFERENCES products (id),
+    quantity            INT  NOT NULL CHECK (quantity > 0),
+    dispatched_quantity INT  NOT NULL DEFAULT 0 CHECK (dispatched_quantity >= 0),
+    received_quantity   INT  NOT NULL DEFAULT 0 CHECK (received_quantity >= 0),
+    unit_cost           INT  NOT NULL DEFAULT 0,
+    discrepancy_note    TEXT NOT NULL DEFAULT '',
+    UNIQUE (stock_transfer_id, product_id)
+);
@@ -0,0 +1,39 @@
+ALTER TABLE products ADD COLUMN IF NOT EXISTS track_lots BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Stock of a lot-tracked product at an outlet is held in lots whose
+-- remaining quantities add up to outlet_stocks.stock. Units received without
+-- a batch number sit in the product's unassigned lot (batch_number '').
+CREATE TABLE IF NOT EXISTS lots (
+    id           SERIAL PRIMARY KEY,
+    outlet_id    INT         NOT NULL REFERENCES outlets (id),
+    product_id   INT         NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    batch_number TEXT        NOT NULL DEFAULT '',
+    expiry_date  DATE,
+    remaining    INT         NOT NULL DEFAULT 0 CHECK (remaining >= 0),
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (outlet_id, product_id, batch_number)
+);
+
+CREATE INDEX IF NOT EXISTS idx_lots_expiry ON lots (expiry_date) WHERE remaining > 0;
+
+CREATE TABLE IF NOT EXISTS transaction_detail_lots (
+    id                    SERIAL PRIMARY KEY,
+    transaction_detail_id INT NOT NULL REFERENCES transaction_details (id),
+    lot_id                INT NOT NULL REFERENCES lots (id),
+    quantity              INT NOT NULL CHECK (quantity > 0),
+    returned_quantity     INT NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_transaction_detail_lots_detail ON transaction_detail_lots (transaction_detail_id);
+
+ALTER TABLE goods_receipt_lines ADD COLUMN IF NOT EXISTS batch_number TEXT NOT NULL DEFAULT '';
+ALTER TABLE goods_receipt_lines ADD COLUMN IF NOT EXISTS expiry_date DATE;
+
+-- Lots a transfer line was dispatched from, recreated at the destination.
+CREATE TABLE IF NOT EXISTS stock_transfer_line_lots (
+    id                     SERIAL PRIMARY KEY,
+    stock_transfer_line_id INT  NOT NULL REFERENCES stock_transfer_lines (id) ON DELETE CASCADE,
+    batch_number           TEXT NOT NULL DEFAULT '',
+    expiry_date            DATE,
+    quantity               INT  NOT NULL CHECK (quantity > 0)
+);
@@ -0,0 +1,18 @@
+-- A parent product groups sellable variants along option axes such as size
+-- or flavour. Each variant is an ordinary product with its own price, stock,
+-- SKU and barcode.
+CREATE TABLE IF NOT EXISTS parent_products (
+    id          SERIAL PRIMARY KEY,
+    name        TEXT  NOT NULL,
+    category_id INT   REFERENCES categories (id),
+    axes        JSONB NOT NULL DEFAULT '[]'
+);
+
+ALTER TABLE products ADD COLUMN IF NOT EXISTS parent_id INT REFERENCES parent_products (id);
+ALTER TABLE products ADD COLUMN IF NOT EXISTS variant_options JSONB;
+ALTER TABLE products ADD COLUMN IF NOT EXISTS sku TEXT;
+ALTER TABLE products ADD COLUMN IF NOT EXISTS barcode TEXT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_products_variant ON products (parent_id, variant_options) WHERE parent_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_products_sku ON products (sku);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_products_barcode ON products (barcode);
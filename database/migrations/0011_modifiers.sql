@@ -0,0 +1,41 @@
+-- Modifier groups such as "Milk" or "Sugar level" are attached to products.
+-- A checkout item picks between min_select and max_select modifiers of each
+-- attached group.
+CREATE TABLE IF NOT EXISTS modifier_groups (
+    id         SERIAL PRIMARY KEY,
+    name       TEXT NOT NULL,
+    min_select INT  NOT NULL DEFAULT 0 CHECK (min_select >= 0),
+    max_select INT  NOT NULL DEFAULT 1 CHECK (max_select >= 1),
+    CHECK (min_select <= max_select)
+);
+
+-- A modifier adds price to the item and may use up quantity units of an
+-- ingredient product per item sold.
+CREATE TABLE IF NOT EXISTS modifiers (
+    id                SERIAL PRIMARY KEY,
+    modifier_group_id INT  NOT NULL REFERENCES modifier_groups (id) ON DELETE CASCADE,
+    name              TEXT NOT NULL,
+    price             INT  NOT NULL DEFAULT 0,
+    product_id        INT  REFERENCES products (id),
+    quantity          INT  NOT NULL DEFAULT 0 CHECK (quantity >= 0)
+);
+
+CREATE TABLE IF NOT EXISTS product_modifier_groups (
+    product_id        INT NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    modifier_group_id INT NOT NULL REFERENCES modifier_groups (id) ON DELETE CASCADE,
+    position          INT NOT NULL DEFAULT 0,
+    PRIMARY KEY (product_id, modifier_group_id)
+);
+
+-- Chosen modifiers are copied onto the sale so later menu changes do not
+-- alter past receipts. cost_amount is the ingredient cost they used up.
+CREATE TABLE IF NOT EXISTS transaction_detail_modifiers (
+    id                    SERIAL PRIMARY KEY,
+    transaction_detail_id INT  NOT NULL REFERENCES transaction_details (id),
+    modifier_id           INT  REFERENCES modifiers (id) ON DELETE SET NULL,
+    name                  TEXT NOT NULL,
+    price                 INT  NOT NULL,
+    cost_amount           INT  NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_transaction_detail_modifiers_detail ON transaction_detail_modifiers (transaction_detail_id);
@@ -0,0 +1,29 @@
+-- Composite products are made to order from a recipe of component products
+-- and hold no stock of their own. Recipe quantities may be fractional.
+ALTER TABLE products ADD COLUMN IF NOT EXISTS is_composite BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE IF NOT EXISTS recipe_items (
+    product_id   INT           NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    component_id INT           NOT NULL REFERENCES products (id),
+    quantity     NUMERIC(12,3) NOT NULL CHECK (quantity > 0),
+    PRIMARY KEY (product_id, component_id),
+    CHECK (product_id <> component_id)
+);
+
+-- Stock is counted in whole units, so a component is taken out of stock a
+-- unit at a time as it is opened. opened is what is left of the last unit
+-- opened at the outlet; it is used up before another unit is opened.
+ALTER TABLE outlet_stocks ADD COLUMN IF NOT EXISTS opened NUMERIC(12,3) NOT NULL DEFAULT 0 CHECK (opened >= 0 AND opened < 1);
+
+-- What each sale of a composite product used, valued at the component's cost
+-- when it was sold.
+CREATE TABLE IF NOT EXISTS ingredient_usages (
+    id                    SERIAL PRIMARY KEY,
+    transaction_detail_id INT           NOT NULL REFERENCES transaction_details (id),
+    product_id            INT           NOT NULL REFERENCES products (id),
+    quantity              NUMERIC(12,3) NOT NULL,
+    cost_amount           INT           NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_ingredient_usages_detail ON ingredient_usages (transaction_detail_id);
+CREATE INDEX IF NOT EXISTS idx_ingredient_usages_product ON ingredient_usages (product_id);
@@ -0,0 +1,31 @@
+-- Bundles ("paket") sell a fixed set of products together at a package price.
+CREATE TABLE IF NOT EXISTS bundles (
+    id    SERIAL PRIMARY KEY,
+    name  TEXT NOT NULL,
+    price INT  NOT NULL CHECK (price >= 0)
+);
+
+CREATE TABLE IF NOT EXISTS bundle_items (
+    bundle_id  INT NOT NULL REFERENCES bundles (id) ON DELETE CASCADE,
+    product_id INT NOT NULL REFERENCES products (id),
+    quantity   INT NOT NULL CHECK (quantity > 0),
+    PRIMARY KEY (bundle_id, product_id)
+);
+
+-- A bundle sold is kept with its name and price at the time of sale. Its
+-- components are ordinary transaction details carrying their share of the
+-- package price, so product reports see the revenue where it belongs.
+CREATE TABLE IF NOT EXISTS transaction_bundles (
+    id             SERIAL PRIMARY KEY,
+    transaction_id INT  NOT NULL REFERENCES transactions (id),
+    bundle_id      INT  REFERENCES bundles (id) ON DELETE SET NULL,
+    name           TEXT NOT NULL,
+    price          INT  NOT NULL,
+    quantity       INT  NOT NULL,
+    subtotal       INT  NOT NULL
+);
+
+ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS transaction_bundle_id INT REFERENCES transaction_bundles (id);
+
+CREATE INDEX IF NOT EXISTS idx_transaction_bundles_transaction ON transaction_bundles (transaction_id);
+CREATE INDEX IF NOT EXISTS idx_transaction_bundles_bundle ON transaction_bundles (bundle_id);
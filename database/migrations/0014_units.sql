@@ -0,0 +1,71 @@
+-- Quantities become decimals with three places, so goods can be sold by
+-- weight or volume and recipes use up exact amounts of their components.
+ALTER TABLE products
+    ALTER COLUMN stock TYPE NUMERIC(14,3),
+    ALTER COLUMN min_stock TYPE NUMERIC(14,3),
+    ALTER COLUMN reorder_qty TYPE NUMERIC(14,3);
+ALTER TABLE outlet_stocks ALTER COLUMN stock TYPE NUMERIC(14,3);
+ALTER TABLE stock_movements
+    ALTER COLUMN quantity TYPE NUMERIC(14,3),
+    ALTER COLUMN balance TYPE NUMERIC(14,3);
+ALTER TABLE transaction_details ALTER COLUMN quantity TYPE NUMERIC(14,3);
+ALTER TABLE refund_items ALTER COLUMN quantity TYPE NUMERIC(14,3);
+ALTER TABLE cost_layers
+    ALTER COLUMN quantity TYPE NUMERIC(14,3),
+    ALTER COLUMN remaining TYPE NUMERIC(14,3);
+ALTER TABLE stocktake_items ALTER COLUMN snapshot_quantity TYPE NUMERIC(14,3);
+ALTER TABLE stocktake_counts
+    ALTER COLUMN quantity TYPE NUMERIC(14,3),
+    ALTER COLUMN system_quantity TYPE NUMERIC(14,3);
+ALTER TABLE purchase_order_lines
+    ALTER COLUMN quantity TYPE NUMERIC(14,3),
+    ALTER COLUMN received_quantity TYPE NUMERIC(14,3);
+ALTER TABLE goods_receipt_lines ALTER COLUMN quantity TYPE NUMERIC(14,3);
+ALTER TABLE stock_transfer_lines
+    ALTER COLUMN quantity TYPE NUMERIC(14,3),
+    ALTER COLUMN dispatched_quantity TYPE NUMERIC(14,3),
+    ALTER COLUMN received_quantity TYPE NUMERIC(14,3);
+ALTER TABLE lots ALTER COLUMN remaining TYPE NUMERIC(14,3);
+ALTER TABLE transaction_detail_lots
+    ALTER COLUMN quantity TYPE NUMERIC(14,3),
+    ALTER COLUMN returned_quantity TYPE NUMERIC(14,3);
+ALTER TABLE stock_transfer_line_lots ALTER COLUMN quantity TYPE NUMERIC(14,3);
+ALTER TABLE modifiers ALTER COLUMN quantity TYPE NUMERIC(14,3);
+ALTER TABLE recipe_items ALTER COLUMN quantity TYPE NUMERIC(14,3);
+ALTER TABLE ingredient_usages ALTER COLUMN quantity TYPE NUMERIC(14,3);
+ALTER TABLE bundle_items ALTER COLUMN quantity TYPE NUMERIC(14,3);
+ALTER TABLE transaction_bundles ALTER COLUMN quantity TYPE NUMERIC(14,3);
+
+-- Opened recipe components no longer need tracking apart from stock: what is
+-- left of an opened unit goes back into stock through the ledger.
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'outlet_stocks' AND column_name = 'opened') THEN
+        UPDATE outlet_stocks SET stock = stock + opened WHERE opened > 0;
+        UPDATE products p SET stock = p.stock + o.opened
+        FROM (SELECT product_id, SUM(opened) AS opened FROM outlet_stocks WHERE opened > 0 GROUP BY product_id) o
+        WHERE o.product_id = p.id;
+        INSERT INTO stock_movements (outlet_id, product_id, quantity, reason, balance, note, actor)
+        SELECT outlet_id, product_id, opened, 'adjustment', stock, 'Opened unit remainder', 'system'
+        FROM outlet_stocks
+        WHERE opened > 0;
+        ALTER TABLE outlet_stocks DROP COLUMN opened;
+    END IF;
+END $$;
+
+-- Stock, prices and costs are kept in a product's base unit. Other units
+-- convert to it by factor, such as a box of 12 pcs or a 250 g pack.
+ALTER TABLE products ADD COLUMN IF NOT EXISTS unit TEXT NOT NULL DEFAULT 'pcs';
+ALTER TABLE products ADD COLUMN IF NOT EXISTS purchase_unit TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE IF NOT EXISTS product_units (
+    product_id INT           NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    name       TEXT          NOT NULL,
+    factor     NUMERIC(14,3) NOT NULL CHECK (factor > 0),
+    PRIMARY KEY (product_id, name)
+);
+
+-- Purchase order lines are ordered and costed in a unit of the product;
+-- factor converts their quantities to base units when received.
+ALTER TABLE purchase_order_lines ADD COLUMN IF NOT EXISTS unit TEXT NOT NULL DEFAULT '';
+ALTER TABLE purchase_order_lines ADD COLUMN IF NOT EXISTS factor NUMERIC(14,3) NOT NULL DEFAULT 1 CHECK (factor > 0);
@@ -0,0 +1,21 @@
+-- Every code a product scans by, including its primary products.barcode.
+-- Scale labels register the first seven digits of the label with a scale
+-- kind; the rest of the label carries a weight or price.
+CREATE TABLE IF NOT EXISTS product_barcodes (
+    barcode    TEXT PRIMARY KEY,
+    product_id INT  NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    kind       TEXT NOT NULL CHECK (kind IN ('ean13', 'upca', 'ean8', 'internal', 'scale_weight', 'scale_price'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_barcodes_product ON product_barcodes (product_id);
+
+INSERT INTO product_barcodes (barcode, product_id, kind)
+SELECT barcode, id, CASE
+        WHEN barcode ~ '^[0-9]{13}$' THEN 'ean13'
+        WHEN barcode ~ '^[0-9]{12}$' THEN 'upca'
+        WHEN barcode ~ '^[0-9]{8}$' THEN 'ean8'
+        ELSE 'internal'
+    END
+FROM products
+WHERE barcode IS NOT NULL
+ON CONFLICT (barcode) DO NOTHING;
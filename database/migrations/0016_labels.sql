@@ -0,0 +1,17 @@
+-- A print run records the price each shelf label was printed with, so labels
+-- can be reprinted for products whose price has changed since.
+CREATE TABLE IF NOT EXISTS label_print_runs (
+    id         SERIAL PRIMARY KEY,
+    outlet_id  INT         REFERENCES outlets (id),
+    printed_by TEXT        NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS label_prints (
+    print_run_id INT NOT NULL REFERENCES label_print_runs (id) ON DELETE CASCADE,
+    product_id   INT NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    price        INT NOT NULL,
+    PRIMARY KEY (print_run_id, product_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_label_prints_product ON label_prints (product_id, print_run_id);
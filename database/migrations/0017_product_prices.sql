@@ -0,0 +1,18 @@
+-- Price history: every base price a product has had or is scheduled to have.
+-- The price in effect is the latest entry whose effective_at has passed;
+-- products.price only backs products without any entry.
+CREATE TABLE IF NOT EXISTS product_prices (
+    id           SERIAL PRIMARY KEY,
+    product_id   INT         NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    price        INT         NOT NULL CHECK (price >= 0),
+    effective_at TIMESTAMPTZ NOT NULL,
+    created_by   TEXT        NOT NULL DEFAULT '',
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_prices_product ON product_prices (product_id, effective_at);
+
+INSERT INTO product_prices (product_id, price, effective_at)
+SELECT p.id, p.price, NOW()
+FROM products p
+WHERE NOT EXISTS (SELECT 1 FROM product_prices pp WHERE pp.product_id = p.id);
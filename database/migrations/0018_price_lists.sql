@@ -0,0 +1,39 @@
+-- Price lists set product prices by quantity: an item applies from its
+-- min_quantity, in base units, up to the next item's. Customer groups sell
+-- at their price list; the default list applies to everyone else and to
+-- quantities below a group's first break.
+CREATE TABLE IF NOT EXISTS price_lists (
+    id         SERIAL PRIMARY KEY,
+    name       TEXT        NOT NULL UNIQUE,
+    is_default BOOLEAN     NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_price_lists_default ON price_lists (is_default) WHERE is_default;
+
+CREATE TABLE IF NOT EXISTS price_list_items (
+    price_list_id INT            NOT NULL REFERENCES price_lists (id) ON DELETE CASCADE,
+    product_id    INT            NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    min_quantity  NUMERIC(14, 3) NOT NULL CHECK (min_quantity > 0),
+    price         INT            NOT NULL CHECK (price >= 0),
+    PRIMARY KEY (price_list_id, product_id, min_quantity)
+);
+
+CREATE INDEX IF NOT EXISTS idx_price_list_items_product ON price_list_items (product_id);
+
+CREATE TABLE IF NOT EXISTS customer_groups (
+    id            SERIAL PRIMARY KEY,
+    name          TEXT        NOT NULL UNIQUE,
+    price_list_id INT         REFERENCES price_lists (id) ON DELETE SET NULL,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS customers (
+    id                SERIAL PRIMARY KEY,
+    name              TEXT        NOT NULL,
+    customer_group_id INT         REFERENCES customer_groups (id) ON DELETE SET NULL,
+    created_at        TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS customer_id INT REFERENCES customers (id) ON DELETE SET NULL;
+ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS price_list_id INT REFERENCES price_lists (id) ON DELETE SET NULL;
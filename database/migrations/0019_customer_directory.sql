@@ -0,0 +1,10 @@
+-- Contact details for the customer directory. Phones are stored in E.164
+-- and identify a customer: no two customers share one.
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS phone TEXT;
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS email TEXT NOT NULL DEFAULT '';
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS address TEXT NOT NULL DEFAULT '';
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS tags TEXT[] NOT NULL DEFAULT '{}';
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_customers_phone ON customers (phone);
+CREATE INDEX IF NOT EXISTS idx_customers_tags ON customers USING GIN (tags);
+CREATE INDEX IF NOT EXISTS idx_transactions_customer ON transactions (customer_id, created_at);
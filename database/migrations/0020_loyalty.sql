@@ -0,0 +1,98 @@
+-- Payments record how each sale was settled. A sale may be split across
+-- methods; amount is what the payment covers, so cash handed back as change
+-- is not part of it. Points payments also record the points spent.
+CREATE TABLE IF NOT EXISTS transaction_payments (
+    id             SERIAL PRIMARY KEY,
+    transaction_id INT  NOT NULL REFERENCES transactions (id) ON DELETE CASCADE,
+    method         TEXT NOT NULL,
+    amount         INT  NOT NULL CHECK (amount > 0),
+    points         INT  NOT NULL DEFAULT 0 CHECK (points >= 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_transaction_payments_transaction ON transaction_payments (transaction_id);
+
+-- A refund goes back through the payments of the sale, in proportion to what
+-- each has left to refund.
+CREATE TABLE IF NOT EXISTS refund_payments (
+    id                     SERIAL PRIMARY KEY,
+    refund_id              INT  NOT NULL REFERENCES refunds (id) ON DELETE CASCADE,
+    transaction_payment_id INT  NOT NULL REFERENCES transaction_payments (id) ON DELETE CASCADE,
+    method                 TEXT NOT NULL,
+    amount                 INT  NOT NULL CHECK (amount > 0),
+    points                 INT  NOT NULL DEFAULT 0 CHECK (points >= 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_refund_payments_payment ON refund_payments (transaction_payment_id);
+
+-- Earlier sales and refunds were all cash.
+INSERT INTO transaction_payments (transaction_id, method, amount)
+SELECT t.id, 'cash', t.total_amount
+FROM transactions t
+WHERE t.total_amount > 0
+  AND NOT EXISTS (SELECT 1 FROM transaction_payments tp WHERE tp.transaction_id = t.id);
+
+INSERT INTO refund_payments (refund_id, transaction_payment_id, method, amount)
+SELECT r.id, tp.id, 'cash', r.total_amount
+FROM refunds r
+JOIN transaction_payments tp ON tp.transaction_id = r.transaction_id
+WHERE r.total_amount > 0
+  AND NOT EXISTS (SELECT 1 FROM refund_payments rp WHERE rp.refund_id = r.id);
+
+-- The loyalty programme has one row of settings. Customers earn a point for
+-- every spend_per_point rupiah, and a point redeems for point_value rupiah.
+-- expiry_days of 0 means points never expire.
+CREATE TABLE IF NOT EXISTS loyalty_settings (
+    id              INT         PRIMARY KEY DEFAULT 1 CHECK (id = 1),
+    enabled         BOOLEAN     NOT NULL DEFAULT FALSE,
+    spend_per_point INT         NOT NULL DEFAULT 10000 CHECK (spend_per_point > 0),
+    point_value     INT         NOT NULL DEFAULT 100 CHECK (point_value > 0),
+    min_redeem      INT         NOT NULL DEFAULT 0 CHECK (min_redeem >= 0),
+    expiry_days     INT         NOT NULL DEFAULT 365 CHECK (expiry_days >= 0),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO loyalty_settings (id) VALUES (1) ON CONFLICT (id) DO NOTHING;
+
+-- Rules multiply the spend that earns points, per category or per product; a
+-- product rule wins over its category's. A multiplier of 0 excludes the
+-- product from earning.
+CREATE TABLE IF NOT EXISTS loyalty_rules (
+    id          SERIAL PRIMARY KEY,
+    category_id INT           REFERENCES categories (id) ON DELETE CASCADE,
+    product_id  INT           REFERENCES products (id) ON DELETE CASCADE,
+    multiplier  NUMERIC(6, 3) NOT NULL CHECK (multiplier >= 0),
+    CHECK (num_nonnulls(category_id, product_id) = 1)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_loyalty_rules_category ON loyalty_rules (category_id) WHERE category_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_loyalty_rules_product ON loyalty_rules (product_id) WHERE product_id IS NOT NULL;
+
+-- The points ledger. A customer's balance is the sum of points. Entries that
+-- add points are lots that expire on their own; remaining is what is left of
+-- a lot after redemptions, reversals and expiry used it up, oldest first.
+CREATE TABLE IF NOT EXISTS loyalty_ledger (
+    id             SERIAL PRIMARY KEY,
+    customer_id    INT         NOT NULL REFERENCES customers (id) ON DELETE CASCADE,
+    points         INT         NOT NULL,
+    reason         TEXT        NOT NULL,
+    transaction_id INT         REFERENCES transactions (id) ON DELETE SET NULL,
+    refund_id      INT         REFERENCES refunds (id) ON DELETE SET NULL,
+    note           TEXT        NOT NULL DEFAULT '',
+    expires_at     TIMESTAMPTZ,
+    remaining      INT         NOT NULL DEFAULT 0 CHECK (remaining >= 0),
+    created_by     TEXT        NOT NULL DEFAULT '',
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_loyalty_ledger_customer ON loyalty_ledger (customer_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_loyalty_ledger_lots ON loyalty_ledger (customer_id, expires_at) WHERE remaining > 0;
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS discount_amount INT NOT NULL DEFAULT 0;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS change_amount INT NOT NULL DEFAULT 0;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS points_redeemed INT NOT NULL DEFAULT 0;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS points_earned INT NOT NULL DEFAULT 0;
+ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS discount_amount INT NOT NULL DEFAULT 0;
+ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS points_earned INT NOT NULL DEFAULT 0;
+ALTER TABLE refunds ADD COLUMN IF NOT EXISTS points_reversed INT NOT NULL DEFAULT 0;
+ALTER TABLE refunds ADD COLUMN IF NOT EXISTS points_restored INT NOT NULL DEFAULT 0;
+ALTER TABLE refund_items ADD COLUMN IF NOT EXISTS points_reversed INT NOT NULL DEFAULT 0;
@@ -0,0 +1,42 @@
+-- Gift card products load a new card when sold and hold no stock.
+ALTER TABLE products ADD COLUMN IF NOT EXISTS is_gift_card BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Stored value accounts hold money customers pay with: gift cards, found by
+-- their code, and store credit, one account per customer. The balance never
+-- goes below zero; every change to it is in the ledger.
+CREATE TABLE IF NOT EXISTS stored_value_accounts (
+    id                    SERIAL PRIMARY KEY,
+    kind                  TEXT        NOT NULL CHECK (kind IN ('gift_card', 'store_credit')),
+    code                  TEXT        UNIQUE,
+    customer_id           INT         REFERENCES customers (id) ON DELETE CASCADE,
+    issued_amount         INT         NOT NULL DEFAULT 0,
+    balance               INT         NOT NULL DEFAULT 0 CHECK (balance >= 0),
+    status                TEXT        NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'void')),
+    transaction_detail_id INT         REFERENCES transaction_details (id) ON DELETE SET NULL,
+    created_at            TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (kind <> 'gift_card' OR code IS NOT NULL),
+    CHECK (kind <> 'store_credit' OR customer_id IS NOT NULL)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_stored_value_store_credit ON stored_value_accounts (customer_id) WHERE kind = 'store_credit';
+CREATE INDEX IF NOT EXISTS idx_stored_value_detail ON stored_value_accounts (transaction_detail_id);
+
+CREATE TABLE IF NOT EXISTS stored_value_ledger (
+    id             SERIAL PRIMARY KEY,
+    account_id     INT         NOT NULL REFERENCES stored_value_accounts (id) ON DELETE CASCADE,
+    amount         INT         NOT NULL,
+    balance        INT         NOT NULL,
+    reason         TEXT        NOT NULL,
+    transaction_id INT         REFERENCES transactions (id) ON DELETE SET NULL,
+    refund_id      INT         REFERENCES refunds (id) ON DELETE SET NULL,
+    note           TEXT        NOT NULL DEFAULT '',
+    created_by     TEXT        NOT NULL DEFAULT '',
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_stored_value_ledger_account ON stored_value_ledger (account_id, created_at);
+
+-- Payments with a gift card or store credit name the account they used, and
+-- refunds the account they paid back into.
+ALTER TABLE transaction_payments ADD COLUMN IF NOT EXISTS account_id INT REFERENCES stored_value_accounts (id) ON DELETE SET NULL;
+ALTER TABLE refund_payments ADD COLUMN IF NOT EXISTS account_id INT REFERENCES stored_value_accounts (id) ON DELETE SET NULL;
@@ -0,0 +1,31 @@
+-- Customers with a credit limit may buy on account (kasbon). A limit of 0
+-- means the customer must pay in full at the till.
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS credit_limit INT NOT NULL DEFAULT 0 CHECK (credit_limit >= 0);
+
+-- An on_account payment is an invoice the customer owes. What is still owed
+-- on it is its amount less what refunds credited back to the account and
+-- what repayments were allocated to it.
+CREATE INDEX IF NOT EXISTS idx_transaction_payments_on_account ON transaction_payments (transaction_id) WHERE method = 'on_account';
+
+-- A repayment is money the customer brought in to settle their account,
+-- allocated to one or more of their invoices.
+CREATE TABLE IF NOT EXISTS customer_repayments (
+    id          SERIAL PRIMARY KEY,
+    customer_id INT         NOT NULL REFERENCES customers (id) ON DELETE CASCADE,
+    method      TEXT        NOT NULL,
+    amount      INT         NOT NULL CHECK (amount > 0),
+    note        TEXT        NOT NULL DEFAULT '',
+    created_by  TEXT        NOT NULL DEFAULT '',
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_customer_repayments_customer ON customer_repayments (customer_id, created_at);
+
+CREATE TABLE IF NOT EXISTS repayment_allocations (
+    id                     SERIAL PRIMARY KEY,
+    repayment_id           INT NOT NULL REFERENCES customer_repayments (id) ON DELETE CASCADE,
+    transaction_payment_id INT NOT NULL REFERENCES transaction_payments (id) ON DELETE CASCADE,
+    amount                 INT NOT NULL CHECK (amount > 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_repayment_allocations_payment ON repayment_allocations (transaction_payment_id);
@@ -0,0 +1,71 @@
+-- Stock set aside for layaways. It still counts as stock at the outlet but
+-- cannot be sold to anyone else until the layaway is collected or cancelled.
+ALTER TABLE outlet_stocks ADD COLUMN IF NOT EXISTS reserved NUMERIC(14,3) NOT NULL DEFAULT 0 CHECK (reserved >= 0);
+
+-- Layaways have one row of settings. A layaway needs a deposit of at least
+-- min_deposit_percent of its total and must be paid off within term_days.
+-- A customer who cancels forfeits forfeit_percent of what they paid, and at
+-- least forfeit_min; a layaway cancelled after its due date forfeits
+-- expired_forfeit_percent instead.
+CREATE TABLE IF NOT EXISTS layaway_settings (
+    id                      INT         PRIMARY KEY DEFAULT 1 CHECK (id = 1),
+    min_deposit_percent     INT         NOT NULL DEFAULT 20 CHECK (min_deposit_percent BETWEEN 0 AND 100),
+    term_days               INT         NOT NULL DEFAULT 60 CHECK (term_days > 0),
+    forfeit_percent         INT         NOT NULL DEFAULT 10 CHECK (forfeit_percent BETWEEN 0 AND 100),
+    forfeit_min             INT         NOT NULL DEFAULT 0 CHECK (forfeit_min >= 0),
+    expired_forfeit_percent INT         NOT NULL DEFAULT 50 CHECK (expired_forfeit_percent BETWEEN 0 AND 100),
+    updated_at              TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO layaway_settings (id) VALUES (1) ON CONFLICT (id) DO NOTHING;
+
+-- A layaway holds a customer's goods at the prices of the day it was opened
+-- while they pay in installments. Payments are deposits held until the
+-- layaway is collected, when it becomes a sale, or cancelled, when part of
+-- them is forfeited and the rest paid back.
+CREATE TABLE IF NOT EXISTS layaways (
+    id               SERIAL PRIMARY KEY,
+    outlet_id        INT         NOT NULL REFERENCES outlets (id),
+    customer_id      INT         REFERENCES customers (id) ON DELETE SET NULL,
+    status           TEXT        NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'completed', 'cancelled')),
+    total_amount     INT         NOT NULL CHECK (total_amount > 0),
+    paid_amount      INT         NOT NULL DEFAULT 0 CHECK (paid_amount >= 0),
+    due_at           TIMESTAMPTZ NOT NULL,
+    note             TEXT        NOT NULL DEFAULT '',
+    transaction_id   INT         REFERENCES transactions (id) ON DELETE SET NULL,
+    forfeited_amount INT         NOT NULL DEFAULT 0 CHECK (forfeited_amount >= 0),
+    refunded_amount  INT         NOT NULL DEFAULT 0 CHECK (refunded_amount >= 0),
+    refund_method    TEXT        NOT NULL DEFAULT '',
+    cancel_reason    TEXT        NOT NULL DEFAULT '',
+    created_by       TEXT        NOT NULL DEFAULT '',
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    completed_at     TIMESTAMPTZ,
+    cancelled_at     TIMESTAMPTZ,
+    CHECK (paid_amount <= total_amount)
+);
+
+CREATE INDEX IF NOT EXISTS idx_layaways_status ON layaways (status, due_at);
+CREATE INDEX IF NOT EXISTS idx_layaways_customer ON layaways (customer_id);
+
+CREATE TABLE IF NOT EXISTS layaway_items (
+    id            SERIAL PRIMARY KEY,
+    layaway_id    INT            NOT NULL REFERENCES layaways (id) ON DELETE CASCADE,
+    product_id    INT            NOT NULL REFERENCES products (id),
+    quantity      NUMERIC(14,3)  NOT NULL CHECK (quantity > 0),
+    subtotal      INT            NOT NULL CHECK (subtotal >= 0),
+    price_list_id INT            REFERENCES price_lists (id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_layaway_items_layaway ON layaway_items (layaway_id);
+
+CREATE TABLE IF NOT EXISTS layaway_payments (
+    id         SERIAL PRIMARY KEY,
+    layaway_id INT         NOT NULL REFERENCES layaways (id) ON DELETE CASCADE,
+    method     TEXT        NOT NULL,
+    amount     INT         NOT NULL CHECK (amount > 0),
+    created_by TEXT        NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_layaway_payments_layaway ON layaway_payments (layaway_id);
+CREATE INDEX IF NOT EXISTS idx_layaway_payments_created ON layaway_payments (created_at);
@@ -0,0 +1,65 @@
+-- An order is a table's open tab at a restaurant outlet. Items are added
+-- while the table is served and priced when the bill is split, which
+-- records the order as one sale and takes its stock once.
+CREATE TABLE IF NOT EXISTS orders (
+    id             SERIAL PRIMARY KEY,
+    outlet_id      INT         NOT NULL REFERENCES outlets (id),
+    table_name     TEXT        NOT NULL DEFAULT '',
+    customer_id    INT         REFERENCES customers (id) ON DELETE SET NULL,
+    status         TEXT        NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'split', 'settled', 'cancelled')),
+    split_mode     TEXT        NOT NULL DEFAULT '' CHECK (split_mode IN ('', 'items', 'equal', 'amounts')),
+    total_amount   INT         NOT NULL DEFAULT 0 CHECK (total_amount >= 0),
+    note           TEXT        NOT NULL DEFAULT '',
+    transaction_id INT         REFERENCES transactions (id) ON DELETE SET NULL,
+    cancel_reason  TEXT        NOT NULL DEFAULT '',
+    created_by     TEXT        NOT NULL DEFAULT '',
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    split_at       TIMESTAMPTZ,
+    settled_at     TIMESTAMPTZ,
+    cancelled_at   TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_orders_status ON orders (outlet_id, status);
+CREATE INDEX IF NOT EXISTS idx_orders_transaction ON orders (transaction_id);
+
+-- Each share of a split bill is settled on its own, with its own payments
+-- and receipt.
+CREATE TABLE IF NOT EXISTS order_settlements (
+    id            SERIAL PRIMARY KEY,
+    order_id      INT         NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+    amount        INT         NOT NULL CHECK (amount > 0),
+    change_amount INT         NOT NULL DEFAULT 0 CHECK (change_amount >= 0),
+    status        TEXT        NOT NULL DEFAULT 'unpaid' CHECK (status IN ('unpaid', 'paid')),
+    paid_by       TEXT        NOT NULL DEFAULT '',
+    paid_at       TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_settlements_order ON order_settlements (order_id);
+
+-- Order items are kept as they were ordered, a product, scanned barcode or
+-- bundle with its unit and modifiers. Their name and subtotal are set when
+-- the bill is split, and the settlement paying for them when it is split
+-- by items.
+CREATE TABLE IF NOT EXISTS order_items (
+    id            SERIAL PRIMARY KEY,
+    order_id      INT            NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+    product_id    INT            REFERENCES products (id),
+    barcode       TEXT           NOT NULL DEFAULT '',
+    bundle_id     INT            REFERENCES bundles (id),
+    quantity      NUMERIC(14,3)  NOT NULL DEFAULT 0 CHECK (quantity >= 0),
+    unit          TEXT           NOT NULL DEFAULT '',
+    modifier_ids  INT[]          NOT NULL DEFAULT '{}',
+    name          TEXT           NOT NULL DEFAULT '',
+    subtotal      INT            NOT NULL DEFAULT 0,
+    settlement_id INT            REFERENCES order_settlements (id) ON DELETE SET NULL,
+    created_by    TEXT           NOT NULL DEFAULT '',
+    created_at    TIMESTAMPTZ    NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_items_order ON order_items (order_id);
+
+-- The lines of the sale and the payments each settlement paid for.
+ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS order_settlement_id INT REFERENCES order_settlements (id) ON DELETE SET NULL;
+ALTER TABLE transaction_payments ADD COLUMN IF NOT EXISTS order_settlement_id INT REFERENCES order_settlements (id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_transaction_payments_settlement ON transaction_payments (order_settlement_id) WHERE order_settlement_id IS NOT NULL;
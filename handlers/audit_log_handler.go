@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"cashier-api/models"
+	"cashier-api/response"
+	"cashier-api/services"
+	"encoding/json"
+	"net/http"
+)
+
+type AuditLogHandler struct {
+	service *services.AuditLogService
+}
+
+func NewAuditLogHandler(service *services.AuditLogService) *AuditLogHandler {
+	return &AuditLogHandler{service: service}
+}
+
+func (h *AuditLogHandler) HandleAuditLogs(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetAll(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *AuditLogHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	query := r.URL.Query()
+	from, to, err := parseDateRange(query)
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	entityID, err := queryInt(query, "entity_id")
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	limit, err := queryInt(query, "limit")
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	filter := models.AuditLogFilter{
+		Entity:   query.Get("entity"),
+		EntityID: entityID,
+		Actor:    query.Get("actor"),
+		Action:   query.Get("action"),
+		From:     from,
+		To:       to,
+		Limit:    limit,
+	}
+
+	logs, err := h.service.GetAll(filter)
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Get Audit Log",
+		Data:    logs,
+	}
+	json.NewEncoder(w).Encode(response)
+}
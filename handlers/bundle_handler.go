@@ -0,0 +1,206 @@
+package handlers
+
+import (
+	"cashier-api/models"
+	"cashier-api/response"
+	"cashier-api/services"
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+type BundleHandler struct {
+	service *services.BundleService
+}
+
+func NewBundleHandler(service *services.BundleService) *BundleHandler {
+	return &BundleHandler{service: service}
+}
+
+func (h *BundleHandler) HandleBundles(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetAll(w, r)
+	case http.MethodPost:
+		h.Create(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *BundleHandler) HandleBundleByID(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetByID(w, r)
+	case http.MethodPut:
+		h.Update(w, r)
+	case http.MethodDelete:
+		h.Delete(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *BundleHandler) HandleSalesReport(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetSalesReport(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *BundleHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	bundles, err := h.service.GetAll(requestMeta(r).OutletID)
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Get All Bundle",
+		Data:    bundles,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *BundleHandler) Create(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var bundle models.Bundle
+	err := json.NewDecoder(r.Body).Decode(&bundle)
+	if err != nil {
+		response.ErrorResponse(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	err = h.service.Create(&bundle, requestMeta(r))
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Create Bundle",
+		Data:    bundle,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *BundleHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.ErrorResponse(w, "Invalid bundle ID", http.StatusBadRequest)
+		return
+	}
+
+	bundle, err := h.service.GetByID(id, requestMeta(r).OutletID)
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Get Bundle",
+		Data:    bundle,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *BundleHandler) Update(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.ErrorResponse(w, "Invalid bundle ID", http.StatusBadRequest)
+		return
+	}
+
+	var bundle models.Bundle
+	err = json.NewDecoder(r.Body).Decode(&bundle)
+	if err != nil {
+		response.ErrorResponse(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	bundle.ID = id
+	err = h.service.Update(&bundle, requestMeta(r))
+	if err != nil {
+		status := http.StatusBadRequest
+		if err.Error() == "Bundle not found" {
+			status = http.StatusNotFound
+		}
+		response.ErrorResponse(w, err.Error(), status)
+		return
+	}
+
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Update Bundle",
+		Data:    bundle,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *BundleHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.ErrorResponse(w, "Invalid bundle ID", http.StatusBadRequest)
+		return
+	}
+
+	err = h.service.Delete(id, requestMeta(r))
+	if err != nil {
+		status := http.StatusBadRequest
+		if err.Error() == "Bundle not found" {
+			status = http.StatusNotFound
+		}
+		response.ErrorResponse(w, err.Error(), status)
+		return
+	}
+
+	response := response.Response{
+		Status:  true,
+		Message: "Success delete bundle",
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *BundleHandler) GetSalesReport(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	from, to, err := parseDateRange(r.URL.Query())
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	filter := models.BundleSalesFilter{
+		OutletID: reportOutletID(r),
+		From:     from,
+		To:       to,
+	}
+
+	report, err := h.service.GetSalesReport(filter)
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Get Bundle Sales Report",
+		Data:    report,
+	}
+	json.NewEncoder(w).Encode(response)
+}
@@ -56,7 +56,7 @@ func (h *CategoryHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = h.service.Create(&category)
+	err = h.service.Create(&category, requestMeta(r))
 	if err != nil {
 		response.ErrorResponse(w, err.Error(), http.StatusBadRequest)
 		return
@@ -126,7 +126,7 @@ func (h *CategoryHandler) Update(w http.ResponseWriter, r *http.Request) {
 	}
 
 	category.ID = id
-	err = h.service.Update(&category)
+	err = h.service.Update(&category, requestMeta(r))
 	if err != nil {
 		response.ErrorResponse(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -150,7 +150,7 @@ func (h *CategoryHandler) Delete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = h.service.Delete(id)
+	err = h.service.Delete(id, requestMeta(r))
 	if err != nil {
 		response.ErrorResponse(w, err.Error(), http.StatusBadRequest)
 		return
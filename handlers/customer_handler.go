@@ -0,0 +1,373 @@
+package handlers
+
+import (
+	"cashier-api/models"
+	"cashier-api/response"
+	"cashier-api/services"
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+type CustomerHandler struct {
+	service *services.CustomerService
+}
+
+func NewCustomerHandler(service *services.CustomerService) *CustomerHandler {
+	return &CustomerHandler{service: service}
+}
+
+func (h *CustomerHandler) HandleCustomers(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetAll(w, r)
+	case http.MethodPost:
+		h.Create(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *CustomerHandler) HandleCustomerByID(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetByID(w, r)
+	case http.MethodPut:
+		h.Update(w, r)
+	case http.MethodDelete:
+		h.Delete(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *CustomerHandler) HandleHistory(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetHistory(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *CustomerHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	query := r.URL.Query()
+	limit, err := queryInt(query, "limit")
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	filter := models.CustomerFilter{
+		Query: query.Get("q"),
+		Tag:   query.Get("tag"),
+		Limit: limit,
+	}
+
+	customers, err := h.service.GetAll(filter)
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Get All Customer",
+		Data:    customers,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *CustomerHandler) Create(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var customer models.Customer
+	err := json.NewDecoder(r.Body).Decode(&customer)
+	if err != nil {
+		response.ErrorResponse(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	err = h.service.Create(&customer, requestMeta(r))
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Create Customer",
+		Data:    customer,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *CustomerHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.ErrorResponse(w, "Invalid customer ID", http.StatusBadRequest)
+		return
+	}
+
+	customer, err := h.service.GetByID(id)
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Get Customer",
+		Data:    customer,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *CustomerHandler) GetHistory(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.ErrorResponse(w, "Invalid customer ID", http.StatusBadRequest)
+		return
+	}
+
+	limit, err := queryInt(r.URL.Query(), "limit")
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	history, err := h.service.GetHistory(id, limit)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if err.Error() == "Customer not found" {
+			status = http.StatusNotFound
+		}
+		response.ErrorResponse(w, err.Error(), status)
+		return
+	}
+
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Get Customer History",
+		Data:    history,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *CustomerHandler) Update(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.ErrorResponse(w, "Invalid customer ID", http.StatusBadRequest)
+		return
+	}
+
+	var customer models.Customer
+	err = json.NewDecoder(r.Body).Decode(&customer)
+	if err != nil {
+		response.ErrorResponse(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	customer.ID = id
+	err = h.service.Update(&customer, requestMeta(r))
+	if err != nil {
+		status := http.StatusBadRequest
+		if err.Error() == "Customer not found" {
+			status = http.StatusNotFound
+		}
+		response.ErrorResponse(w, err.Error(), status)
+		return
+	}
+
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Update Customer",
+		Data:    customer,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *CustomerHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.ErrorResponse(w, "Invalid customer ID", http.StatusBadRequest)
+		return
+	}
+
+	err = h.service.Delete(id, requestMeta(r))
+	if err != nil {
+		status := http.StatusBadRequest
+		if err.Error() == "Customer not found" {
+			status = http.StatusNotFound
+		}
+		response.ErrorResponse(w, err.Error(), status)
+		return
+	}
+
+	response := response.Response{
+		Status:  true,
+		Message: "Success delete customer",
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *CustomerHandler) HandleGroups(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetGroups(w, r)
+	case http.MethodPost:
+		h.CreateGroup(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *CustomerHandler) HandleGroupByID(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetGroupByID(w, r)
+	case http.MethodPut:
+		h.UpdateGroup(w, r)
+	case http.MethodDelete:
+		h.DeleteGroup(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *CustomerHandler) GetGroups(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	groups, err := h.service.GetGroups()
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Get All Customer Group",
+		Data:    groups,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *CustomerHandler) CreateGroup(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var group models.CustomerGroup
+	err := json.NewDecoder(r.Body).Decode(&group)
+	if err != nil {
+		response.ErrorResponse(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	err = h.service.CreateGroup(&group, requestMeta(r))
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Create Customer Group",
+		Data:    group,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *CustomerHandler) GetGroupByID(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.ErrorResponse(w, "Invalid customer group ID", http.StatusBadRequest)
+		return
+	}
+
+	group, err := h.service.GetGroupByID(id)
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Get Customer Group",
+		Data:    group,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *CustomerHandler) UpdateGroup(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.ErrorResponse(w, "Invalid customer group ID", http.StatusBadRequest)
+		return
+	}
+
+	var group models.CustomerGroup
+	err = json.NewDecoder(r.Body).Decode(&group)
+	if err != nil {
+		response.ErrorResponse(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	group.ID = id
+	err = h.service.UpdateGroup(&group, requestMeta(r))
+	if err != nil {
+		status := http.StatusBadRequest
+		if err.Error() == "Customer group not found" {
+			status = http.StatusNotFound
+		}
+		response.ErrorResponse(w, err.Error(), status)
+		return
+	}
+
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Update Customer Group",
+		Data:    group,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *CustomerHandler) DeleteGroup(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.ErrorResponse(w, "Invalid customer group ID", http.StatusBadRequest)
+		return
+	}
+
+	err = h.service.DeleteGroup(id, requestMeta(r))
+	if err != nil {
+		status := http.StatusBadRequest
+		if err.Error() == "Customer group not found" {
+			status = http.StatusNotFound
+		}
+		response.ErrorResponse(w, err.Error(), status)
+		return
+	}
+
+	response := response.Response{
+		Status:  true,
+		Message: "Success delete customer group",
+	}
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"cashier-api/response"
+	"cashier-api/services"
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+type InventoryHandler struct {
+	service *services.InventoryService
+}
+
+func NewInventoryHandler(service *services.InventoryService) *InventoryHandler {
+	return &InventoryHandler{service: service}
+}
+
+func (h *InventoryHandler) HandleLowStock(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetLowStock(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *InventoryHandler) HandleSuggestedPurchaseOrders(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		h.CreateSuggestedPurchaseOrders(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *InventoryHandler) HandleValuation(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetValuation(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *InventoryHandler) HandleLots(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetLots(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *InventoryHandler) HandleNearExpiry(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetNearExpiry(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *InventoryHandler) GetLowStock(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	items, err := h.service.GetLowStock(reportOutletID(r))
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Get Low Stock",
+		Data:    items,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *InventoryHandler) CreateSuggestedPurchaseOrders(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	meta, ok := requireOutlet(w, r)
+	if !ok {
+		return
+	}
+
+	result, err := h.service.CreateSuggestedPurchaseOrders(meta)
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Create Suggested Purchase Order",
+		Data:    result,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *InventoryHandler) GetValuation(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	valuation, err := h.service.GetValuation()
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Get Inventory Valuation",
+		Data:    valuation,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *InventoryHandler) GetLots(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	productID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.ErrorResponse(w, "Invalid product ID", http.StatusBadRequest)
+		return
+	}
+
+	lots, err := h.service.GetLots(productID, reportOutletID(r))
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Get Lots",
+		Data:    lots,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetNearExpiry reports lots expiring within days (default 30).
+func (h *InventoryHandler) GetNearExpiry(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	days, err := queryInt(r.URL.Query(), "days")
+	if err != nil || days < 0 {
+		response.ErrorResponse(w, "Invalid days", http.StatusBadRequest)
+		return
+	}
+	if r.URL.Query().Get("days") == "" {
+		days = 30
+	}
+
+	report, err := h.service.GetNearExpiry(days, reportOutletID(r))
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Get Near Expiry",
+		Data:    report,
+	}
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,245 @@
+package handlers
+
+import (
+	"cashier-api/labels"
+	"cashier-api/models"
+	"cashier-api/response"
+	"cashier-api/services"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+type LabelHandler struct {
+	service *services.LabelService
+}
+
+func NewLabelHandler(service *services.LabelService) *LabelHandler {
+	return &LabelHandler{service: service}
+}
+
+func (h *LabelHandler) HandleProductLabel(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetProductLabel(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *LabelHandler) HandleLabels(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetLabels(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *LabelHandler) HandleChanged(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetChanged(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *LabelHandler) HandlePrintRuns(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		h.CreatePrintRun(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// GetProductLabel renders one product's label, one label per page unless a
+// grid is given.
+func (h *LabelHandler) GetProductLabel(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		response.ErrorResponse(w, "Invalid product ID", http.StatusBadRequest)
+		return
+	}
+
+	products, err := h.service.GetProducts([]int{id}, requestMeta(r).OutletID)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		response.ErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if len(products) == 0 {
+		w.Header().Set("Content-Type", "application/json")
+		response.ErrorResponse(w, "Product not found", http.StatusNotFound)
+		return
+	}
+
+	writeLabels(w, r, products, labels.SingleGrid)
+}
+
+// GetLabels previews a sheet of labels for the products in product_ids
+// without recording a print run.
+func (h *LabelHandler) GetLabels(w http.ResponseWriter, r *http.Request) {
+	ids, err := queryIDs(r.URL.Query(), "product_ids")
+	if err == nil && len(ids) == 0 {
+		err = errors.New("product_ids is required")
+	}
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		response.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	products, err := h.service.GetProducts(ids, requestMeta(r).OutletID)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		response.ErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeLabels(w, r, products, labels.DefaultGrid)
+}
+
+func (h *LabelHandler) GetChanged(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	changes, err := h.service.GetChanged(requestMeta(r).OutletID)
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Get Changed Labels",
+		Data:    changes,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// CreatePrintRun prints and records a run of labels, as a PDF unless another
+// format is asked for. Nothing to print answers 204 No Content.
+func (h *LabelHandler) CreatePrintRun(w http.ResponseWriter, r *http.Request) {
+	var req models.LabelPrintRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		response.ErrorResponse(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	query := r.URL.Query()
+	if query.Get("format") == "" {
+		query.Set("format", labels.FormatPDF)
+		r.URL.RawQuery = query.Encode()
+	}
+	if _, _, _, err := labelOptions(r, labels.DefaultGrid); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		response.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	run, err := h.service.CreatePrintRun(req, requestMeta(r))
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		response.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(run.Products) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	w.Header().Set("X-Print-Run-ID", strconv.Itoa(run.ID))
+	writeLabels(w, r, run.Products, labels.DefaultGrid)
+}
+
+// writeLabels renders products as labels in the format and grid asked for
+// in the query, starting from base.
+func writeLabels(w http.ResponseWriter, r *http.Request, products []models.Product, base labels.Grid) {
+	format, grid, page, err := labelOptions(r, base)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		response.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	sheet := make([]labels.Label, 0, len(products))
+	for _, product := range products {
+		sheet = append(sheet, labels.FromProduct(product))
+	}
+	data, contentType, err := labels.Render(format, sheet, grid, page)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		response.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Write(data)
+}
+
+// labelOptions reads format (svg by default), page, and the grid settings
+// columns, rows, width, height, gap and margin, in millimetres.
+func labelOptions(r *http.Request, grid labels.Grid) (string, labels.Grid, int, error) {
+	query := r.URL.Query()
+
+	format := query.Get("format")
+	if format == "" {
+		format = labels.FormatSVG
+	}
+	page, err := queryInt(query, "page")
+	if err != nil {
+		return "", grid, 0, err
+	}
+	if page == 0 {
+		page = 1
+	}
+
+	ints := map[string]*int{"columns": &grid.Columns, "rows": &grid.Rows}
+	for key, target := range ints {
+		n, err := queryInt(query, key)
+		if err != nil {
+			return "", grid, 0, err
+		}
+		if n != 0 {
+			*target = n
+		}
+	}
+	floats := map[string]*float64{"width": &grid.LabelWidth, "height": &grid.LabelHeight, "gap": &grid.Gap, "margin": &grid.Margin}
+	for key, target := range floats {
+		value := query.Get(key)
+		if value == "" {
+			continue
+		}
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+			return "", grid, 0, errors.New("Invalid " + key)
+		}
+		*target = f
+	}
+
+	return format, grid, page, grid.Validate()
+}
+
+// queryIDs reads a comma separated list of IDs.
+func queryIDs(query url.Values, key string) ([]int, error) {
+	ids := make([]int, 0)
+	for _, part := range strings.Split(query.Get(key), ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		id, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, errors.New("Invalid " + key)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
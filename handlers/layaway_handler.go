@@ -0,0 +1,359 @@
+package handlers
+
+import (
+	"cashier-api/models"
+	"cashier-api/response"
+	"cashier-api/services"
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+type LayawayHandler struct {
+	service *services.LayawayService
+}
+
+func NewLayawayHandler(service *services.LayawayService) *LayawayHandler {
+	return &LayawayHandler{service: service}
+}
+
+func (h *LayawayHandler) HandleLayaways(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetAll(w, r)
+	case http.MethodPost:
+		h.Create(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *LayawayHandler) HandleLayawayByID(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetByID(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *LayawayHandler) HandlePayments(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		h.AddPayment(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *LayawayHandler) HandleComplete(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		h.Complete(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *LayawayHandler) HandleCancel(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		h.Cancel(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *LayawayHandler) HandleSettings(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetSettings(w, r)
+	case http.MethodPut:
+		h.UpdateSettings(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *LayawayHandler) HandleReport(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetReport(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *LayawayHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	query := r.URL.Query()
+	customerID, err := queryInt(query, "customer_id")
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	limit, err := queryInt(query, "limit")
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	filter := models.LayawayFilter{
+		Status:     query.Get("status"),
+		CustomerID: customerID,
+		OutletID:   reportOutletID(r),
+		Limit:      limit,
+	}
+
+	layaways, err := h.service.GetAll(filter)
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Get All Layaway",
+		Data:    layaways,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *LayawayHandler) Create(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	meta, ok := requireOutlet(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.LayawayRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		response.ErrorResponse(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	layaway, err := h.service.Create(req, meta)
+	if err != nil {
+		status := http.StatusBadRequest
+		if err.Error() == "Customer not found" {
+			status = http.StatusNotFound
+		}
+		response.ErrorResponse(w, err.Error(), status)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Create Layaway",
+		Data:    layaway,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *LayawayHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.ErrorResponse(w, "Invalid layaway ID", http.StatusBadRequest)
+		return
+	}
+
+	layaway, err := h.service.GetByID(id)
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Get Layaway",
+		Data:    layaway,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *LayawayHandler) AddPayment(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	meta, ok := requireOutlet(w, r)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.ErrorResponse(w, "Invalid layaway ID", http.StatusBadRequest)
+		return
+	}
+
+	var payment models.Payment
+	err = json.NewDecoder(r.Body).Decode(&payment)
+	if err != nil {
+		response.ErrorResponse(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	layaway, err := h.service.AddPayment(id, payment, meta)
+	if err != nil {
+		status := http.StatusBadRequest
+		if err.Error() == "Layaway not found" {
+			status = http.StatusNotFound
+		}
+		response.ErrorResponse(w, err.Error(), status)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Add Layaway Payment",
+		Data:    layaway,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *LayawayHandler) Complete(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	meta, ok := requireOutlet(w, r)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.ErrorResponse(w, "Invalid layaway ID", http.StatusBadRequest)
+		return
+	}
+
+	layaway, err := h.service.Complete(id, meta)
+	if err != nil {
+		status := http.StatusBadRequest
+		if err.Error() == "Layaway not found" {
+			status = http.StatusNotFound
+		}
+		response.ErrorResponse(w, err.Error(), status)
+		return
+	}
+
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Complete Layaway",
+		Data:    layaway,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *LayawayHandler) Cancel(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	meta, ok := requireOutlet(w, r)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.ErrorResponse(w, "Invalid layaway ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.LayawayCancelRequest
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		response.ErrorResponse(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	layaway, err := h.service.Cancel(id, req, meta)
+	if err != nil {
+		status := http.StatusBadRequest
+		if err.Error() == "Layaway not found" {
+			status = http.StatusNotFound
+		}
+		response.ErrorResponse(w, err.Error(), status)
+		return
+	}
+
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Cancel Layaway",
+		Data:    layaway,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *LayawayHandler) GetSettings(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	settings, err := h.service.GetSettings()
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Get Layaway Settings",
+		Data:    settings,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *LayawayHandler) UpdateSettings(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var settings models.LayawaySettings
+	err := json.NewDecoder(r.Body).Decode(&settings)
+	if err != nil {
+		response.ErrorResponse(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	err = h.service.UpdateSettings(&settings, requestMeta(r))
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Update Layaway Settings",
+		Data:    settings,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *LayawayHandler) GetReport(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	from, to, err := parseDateRange(r.URL.Query())
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	filter := models.LayawayReportFilter{
+		OutletID: reportOutletID(r),
+		From:     from,
+		To:       to,
+	}
+
+	report, err := h.service.GetReport(filter)
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Get Layaway Report",
+		Data:    report,
+	}
+	json.NewEncoder(w).Encode(response)
+}
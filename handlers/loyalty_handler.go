@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"cashier-api/models"
+	"cashier-api/response"
+	"cashier-api/services"
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+type LoyaltyHandler struct {
+	service *services.LoyaltyService
+}
+
+func NewLoyaltyHandler(service *services.LoyaltyService) *LoyaltyHandler {
+	return &LoyaltyHandler{service: service}
+}
+
+func (h *LoyaltyHandler) HandleSettings(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetSettings(w, r)
+	case http.MethodPut:
+		h.UpdateSettings(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *LoyaltyHandler) HandlePoints(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetAccount(w, r)
+	case http.MethodPost:
+		h.Adjust(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *LoyaltyHandler) GetSettings(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	settings, err := h.service.GetSettings()
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Get Loyalty Settings",
+		Data:    settings,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *LoyaltyHandler) UpdateSettings(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var settings models.LoyaltySettings
+	err := json.NewDecoder(r.Body).Decode(&settings)
+	if err != nil {
+		response.ErrorResponse(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	err = h.service.UpdateSettings(&settings, requestMeta(r))
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Update Loyalty Settings",
+		Data:    settings,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *LoyaltyHandler) GetAccount(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.ErrorResponse(w, "Invalid customer ID", http.StatusBadRequest)
+		return
+	}
+
+	limit, err := queryInt(r.URL.Query(), "limit")
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	account, err := h.service.GetAccount(id, limit, requestMeta(r))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if err.Error() == "Customer not found" {
+			status = http.StatusNotFound
+		}
+		response.ErrorResponse(w, err.Error(), status)
+		return
+	}
+
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Get Points",
+		Data:    account,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *LoyaltyHandler) Adjust(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.ErrorResponse(w, "Invalid customer ID", http.StatusBadRequest)
+		return
+	}
+
+	var adjustment models.PointsAdjustment
+	err = json.NewDecoder(r.Body).Decode(&adjustment)
+	if err != nil {
+		response.ErrorResponse(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	entry, err := h.service.Adjust(id, adjustment, requestMeta(r))
+	if err != nil {
+		status := http.StatusBadRequest
+		if err.Error() == "Customer not found" {
+			status = http.StatusNotFound
+		}
+		response.ErrorResponse(w, err.Error(), status)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Adjust Points",
+		Data:    entry,
+	}
+	json.NewEncoder(w).Encode(response)
+}
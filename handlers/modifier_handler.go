@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"cashier-api/models"
+	"cashier-api/response"
+	"cashier-api/services"
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+type ModifierHandler struct {
+	service *services.ModifierService
+}
+
+func NewModifierHandler(service *services.ModifierService) *ModifierHandler {
+	return &ModifierHandler{service: service}
+}
+
+func (h *ModifierHandler) HandleModifierGroups(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetAll(w, r)
+	case http.MethodPost:
+		h.Create(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *ModifierHandler) HandleModifierGroupByID(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetByID(w, r)
+	case http.MethodPut:
+		h.Update(w, r)
+	case http.MethodDelete:
+		h.Delete(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *ModifierHandler) HandleProductModifierGroups(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetProductGroups(w, r)
+	case http.MethodPut:
+		h.SetProductGroups(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *ModifierHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	groups, err := h.service.GetAll()
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Get All Modifier Group",
+		Data:    groups,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *ModifierHandler) Create(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var group models.ModifierGroup
+	err := json.NewDecoder(r.Body).Decode(&group)
+	if err != nil {
+		response.ErrorResponse(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	err = h.service.Create(&group, requestMeta(r))
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Create Modifier Group",
+		Data:    group,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *ModifierHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.ErrorResponse(w, "Invalid modifier group ID", http.StatusBadRequest)
+		return
+	}
+
+	group, err := h.service.GetByID(id)
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Get Modifier Group",
+		Data:    group,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *ModifierHandler) Update(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.ErrorResponse(w, "Invalid modifier group ID", http.StatusBadRequest)
+		return
+	}
+
+	var group models.ModifierGroup
+	err = json.NewDecoder(r.Body).Decode(&group)
+	if err != nil {
+		response.ErrorResponse(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	group.ID = id
+	err = h.service.Update(&group, requestMeta(r))
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), modifierErrorStatus(err))
+		return
+	}
+
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Update Modifier Group",
+		Data:    group,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *ModifierHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.ErrorResponse(w, "Invalid modifier group ID", http.StatusBadRequest)
+		return
+	}
+
+	err = h.service.Delete(id, requestMeta(r))
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), modifierErrorStatus(err))
+		return
+	}
+
+	response := response.Response{
+		Status:  true,
+		Message: "Success delete modifier group",
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *ModifierHandler) GetProductGroups(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.ErrorResponse(w, "Invalid product ID", http.StatusBadRequest)
+		return
+	}
+
+	groups, err := h.service.GetProductGroups(id)
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Get Product Modifier Groups",
+		Data:    groups,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *ModifierHandler) SetProductGroups(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.ErrorResponse(w, "Invalid product ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.ProductModifierGroupsRequest
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		response.ErrorResponse(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	groups, err := h.service.SetProductGroups(id, req.ModifierGroupIDs)
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), modifierErrorStatus(err))
+		return
+	}
+
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Set Product Modifier Groups",
+		Data:    groups,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func modifierErrorStatus(err error) int {
+	switch err.Error() {
+	case "Modifier group not found", "Product not found":
+		return http.StatusNotFound
+	}
+	return http.StatusBadRequest
+}
@@ -0,0 +1,431 @@
+package handlers
+
+import (
+	"cashier-api/models"
+	"cashier-api/receipts"
+	"cashier-api/response"
+	"cashier-api/services"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+type OrderHandler struct {
+	service *services.OrderService
+}
+
+func NewOrderHandler(service *services.OrderService) *OrderHandler {
+	return &OrderHandler{service: service}
+}
+
+func (h *OrderHandler) HandleOrders(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetAll(w, r)
+	case http.MethodPost:
+		h.Create(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *OrderHandler) HandleOrderByID(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetByID(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *OrderHandler) HandleItems(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		h.AddItems(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *OrderHandler) HandleItemByID(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodDelete:
+		h.RemoveItem(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *OrderHandler) HandleSplit(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		h.Split(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *OrderHandler) HandleSettlementPayments(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		h.PaySettlement(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *OrderHandler) HandleSettlementReceipt(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetSettlementReceipt(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *OrderHandler) HandleCancel(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		h.Cancel(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *OrderHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	query := r.URL.Query()
+	limit, err := queryInt(query, "limit")
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	filter := models.OrderFilter{
+		Status:   query.Get("status"),
+		OutletID: reportOutletID(r),
+		Limit:    limit,
+	}
+
+	orders, err := h.service.GetAll(filter)
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Get All Order",
+		Data:    orders,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *OrderHandler) Create(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	meta, ok := requireOutlet(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.OrderRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		response.ErrorResponse(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	order, err := h.service.Create(req, meta)
+	if err != nil {
+		status := http.StatusBadRequest
+		if err.Error() == "Customer not found" {
+			status = http.StatusNotFound
+		}
+		response.ErrorResponse(w, err.Error(), status)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Create Order",
+		Data:    order,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *OrderHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.ErrorResponse(w, "Invalid order ID", http.StatusBadRequest)
+		return
+	}
+
+	order, err := h.service.GetByID(id)
+	if err != nil {
+		status := http.StatusBadRequest
+		if err.Error() == "Order not found" {
+			status = http.StatusNotFound
+		}
+		response.ErrorResponse(w, err.Error(), status)
+		return
+	}
+
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Get Order",
+		Data:    order,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *OrderHandler) AddItems(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	meta, ok := requireOutlet(w, r)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.ErrorResponse(w, "Invalid order ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.OrderItemsRequest
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		response.ErrorResponse(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	order, err := h.service.AddItems(id, req, meta)
+	if err != nil {
+		status := http.StatusBadRequest
+		if err.Error() == "Order not found" {
+			status = http.StatusNotFound
+		}
+		response.ErrorResponse(w, err.Error(), status)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Add Order Items",
+		Data:    order,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *OrderHandler) RemoveItem(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	meta, ok := requireOutlet(w, r)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.ErrorResponse(w, "Invalid order ID", http.StatusBadRequest)
+		return
+	}
+
+	itemID, err := strconv.Atoi(r.PathValue("item_id"))
+	if err != nil {
+		response.ErrorResponse(w, "Invalid order item ID", http.StatusBadRequest)
+		return
+	}
+
+	order, err := h.service.RemoveItem(id, itemID, meta)
+	if err != nil {
+		status := http.StatusBadRequest
+		if err.Error() == "Order not found" || err.Error() == "Order item not found" {
+			status = http.StatusNotFound
+		}
+		response.ErrorResponse(w, err.Error(), status)
+		return
+	}
+
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Remove Order Item",
+		Data:    order,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *OrderHandler) Split(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	meta, ok := requireOutlet(w, r)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.ErrorResponse(w, "Invalid order ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.SplitRequest
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		response.ErrorResponse(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	order, err := h.service.Split(id, req, meta)
+	if err != nil {
+		status := http.StatusBadRequest
+		if err.Error() == "Order not found" {
+			status = http.StatusNotFound
+		}
+		response.ErrorResponse(w, err.Error(), status)
+		return
+	}
+
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Split Order",
+		Data:    order,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *OrderHandler) PaySettlement(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	meta, ok := requireOutlet(w, r)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.ErrorResponse(w, "Invalid order ID", http.StatusBadRequest)
+		return
+	}
+
+	settlementID, err := strconv.Atoi(r.PathValue("settlement_id"))
+	if err != nil {
+		response.ErrorResponse(w, "Invalid settlement ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.SettlementRequest
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		response.ErrorResponse(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	order, err := h.service.PaySettlement(id, settlementID, req, meta)
+	if err != nil {
+		status := http.StatusBadRequest
+		if err.Error() == "Order not found" || err.Error() == "Settlement not found" {
+			status = http.StatusNotFound
+		}
+		response.ErrorResponse(w, err.Error(), status)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Pay Settlement",
+		Data:    order,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetSettlementReceipt prints one share of a split bill as plain text,
+// ready to send to a receipt printer.
+func (h *OrderHandler) GetSettlementReceipt(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		response.ErrorResponse(w, "Invalid order ID", http.StatusBadRequest)
+		return
+	}
+
+	settlementID, err := strconv.Atoi(r.PathValue("settlement_id"))
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		response.ErrorResponse(w, "Invalid settlement ID", http.StatusBadRequest)
+		return
+	}
+
+	order, transaction, err := h.service.GetReceipt(id)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		status := http.StatusBadRequest
+		if err.Error() == "Order not found" {
+			status = http.StatusNotFound
+		}
+		response.ErrorResponse(w, err.Error(), status)
+		return
+	}
+
+	var settlement *models.OrderSettlement
+	for i := range order.Settlements {
+		if order.Settlements[i].ID == settlementID {
+			settlement = &order.Settlements[i]
+		}
+	}
+	if settlement == nil {
+		w.Header().Set("Content-Type", "application/json")
+		response.ErrorResponse(w, "Settlement not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	io.WriteString(w, receipts.Settlement(order, settlement, transaction))
+}
+
+func (h *OrderHandler) Cancel(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	meta, ok := requireOutlet(w, r)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.ErrorResponse(w, "Invalid order ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.OrderCancelRequest
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		response.ErrorResponse(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	order, err := h.service.Cancel(id, req, meta)
+	if err != nil {
+		status := http.StatusBadRequest
+		if err.Error() == "Order not found" {
+			status = http.StatusNotFound
+		}
+		response.ErrorResponse(w, err.Error(), status)
+		return
+	}
+
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Cancel Order",
+		Data:    order,
+	}
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,351 @@
+package handlers
+
+import (
+	"cashier-api/models"
+	"cashier-api/response"
+	"cashier-api/services"
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+type OutletHandler struct {
+	service *services.OutletService
+}
+
+func NewOutletHandler(service *services.OutletService) *OutletHandler {
+	return &OutletHandler{service: service}
+}
+
+func (h *OutletHandler) HandleOutlets(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetAll(w, r)
+	case http.MethodPost:
+		h.Create(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *OutletHandler) HandleOutletByID(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetByID(w, r)
+	case http.MethodPut:
+		h.Update(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *OutletHandler) HandleTerminals(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetTerminals(w, r)
+	case http.MethodPost:
+		h.CreateTerminal(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *OutletHandler) HandleTerminalByID(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodDelete:
+		h.DeleteTerminal(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *OutletHandler) HandlePrice(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPut:
+		h.SetPrice(w, r)
+	case http.MethodDelete:
+		h.DeletePrice(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *OutletHandler) HandleReport(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetReport(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *OutletHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	outlets, err := h.service.GetAll()
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Get All Outlet",
+		Data:    outlets,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *OutletHandler) Create(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var outlet models.Outlet
+	err := json.NewDecoder(r.Body).Decode(&outlet)
+	if err != nil {
+		response.ErrorResponse(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	err = h.service.Create(&outlet, requestMeta(r))
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Create Outlet",
+		Data:    outlet,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *OutletHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.ErrorResponse(w, "Invalid outlet ID", http.StatusBadRequest)
+		return
+	}
+
+	outlet, err := h.service.GetByID(id)
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Get Outlet",
+		Data:    outlet,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *OutletHandler) Update(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.ErrorResponse(w, "Invalid outlet ID", http.StatusBadRequest)
+		return
+	}
+
+	var outlet models.Outlet
+	err = json.NewDecoder(r.Body).Decode(&outlet)
+	if err != nil {
+		response.ErrorResponse(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	outlet.ID = id
+	err = h.service.Update(&outlet, requestMeta(r))
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Update Outlet",
+		Data:    outlet,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *OutletHandler) GetTerminals(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.ErrorResponse(w, "Invalid outlet ID", http.StatusBadRequest)
+		return
+	}
+
+	terminals, err := h.service.GetTerminals(id)
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Get All Terminal",
+		Data:    terminals,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *OutletHandler) CreateTerminal(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.ErrorResponse(w, "Invalid outlet ID", http.StatusBadRequest)
+		return
+	}
+
+	var terminal models.Terminal
+	err = json.NewDecoder(r.Body).Decode(&terminal)
+	if err != nil {
+		response.ErrorResponse(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	terminal.OutletID = id
+	err = h.service.CreateTerminal(&terminal)
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Create Terminal",
+		Data:    terminal,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *OutletHandler) DeleteTerminal(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.ErrorResponse(w, "Invalid outlet ID", http.StatusBadRequest)
+		return
+	}
+
+	terminalID, err := strconv.Atoi(r.PathValue("terminal_id"))
+	if err != nil {
+		response.ErrorResponse(w, "Invalid terminal ID", http.StatusBadRequest)
+		return
+	}
+
+	err = h.service.DeleteTerminal(id, terminalID)
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response := response.Response{
+		Status:  true,
+		Message: "Success delete terminal",
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *OutletHandler) SetPrice(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	price, ok := outletPriceFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	var body struct {
+		Price *int `json:"price"`
+	}
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil || body.Price == nil {
+		response.ErrorResponse(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	price.Price = body.Price
+	err = h.service.SetPrice(price, requestMeta(r))
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Set Outlet Price",
+		Data:    price,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *OutletHandler) DeletePrice(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	price, ok := outletPriceFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	err := h.service.SetPrice(price, requestMeta(r))
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response := response.Response{
+		Status:  true,
+		Message: "Success delete outlet price",
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func outletPriceFromPath(w http.ResponseWriter, r *http.Request) (*models.OutletPrice, bool) {
+	outletID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.ErrorResponse(w, "Invalid outlet ID", http.StatusBadRequest)
+		return nil, false
+	}
+
+	productID, err := strconv.Atoi(r.PathValue("product_id"))
+	if err != nil {
+		response.ErrorResponse(w, "Invalid product ID", http.StatusBadRequest)
+		return nil, false
+	}
+
+	return &models.OutletPrice{OutletID: outletID, ProductID: productID}, true
+}
+
+func (h *OutletHandler) GetReport(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	from, to, err := parseDateRange(r.URL.Query())
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.service.GetReport(from, to)
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Get Outlet Report",
+		Data:    report,
+	}
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"cashier-api/models"
+	"cashier-api/response"
+	"cashier-api/services"
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+type ParentProductHandler struct {
+	service *services.ParentProductService
+}
+
+func NewParentProductHandler(service *services.ParentProductService) *ParentProductHandler {
+	return &ParentProductHandler{service: service}
+}
+
+func (h *ParentProductHandler) HandleParentProducts(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetAll(w, r)
+	case http.MethodPost:
+		h.Create(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *ParentProductHandler) HandleParentProductByID(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetByID(w, r)
+	case http.MethodPut:
+		h.Update(w, r)
+	case http.MethodDelete:
+		h.Delete(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *ParentProductHandler) HandleCatalog(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetCatalog(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *ParentProductHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	parents, err := h.service.GetAll()
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Get All Parent Product",
+		Data:    parents,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *ParentProductHandler) Create(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var parent models.ParentProduct
+	err := json.NewDecoder(r.Body).Decode(&parent)
+	if err != nil {
+		response.ErrorResponse(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	err = h.service.Create(&parent, requestMeta(r))
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Create Parent Product",
+		Data:    parent,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *ParentProductHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.ErrorResponse(w, "Invalid parent product ID", http.StatusBadRequest)
+		return
+	}
+
+	parent, err := h.service.GetByID(id, requestMeta(r).OutletID)
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Get Parent Product",
+		Data:    parent,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *ParentProductHandler) Update(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.ErrorResponse(w, "Invalid parent product ID", http.StatusBadRequest)
+		return
+	}
+
+	var parent models.ParentProduct
+	err = json.NewDecoder(r.Body).Decode(&parent)
+	if err != nil {
+		response.ErrorResponse(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	parent.ID = id
+	err = h.service.Update(&parent, requestMeta(r))
+	if err != nil {
+		status := http.StatusBadRequest
+		if err.Error() == "Parent product not found" {
+			status = http.StatusNotFound
+		}
+		response.ErrorResponse(w, err.Error(), status)
+		return
+	}
+
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Update Parent Product",
+		Data:    parent,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *ParentProductHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.ErrorResponse(w, "Invalid parent product ID", http.StatusBadRequest)
+		return
+	}
+
+	err = h.service.Delete(id, requestMeta(r))
+	if err != nil {
+		status := http.StatusBadRequest
+		if err.Error() == "Parent product not found" {
+			status = http.StatusNotFound
+		}
+		response.ErrorResponse(w, err.Error(), status)
+		return
+	}
+
+	response := response.Response{
+		Status:  true,
+		Message: "Success delete parent product",
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *ParentProductHandler) GetCatalog(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	catalog, err := h.service.GetCatalog(requestMeta(r).OutletID)
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Get Catalog",
+		Data:    catalog,
+	}
+	json.NewEncoder(w).Encode(response)
+}
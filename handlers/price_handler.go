@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"cashier-api/models"
+	"cashier-api/response"
+	"cashier-api/services"
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+type PriceHandler struct {
+	service *services.PriceService
+}
+
+func NewPriceHandler(service *services.PriceService) *PriceHandler {
+	return &PriceHandler{service: service}
+}
+
+func (h *PriceHandler) HandlePrices(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetHistory(w, r)
+	case http.MethodPost:
+		h.Schedule(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *PriceHandler) HandlePriceByID(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodDelete:
+		h.Cancel(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *PriceHandler) GetHistory(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.ErrorResponse(w, "Invalid product ID", http.StatusBadRequest)
+		return
+	}
+
+	history, err := h.service.GetHistory(id)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if err.Error() == "Product not found" {
+			status = http.StatusNotFound
+		}
+		response.ErrorResponse(w, err.Error(), status)
+		return
+	}
+
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Get Price History",
+		Data:    history,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *PriceHandler) Schedule(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.ErrorResponse(w, "Invalid product ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.ProductPriceRequest
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		response.ErrorResponse(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	price, err := h.service.Schedule(id, req, requestMeta(r))
+	if err != nil {
+		status := http.StatusBadRequest
+		if err.Error() == "Product not found" {
+			status = http.StatusNotFound
+		}
+		response.ErrorResponse(w, err.Error(), status)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Schedule Price",
+		Data:    price,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *PriceHandler) Cancel(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.ErrorResponse(w, "Invalid product ID", http.StatusBadRequest)
+		return
+	}
+
+	priceID, err := strconv.Atoi(r.PathValue("price_id"))
+	if err != nil {
+		response.ErrorResponse(w, "Invalid price ID", http.StatusBadRequest)
+		return
+	}
+
+	err = h.service.Cancel(id, priceID, requestMeta(r))
+	if err != nil {
+		status := http.StatusBadRequest
+		if err.Error() == "Price not found" {
+			status = http.StatusNotFound
+		}
+		response.ErrorResponse(w, err.Error(), status)
+		return
+	}
+
+	response := response.Response{
+		Status:  true,
+		Message: "Success cancel price",
+	}
+	json.NewEncoder(w).Encode(response)
+}
@@ -34,7 +34,7 @@ func (h *ProductHandler) GetAll(w http.ResponseWriter, r *http.Request) {
 
 	name := r.URL.Query().Get("name")
 
-	products, err := h.service.GetAll(name)
+	products, err := h.service.GetAll(name, requestMeta(r).OutletID)
 	if err != nil {
 		response.ErrorResponse(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -58,7 +58,7 @@ func (h *ProductHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = h.service.Create(&product)
+	err = h.service.Create(&product, requestMeta(r))
 	if err != nil {
 		response.ErrorResponse(w, err.Error(), http.StatusBadRequest)
 		return
@@ -86,6 +86,42 @@ func (h *ProductHandler) HandleProductByID(w http.ResponseWriter, r *http.Reques
 	}
 }
 
+func (h *ProductHandler) HandleLookup(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.Lookup(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *ProductHandler) Lookup(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	barcode := r.URL.Query().Get("barcode")
+	if barcode == "" {
+		response.ErrorResponse(w, "barcode is required", http.StatusBadRequest)
+		return
+	}
+
+	match, err := h.service.Lookup(barcode, requestMeta(r).OutletID)
+	if err != nil {
+		status := http.StatusBadRequest
+		if err.Error() == "Barcode not found" {
+			status = http.StatusNotFound
+		}
+		response.ErrorResponse(w, err.Error(), status)
+		return
+	}
+
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Lookup Product",
+		Data:    match,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
 func (h *ProductHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
@@ -96,7 +132,7 @@ func (h *ProductHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	product, err := h.service.GetByID(id)
+	product, err := h.service.GetByID(id, requestMeta(r).OutletID)
 	if err != nil {
 		response.ErrorResponse(w, err.Error(), http.StatusNotFound)
 		return
@@ -128,7 +164,7 @@ func (h *ProductHandler) Update(w http.ResponseWriter, r *http.Request) {
 	}
 
 	product.ID = id
-	err = h.service.Update(&product)
+	err = h.service.Update(&product, requestMeta(r))
 	if err != nil {
 		response.ErrorResponse(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -152,7 +188,7 @@ func (h *ProductHandler) Delete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = h.service.Delete(id)
+	err = h.service.Delete(id, requestMeta(r))
 	if err != nil {
 		response.ErrorResponse(w, err.Error(), http.StatusBadRequest)
 		return
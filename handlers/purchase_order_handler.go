@@ -0,0 +1,323 @@
+package handlers
+
+import (
+	"cashier-api/models"
+	"cashier-api/response"
+	"cashier-api/services"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type PurchaseOrderHandler struct {
+	service *services.PurchaseOrderService
+}
+
+func NewPurchaseOrderHandler(service *services.PurchaseOrderService) *PurchaseOrderHandler {
+	return &PurchaseOrderHandler{service: service}
+}
+
+func (h *PurchaseOrderHandler) HandlePurchaseOrders(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetAll(w, r)
+	case http.MethodPost:
+		h.Create(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *PurchaseOrderHandler) HandlePurchaseOrderByID(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetByID(w, r)
+	case http.MethodPut:
+		h.Update(w, r)
+	case http.MethodDelete:
+		h.Delete(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *PurchaseOrderHandler) HandleSend(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		h.Send(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *PurchaseOrderHandler) HandleClose(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		h.Close(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *PurchaseOrderHandler) HandleReceipts(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		h.Receive(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *PurchaseOrderHandler) HandleOpenReport(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetOpenReport(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *PurchaseOrderHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	supplierID, err := queryInt(r.URL.Query(), "supplier_id")
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	filter := models.PurchaseOrderFilter{
+		SupplierID: supplierID,
+		OutletID:   requestMeta(r).OutletID,
+		Status:     r.URL.Query().Get("status"),
+	}
+
+	orders, err := h.service.GetAll(filter)
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Get All Purchase Order",
+		Data:    orders,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *PurchaseOrderHandler) Create(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req models.PurchaseOrderRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		response.ErrorResponse(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	order, err := h.service.Create(req, requestMeta(r))
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Create Purchase Order",
+		Data:    order,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *PurchaseOrderHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.ErrorResponse(w, "Invalid purchase order ID", http.StatusBadRequest)
+		return
+	}
+
+	order, err := h.service.GetByID(id)
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Get Purchase Order",
+		Data:    order,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *PurchaseOrderHandler) Update(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.ErrorResponse(w, "Invalid purchase order ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.PurchaseOrderRequest
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		response.ErrorResponse(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	order, err := h.service.Update(id, req)
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), purchaseOrderErrorStatus(err))
+		return
+	}
+
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Update Purchase Order",
+		Data:    order,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *PurchaseOrderHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.ErrorResponse(w, "Invalid purchase order ID", http.StatusBadRequest)
+		return
+	}
+
+	err = h.service.Delete(id)
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), purchaseOrderErrorStatus(err))
+		return
+	}
+
+	response := response.Response{
+		Status:  true,
+		Message: "Success delete purchase order",
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *PurchaseOrderHandler) Send(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.ErrorResponse(w, "Invalid purchase order ID", http.StatusBadRequest)
+		return
+	}
+
+	order, err := h.service.Send(id)
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), purchaseOrderErrorStatus(err))
+		return
+	}
+
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Send Purchase Order",
+		Data:    order,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *PurchaseOrderHandler) Close(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.ErrorResponse(w, "Invalid purchase order ID", http.StatusBadRequest)
+		return
+	}
+
+	order, err := h.service.Close(id)
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), purchaseOrderErrorStatus(err))
+		return
+	}
+
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Close Purchase Order",
+		Data:    order,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *PurchaseOrderHandler) Receive(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	meta, ok := requireOutlet(w, r)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.ErrorResponse(w, "Invalid purchase order ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.GoodsReceiptRequest
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		response.ErrorResponse(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	receipt, err := h.service.Receive(id, req, meta)
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), purchaseOrderErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Receive Purchase Order",
+		Data:    receipt,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *PurchaseOrderHandler) GetOpenReport(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	supplierID, err := queryInt(r.URL.Query(), "supplier_id")
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.service.GetOpenReport(supplierID, reportOutletID(r))
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Get Open Purchase Order Report",
+		Data:    report,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func purchaseOrderErrorStatus(err error) int {
+	if err.Error() == "Purchase order not found" {
+		return http.StatusNotFound
+	}
+	if strings.HasPrefix(err.Error(), "cannot ") || strings.HasPrefix(err.Error(), "Only draft") {
+		return http.StatusConflict
+	}
+	return http.StatusBadRequest
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"cashier-api/models"
+	"errors"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const dateLayout = models.DateLayout
+
+// parseDateRange reads the optional from/to query parameters (YYYY-MM-DD).
+// The returned upper bound is exclusive: to=2024-01-31 covers the whole day.
+func parseDateRange(query url.Values) (*time.Time, *time.Time, error) {
+	var from, to *time.Time
+
+	if value := query.Get("from"); value != "" {
+		t, err := time.ParseInLocation(dateLayout, value, time.Local)
+		if err != nil {
+			return nil, nil, errors.New("Invalid from date, use YYYY-MM-DD")
+		}
+		from = &t
+	}
+
+	if value := query.Get("to"); value != "" {
+		t, err := time.ParseInLocation(dateLayout, value, time.Local)
+		if err != nil {
+			return nil, nil, errors.New("Invalid to date, use YYYY-MM-DD")
+		}
+		t = t.AddDate(0, 0, 1)
+		to = &t
+	}
+
+	if from != nil && to != nil && !from.Before(*to) {
+		return nil, nil, errors.New("from date must not be after to date")
+	}
+
+	return from, to, nil
+}
+
+// queryInt reads an optional integer query parameter, returning 0 when absent.
+func queryInt(query url.Values, key string) (int, error) {
+	value := query.Get(key)
+	if value == "" {
+		return 0, nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, errors.New("Invalid " + key)
+	}
+	return n, nil
+}
@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"cashier-api/models"
+	"cashier-api/response"
+	"cashier-api/services"
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+type ReceivableHandler struct {
+	service *services.ReceivableService
+}
+
+func NewReceivableHandler(service *services.ReceivableService) *ReceivableHandler {
+	return &ReceivableHandler{service: service}
+}
+
+func (h *ReceivableHandler) HandleReport(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetReport(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *ReceivableHandler) HandleStatement(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetStatement(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *ReceivableHandler) HandleRepayments(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		h.CreateRepayment(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *ReceivableHandler) GetReport(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	report, err := h.service.GetReport()
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Get Receivables Report",
+		Data:    report,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *ReceivableHandler) GetStatement(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.ErrorResponse(w, "Invalid customer ID", http.StatusBadRequest)
+		return
+	}
+
+	from, to, err := parseDateRange(r.URL.Query())
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	statement, err := h.service.GetStatement(id, models.StatementFilter{From: from, To: to})
+	if err != nil {
+		status := http.StatusInternalServerError
+		if err.Error() == "Customer not found" {
+			status = http.StatusNotFound
+		}
+		response.ErrorResponse(w, err.Error(), status)
+		return
+	}
+
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Get Customer Statement",
+		Data:    statement,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *ReceivableHandler) CreateRepayment(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.ErrorResponse(w, "Invalid customer ID", http.StatusBadRequest)
+		return
+	}
+
+	var repayment models.Repayment
+	err = json.NewDecoder(r.Body).Decode(&repayment)
+	if err != nil {
+		response.ErrorResponse(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	err = h.service.CreateRepayment(id, &repayment, requestMeta(r))
+	if err != nil {
+		status := http.StatusBadRequest
+		if err.Error() == "Customer not found" {
+			status = http.StatusNotFound
+		}
+		response.ErrorResponse(w, err.Error(), status)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Create Repayment",
+		Data:    repayment,
+	}
+	json.NewEncoder(w).Encode(response)
+}
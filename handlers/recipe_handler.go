@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"cashier-api/models"
+	"cashier-api/response"
+	"cashier-api/services"
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+type RecipeHandler struct {
+	service *services.RecipeService
+}
+
+func NewRecipeHandler(service *services.RecipeService) *RecipeHandler {
+	return &RecipeHandler{service: service}
+}
+
+func (h *RecipeHandler) HandleRecipe(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetRecipe(w, r)
+	case http.MethodPut:
+		h.SetRecipe(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *RecipeHandler) HandleIngredientUsage(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetIngredientUsage(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *RecipeHandler) GetRecipe(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.ErrorResponse(w, "Invalid product ID", http.StatusBadRequest)
+		return
+	}
+
+	items, err := h.service.GetRecipe(id)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if err.Error() == "Product not found" {
+			status = http.StatusNotFound
+		}
+		response.ErrorResponse(w, err.Error(), status)
+		return
+	}
+
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Get Recipe",
+		Data:    items,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *RecipeHandler) SetRecipe(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.ErrorResponse(w, "Invalid product ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.RecipeRequest
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		response.ErrorResponse(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	items, err := h.service.SetRecipe(id, req.Items, requestMeta(r))
+	if err != nil {
+		status := http.StatusBadRequest
+		if err.Error() == "Product not found" {
+			status = http.StatusNotFound
+		}
+		response.ErrorResponse(w, err.Error(), status)
+		return
+	}
+
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Set Recipe",
+		Data:    items,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *RecipeHandler) GetIngredientUsage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	from, to, err := parseDateRange(r.URL.Query())
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	filter := models.IngredientUsageFilter{
+		OutletID: reportOutletID(r),
+		From:     from,
+		To:       to,
+	}
+
+	report, err := h.service.GetIngredientUsage(filter)
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Get Ingredient Usage Report",
+		Data:    report,
+	}
+	json.NewEncoder(w).Encode(response)
+}
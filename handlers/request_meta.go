@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"cashier-api/models"
+	"cashier-api/response"
+	"cashier-api/services"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+)
+
+const (
+	headerActor       = "X-Actor"
+	headerAdminKey    = "X-Admin-Key"
+	headerRequestID   = "X-Request-ID"
+	headerTerminalKey = "X-Terminal-Key"
+)
+
+type contextKey string
+
+const terminalContextKey contextKey = "terminal"
+
+// RequestID makes sure every request carries an X-Request-ID, generating one
+// when the client did not send it, and echoes it back in the response.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(headerRequestID)
+		if id == "" {
+			id = newRequestID()
+			r.Header.Set(headerRequestID, id)
+		}
+		w.Header().Set(headerRequestID, id)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// TerminalAuth resolves the terminal behind an X-Terminal-Key header and
+// attaches it to the request. Requests without the header pass through
+// unauthenticated; an unknown key is rejected.
+func TerminalAuth(service *services.OutletService, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(headerTerminalKey)
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		terminal, err := service.GetTerminalByAPIKey(key)
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			response.ErrorResponse(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), terminalContextKey, terminal)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// AdminAuth guards an outlet management route: anything but a read needs
+// the admin key in an X-Admin-Key header, so terminals and their keys can
+// only be issued by whoever holds it. Without a configured key no changes
+// are allowed at all.
+func AdminAuth(key string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			given := r.Header.Get(headerAdminKey)
+			if key == "" || subtle.ConstantTimeCompare([]byte(given), []byte(key)) != 1 {
+				w.Header().Set("Content-Type", "application/json")
+				response.ErrorResponse(w, "Admin authentication required", http.StatusUnauthorized)
+				return
+			}
+		}
+		next(w, r)
+	}
+}
+
+func requestMeta(r *http.Request) models.RequestMeta {
+	meta := models.RequestMeta{
+		Actor:     r.Header.Get(headerActor),
+		RequestID: r.Header.Get(headerRequestID),
+	}
+
+	if terminal, ok := r.Context().Value(terminalContextKey).(*models.Terminal); ok {
+		meta.TerminalID = terminal.ID
+		meta.OutletID = terminal.OutletID
+		if meta.Actor == "" {
+			meta.Actor = terminal.Name
+		}
+	}
+
+	return meta
+}
+
+// requireOutlet returns the request meta of an authenticated terminal, or
+// writes a 401 and returns false when the request is not scoped to an outlet.
+func requireOutlet(w http.ResponseWriter, r *http.Request) (models.RequestMeta, bool) {
+	meta := requestMeta(r)
+	if meta.OutletID == 0 {
+		response.ErrorResponse(w, "Terminal authentication required", http.StatusUnauthorized)
+		return meta, false
+	}
+	return meta, true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,245 @@
+package handlers
+
+import (
+	"cashier-api/models"
+	"cashier-api/response"
+	"cashier-api/services"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type StockTransferHandler struct {
+	service *services.StockTransferService
+}
+
+func NewStockTransferHandler(service *services.StockTransferService) *StockTransferHandler {
+	return &StockTransferHandler{service: service}
+}
+
+func (h *StockTransferHandler) HandleStockTransfers(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetAll(w, r)
+	case http.MethodPost:
+		h.Create(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *StockTransferHandler) HandleStockTransferByID(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetByID(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *StockTransferHandler) HandleDispatch(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		h.Dispatch(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *StockTransferHandler) HandleReceive(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		h.Receive(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *StockTransferHandler) HandleCancel(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		h.Cancel(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *StockTransferHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	filter := models.StockTransferFilter{
+		OutletID: requestMeta(r).OutletID,
+		Status:   r.URL.Query().Get("status"),
+	}
+
+	transfers, err := h.service.GetAll(filter)
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Get All Stock Transfer",
+		Data:    transfers,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *StockTransferHandler) Create(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req models.StockTransferRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		response.ErrorResponse(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	transfer, err := h.service.Create(req, requestMeta(r))
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), stockTransferErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Create Stock Transfer",
+		Data:    transfer,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *StockTransferHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.ErrorResponse(w, "Invalid stock transfer ID", http.StatusBadRequest)
+		return
+	}
+
+	transfer, err := h.service.GetByID(id)
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Get Stock Transfer",
+		Data:    transfer,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *StockTransferHandler) Dispatch(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	meta, ok := requireOutlet(w, r)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.ErrorResponse(w, "Invalid stock transfer ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.StockTransferDispatchRequest
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil && err != io.EOF {
+		response.ErrorResponse(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	transfer, err := h.service.Dispatch(id, req, meta)
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), stockTransferErrorStatus(err))
+		return
+	}
+
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Dispatch Stock Transfer",
+		Data:    transfer,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *StockTransferHandler) Receive(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	meta, ok := requireOutlet(w, r)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.ErrorResponse(w, "Invalid stock transfer ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.StockTransferReceiveRequest
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil && err != io.EOF {
+		response.ErrorResponse(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	transfer, err := h.service.Receive(id, req, meta)
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), stockTransferErrorStatus(err))
+		return
+	}
+
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Receive Stock Transfer",
+		Data:    transfer,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *StockTransferHandler) Cancel(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	meta, ok := requireOutlet(w, r)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.ErrorResponse(w, "Invalid stock transfer ID", http.StatusBadRequest)
+		return
+	}
+
+	transfer, err := h.service.Cancel(id, meta)
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), stockTransferErrorStatus(err))
+		return
+	}
+
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Cancel Stock Transfer",
+		Data:    transfer,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func stockTransferErrorStatus(err error) int {
+	if err.Error() == "Stock transfer not found" || err.Error() == "Outlet not found" {
+		return http.StatusNotFound
+	}
+	if strings.HasPrefix(err.Error(), "cannot ") {
+		return http.StatusConflict
+	}
+	return http.StatusBadRequest
+}
@@ -0,0 +1,309 @@
+package handlers
+
+import (
+	"cashier-api/models"
+	"cashier-api/response"
+	"cashier-api/services"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type StocktakeHandler struct {
+	service *services.StocktakeService
+}
+
+func NewStocktakeHandler(service *services.StocktakeService) *StocktakeHandler {
+	return &StocktakeHandler{service: service}
+}
+
+func (h *StocktakeHandler) HandleStocktakes(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetAll(w, r)
+	case http.MethodPost:
+		h.Create(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *StocktakeHandler) HandleStocktakeByID(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetByID(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *StocktakeHandler) HandleCounts(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		h.AddCounts(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *StocktakeHandler) HandleCountByID(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodDelete:
+		h.DeleteCount(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *StocktakeHandler) HandleFinalize(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		h.Finalize(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *StocktakeHandler) HandleCancel(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		h.Cancel(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *StocktakeHandler) HandleVariance(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetVarianceReport(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *StocktakeHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	stocktakes, err := h.service.GetAll(requestMeta(r).OutletID)
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Get All Stocktake",
+		Data:    stocktakes,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *StocktakeHandler) Create(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	meta, ok := requireOutlet(w, r)
+	if !ok {
+		return
+	}
+
+	var stocktake models.Stocktake
+	err := json.NewDecoder(r.Body).Decode(&stocktake)
+	if err != nil && err != io.EOF {
+		response.ErrorResponse(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	err = h.service.Create(&stocktake, meta)
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Create Stocktake",
+		Data:    stocktake,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *StocktakeHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.ErrorResponse(w, "Invalid stocktake ID", http.StatusBadRequest)
+		return
+	}
+
+	stocktake, err := h.service.GetByID(id)
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Get Stocktake",
+		Data:    stocktake,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *StocktakeHandler) AddCounts(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	meta, ok := requireOutlet(w, r)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.ErrorResponse(w, "Invalid stocktake ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.StocktakeCountRequest
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		response.ErrorResponse(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	counts, err := h.service.AddCounts(id, req, meta)
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), stocktakeErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Add Stocktake Count",
+		Data:    counts,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *StocktakeHandler) DeleteCount(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	meta, ok := requireOutlet(w, r)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.ErrorResponse(w, "Invalid stocktake ID", http.StatusBadRequest)
+		return
+	}
+
+	countID, err := strconv.Atoi(r.PathValue("count_id"))
+	if err != nil {
+		response.ErrorResponse(w, "Invalid count ID", http.StatusBadRequest)
+		return
+	}
+
+	err = h.service.DeleteCount(id, countID, meta)
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), stocktakeErrorStatus(err))
+		return
+	}
+
+	response := response.Response{
+		Status:  true,
+		Message: "Success delete count",
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *StocktakeHandler) Finalize(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	meta, ok := requireOutlet(w, r)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.ErrorResponse(w, "Invalid stocktake ID", http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.service.Finalize(id, meta)
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), stocktakeErrorStatus(err))
+		return
+	}
+
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Finalize Stocktake",
+		Data:    report,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *StocktakeHandler) Cancel(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	meta, ok := requireOutlet(w, r)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.ErrorResponse(w, "Invalid stocktake ID", http.StatusBadRequest)
+		return
+	}
+
+	err = h.service.Cancel(id, meta)
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), stocktakeErrorStatus(err))
+		return
+	}
+
+	response := response.Response{
+		Status:  true,
+		Message: "Success cancel stocktake",
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *StocktakeHandler) GetVarianceReport(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.ErrorResponse(w, "Invalid stocktake ID", http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.service.GetVarianceReport(id)
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Get Stocktake Variance",
+		Data:    report,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func stocktakeErrorStatus(err error) int {
+	if strings.HasSuffix(err.Error(), "not found") {
+		return http.StatusNotFound
+	}
+	return http.StatusBadRequest
+}
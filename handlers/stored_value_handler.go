@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"cashier-api/models"
+	"cashier-api/response"
+	"cashier-api/services"
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+type StoredValueHandler struct {
+	service *services.StoredValueService
+}
+
+func NewStoredValueHandler(service *services.StoredValueService) *StoredValueHandler {
+	return &StoredValueHandler{service: service}
+}
+
+func (h *StoredValueHandler) HandleGiftCard(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetGiftCard(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *StoredValueHandler) HandleStoreCredit(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetStoreCredit(w, r)
+	case http.MethodPost:
+		h.AdjustStoreCredit(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *StoredValueHandler) GetGiftCard(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	limit, err := queryInt(r.URL.Query(), "limit")
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	card, err := h.service.GetGiftCard(r.PathValue("code"), limit)
+	if err != nil {
+		status := http.StatusBadRequest
+		if err.Error() == "Gift card not found" {
+			status = http.StatusNotFound
+		}
+		response.ErrorResponse(w, err.Error(), status)
+		return
+	}
+
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Get Gift Card",
+		Data:    card,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *StoredValueHandler) GetStoreCredit(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.ErrorResponse(w, "Invalid customer ID", http.StatusBadRequest)
+		return
+	}
+
+	limit, err := queryInt(r.URL.Query(), "limit")
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	credit, err := h.service.GetStoreCredit(id, limit)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if err.Error() == "Customer not found" {
+			status = http.StatusNotFound
+		}
+		response.ErrorResponse(w, err.Error(), status)
+		return
+	}
+
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Get Store Credit",
+		Data:    credit,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *StoredValueHandler) AdjustStoreCredit(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.ErrorResponse(w, "Invalid customer ID", http.StatusBadRequest)
+		return
+	}
+
+	var adjustment models.StoredValueAdjustment
+	err = json.NewDecoder(r.Body).Decode(&adjustment)
+	if err != nil {
+		response.ErrorResponse(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	entry, err := h.service.AdjustStoreCredit(id, adjustment, requestMeta(r))
+	if err != nil {
+		status := http.StatusBadRequest
+		if err.Error() == "Customer not found" {
+			status = http.StatusNotFound
+		}
+		response.ErrorResponse(w, err.Error(), status)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Adjust Store Credit",
+		Data:    entry,
+	}
+	json.NewEncoder(w).Encode(response)
+}
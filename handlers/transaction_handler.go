@@ -2,10 +2,13 @@ package handlers
 
 import (
 	"cashier-api/models"
+	"cashier-api/receipts"
 	"cashier-api/response"
 	"cashier-api/services"
 	"encoding/json"
+	"io"
 	"net/http"
+	"strconv"
 )
 
 type TransactionHandler struct {
@@ -25,6 +28,24 @@ func (h *TransactionHandler) HandleCheckout(w http.ResponseWriter, r *http.Reque
 	}
 }
 
+func (h *TransactionHandler) HandleTransactionByID(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetByID(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *TransactionHandler) HandleReceipt(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetReceipt(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
 func (h *TransactionHandler) HandleReport(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodGet:
@@ -34,9 +55,32 @@ func (h *TransactionHandler) HandleReport(w http.ResponseWriter, r *http.Request
 	}
 }
 
+func (h *TransactionHandler) HandleGrossProfitReport(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetGrossProfitReport(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *TransactionHandler) HandleRefunds(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		h.Refund(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
 func (h *TransactionHandler) Checkout(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
+	meta, ok := requireOutlet(w, r)
+	if !ok {
+		return
+	}
+
 	var req models.CheckoutRequest
 	err := json.NewDecoder(r.Body).Decode(&req)
 	if err != nil {
@@ -44,7 +88,7 @@ func (h *TransactionHandler) Checkout(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	transaction, err := h.service.Checkout(req.Items, true)
+	transaction, err := h.service.Checkout(req, true, meta)
 	if err != nil {
 		response.ErrorResponse(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -58,10 +102,53 @@ func (h *TransactionHandler) Checkout(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(response)
 }
 
+func (h *TransactionHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.ErrorResponse(w, "Invalid transaction ID", http.StatusBadRequest)
+		return
+	}
+
+	transaction, err := h.service.GetByID(id)
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Get Transaction",
+		Data:    transaction,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetReceipt prints a sale as plain text, ready to send to a receipt printer.
+func (h *TransactionHandler) GetReceipt(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		response.ErrorResponse(w, "Invalid transaction ID", http.StatusBadRequest)
+		return
+	}
+
+	transaction, err := h.service.GetByID(id)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		response.ErrorResponse(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	io.WriteString(w, receipts.Text(transaction))
+}
+
 func (h *TransactionHandler) GetReport(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	report, err := h.service.GetReport()
+	report, err := h.service.GetReport(reportOutletID(r))
 	if err != nil {
 		response.ErrorResponse(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -74,3 +161,85 @@ func (h *TransactionHandler) GetReport(w http.ResponseWriter, r *http.Request) {
 	}
 	json.NewEncoder(w).Encode(response)
 }
+
+func (h *TransactionHandler) Refund(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	meta, ok := requireOutlet(w, r)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.ErrorResponse(w, "Invalid transaction ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.RefundRequest
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil && err != io.EOF {
+		response.ErrorResponse(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	refund, err := h.service.Refund(id, req, meta)
+	if err != nil {
+		status := http.StatusBadRequest
+		if err.Error() == "Transaction not found" {
+			status = http.StatusNotFound
+		}
+		response.ErrorResponse(w, err.Error(), status)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Refund Transaction",
+		Data:    refund,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *TransactionHandler) GetGrossProfitReport(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	from, to, err := parseDateRange(r.URL.Query())
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	filter := models.GrossProfitFilter{
+		GroupBy:  r.URL.Query().Get("group_by"),
+		OutletID: reportOutletID(r),
+		From:     from,
+		To:       to,
+	}
+	if filter.GroupBy == "" {
+		filter.GroupBy = models.GrossProfitByProduct
+	}
+
+	report, err := h.service.GetGrossProfitReport(filter)
+	if err != nil {
+		response.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response := response.ResponseWithData{
+		Status:  true,
+		Message: "Get Gross Profit Report",
+		Data:    report,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// reportOutletID scopes reports to the calling terminal's outlet. Requests
+// without a terminal, or with consolidated=true, cover every outlet.
+func reportOutletID(r *http.Request) int {
+	if r.URL.Query().Get("consolidated") == "true" {
+		return 0
+	}
+	return requestMeta(r).OutletID
+}
@@ -0,0 +1,140 @@
+package labels
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Symbol is an encoded barcode: a dark or light module for each unit of
+// width, and the text printed beneath it.
+type Symbol struct {
+	Modules []bool
+	Text    string
+}
+
+var (
+	ean13L = [10]string{"0001101", "0011001", "0010011", "0111101", "0100011", "0110001", "0101111", "0111011", "0110111", "0001011"}
+	ean13G = [10]string{"0100111", "0110011", "0011011", "0100001", "0011101", "0111001", "0000101", "0010001", "0001001", "0010111"}
+	ean13R = [10]string{"1110010", "1100110", "1101100", "1000010", "1011100", "1001110", "1010000", "1000100", "1001000", "1110100"}
+
+	// ean13Parity picks the L or G set for each left-hand digit; the first
+	// digit of the code is not drawn but carried by this pattern.
+	ean13Parity = [10]string{"LLLLLL", "LLGLGG", "LLGGLG", "LLGGGL", "LGLLGG", "LGGLLG", "LGGGLL", "LGLGLG", "LGLGGL", "LGGLGL"}
+)
+
+// EAN13 encodes a 13 digit code, or a 12 digit UPC-A code as EAN-13 with a
+// leading zero. The check digit must be valid.
+func EAN13(code string) (*Symbol, error) {
+	digits := code
+	if len(digits) == 12 {
+		digits = "0" + digits
+	}
+	if len(digits) != 13 {
+		return nil, fmt.Errorf("EAN-13 needs 13 digits, got %q", code)
+	}
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return nil, fmt.Errorf("EAN-13 needs 13 digits, got %q", code)
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("101")
+	parity := ean13Parity[digits[0]-'0']
+	for i := 1; i <= 6; i++ {
+		d := digits[i] - '0'
+		if parity[i-1] == 'L' {
+			b.WriteString(ean13L[d])
+		} else {
+			b.WriteString(ean13G[d])
+		}
+	}
+	b.WriteString("01010")
+	for i := 7; i <= 12; i++ {
+		b.WriteString(ean13R[digits[i]-'0'])
+	}
+	b.WriteString("101")
+
+	return &Symbol{Modules: modules(b.String()), Text: code}, nil
+}
+
+// code128Widths are the bar and space widths of each Code 128 symbol value,
+// starting with a bar. 103 to 105 start code sets A, B and C; 106 stops.
+var code128Widths = [107]string{
+	"212222", "222122", "222221", "121223", "121322", "131222", "122213", "122312", "132212", "221213",
+	"221312", "231212", "112232", "122132", "122231", "113222", "123122", "123221", "223211", "221132",
+	"221231", "213212", "223112", "312131", "311222", "321122", "321221", "312212", "322112", "322211",
+	"212123", "212321", "232121", "111323", "131123", "131321", "112313", "132113", "132311", "211313",
+	"231113", "231311", "112133", "112331", "132131", "113123", "113321", "133121", "313121", "211331",
+	"231131", "213113", "213311", "213131", "311123", "311321", "331121", "312113", "312311", "332111",
+	"314111", "221411", "431111", "111224", "111422", "121124", "121421", "141122", "141221", "112214",
+	"112412", "122114", "122411", "142112", "142211", "241211", "221114", "413111", "241112", "134111",
+	"111242", "121142", "121241", "114212", "124112", "124211", "411212", "421112", "421211", "212141",
+	"214121", "412121", "111143", "111341", "131141", "114113", "114311", "411113", "411311", "113141",
+	"114131", "311141", "411131", "211412", "211214", "211232", "2331112",
+}
+
+const (
+	code128StartB = 104
+	code128StartC = 105
+	code128Stop   = 106
+)
+
+// Code128 encodes printable ASCII. Codes made only of an even number of
+// digits use code set C, which packs two digits into each symbol; anything
+// else uses code set B.
+func Code128(data string) (*Symbol, error) {
+	if data == "" {
+		return nil, fmt.Errorf("Code 128 needs data to encode")
+	}
+
+	numeric := len(data)%2 == 0
+	for _, r := range data {
+		if r < ' ' || r > '~' {
+			return nil, fmt.Errorf("Code 128 cannot encode %q", data)
+		}
+		if r < '0' || r > '9' {
+			numeric = false
+		}
+	}
+
+	values := make([]int, 0, len(data)+3)
+	if numeric {
+		values = append(values, code128StartC)
+		for i := 0; i < len(data); i += 2 {
+			values = append(values, int(data[i]-'0')*10+int(data[i+1]-'0'))
+		}
+	} else {
+		values = append(values, code128StartB)
+		for i := 0; i < len(data); i++ {
+			values = append(values, int(data[i])-' ')
+		}
+	}
+
+	checksum := values[0]
+	for i, v := range values[1:] {
+		checksum += v * (i + 1)
+	}
+	values = append(values, checksum%103, code128Stop)
+
+	var b strings.Builder
+	for _, v := range values {
+		for i, w := range code128Widths[v] {
+			module := "0"
+			if i%2 == 0 {
+				module = "1"
+			}
+			b.WriteString(strings.Repeat(module, int(w-'0')))
+		}
+	}
+
+	return &Symbol{Modules: modules(b.String()), Text: data}, nil
+}
+
+func modules(pattern string) []bool {
+	m := make([]bool, len(pattern))
+	for i := range pattern {
+		m[i] = pattern[i] == '1'
+	}
+	return m
+}
@@ -0,0 +1,216 @@
+package labels
+
+import (
+	"cashier-api/models"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	FormatSVG = "svg"
+	FormatPNG = "png"
+	FormatPDF = "pdf"
+)
+
+// Label is what a shelf label shows: the product name, its price and a
+// barcode to scan it by.
+type Label struct {
+	Name   string
+	Price  string
+	Symbol *Symbol
+}
+
+// FromProduct labels a product with its price per base unit. The primary
+// barcode is drawn as EAN-13 when it is a valid EAN-13 or UPC-A code and as
+// Code 128 otherwise; products without a barcode get their SKU in Code 128.
+func FromProduct(product models.Product) Label {
+	label := Label{Name: product.Name, Price: FormatPrice(product.Price)}
+	if product.Unit != "" && product.Unit != "pcs" {
+		label.Price += " / " + product.Unit
+	}
+
+	code := product.Barcode
+	if (len(code) == 13 || len(code) == 12) && models.ValidCheckDigit(code) {
+		label.Symbol, _ = EAN13(code)
+		return label
+	}
+	if code == "" {
+		code = product.SKU
+	}
+	if code != "" {
+		label.Symbol, _ = Code128(code)
+	}
+	return label
+}
+
+// FormatPrice writes rupiah with dots between thousands, as in "Rp 12.500".
+func FormatPrice(amount int) string {
+	sign := ""
+	if amount < 0 {
+		sign, amount = "-", -amount
+	}
+	digits := strconv.Itoa(amount)
+	var b strings.Builder
+	for i, r := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte('.')
+		}
+		b.WriteRune(r)
+	}
+	return sign + "Rp " + b.String()
+}
+
+// Grid lays labels out on a sheet, all sizes in millimetres. Labels fill
+// each row left to right; a sheet holds Columns x Rows labels.
+type Grid struct {
+	Columns     int
+	Rows        int
+	LabelWidth  float64
+	LabelHeight float64
+	Gap         float64
+	Margin      float64
+}
+
+// DefaultGrid suits A4 sheets of 24 labels.
+var DefaultGrid = Grid{Columns: 3, Rows: 8, LabelWidth: 63.5, LabelHeight: 33.9, Gap: 2.5, Margin: 10}
+
+// SingleGrid prints one label per page, for label printers.
+var SingleGrid = Grid{Columns: 1, Rows: 1, LabelWidth: 50, LabelHeight: 30}
+
+// MaxPageSize is the longest side of a sheet in millimetres, that of A3.
+const MaxPageSize = 420
+
+// Validate checks the grid fits on a sheet no larger than MaxPageSize on
+// either side. The bounds are written so that NaN fails them.
+func (g Grid) Validate() error {
+	if g.Columns < 1 || g.Rows < 1 || g.Columns > 20 || g.Rows > 40 {
+		return errors.New("columns and rows must be between 1 and 20 and 1 and 40")
+	}
+	if !(g.LabelWidth >= 20 && g.LabelWidth <= 300 && g.LabelHeight >= 15 && g.LabelHeight <= 300) {
+		return errors.New("labels must be between 20x15 and 300x300 mm")
+	}
+	if !(g.Gap >= 0 && g.Gap <= 20) {
+		return errors.New("gap must be between 0 and 20 mm")
+	}
+	if !(g.Margin >= 0 && g.Margin <= 50) {
+		return errors.New("margin must be between 0 and 50 mm")
+	}
+	if width, height := g.PageSize(); width > MaxPageSize || height > MaxPageSize {
+		return fmt.Errorf("a sheet of %.1f x %.1f mm is larger than %d mm on a side", width, height, MaxPageSize)
+	}
+	return nil
+}
+
+// PageSize is the size of one sheet in millimetres.
+func (g Grid) PageSize() (float64, float64) {
+	width := 2*g.Margin + float64(g.Columns)*g.LabelWidth + float64(g.Columns-1)*g.Gap
+	height := 2*g.Margin + float64(g.Rows)*g.LabelHeight + float64(g.Rows-1)*g.Gap
+	return width, height
+}
+
+// Pages is the number of sheets n labels fill.
+func (g Grid) Pages(n int) int {
+	perPage := g.Columns * g.Rows
+	return max((n+perPage-1)/perPage, 1)
+}
+
+// Render draws labels in format. PDF documents hold every sheet; SVG and
+// PNG images hold the one sheet numbered page, counting from 1.
+func Render(format string, labels []Label, grid Grid, page int) ([]byte, string, error) {
+	if err := grid.Validate(); err != nil {
+		return nil, "", err
+	}
+
+	switch format {
+	case FormatPDF:
+		return renderPDF(labels, grid), "application/pdf", nil
+	case FormatSVG, FormatPNG:
+		if page < 1 || page > grid.Pages(len(labels)) {
+			return nil, "", fmt.Errorf("page %d does not exist; there are %d", page, grid.Pages(len(labels)))
+		}
+		perPage := grid.Columns * grid.Rows
+		sheet := labels[min((page-1)*perPage, len(labels)):min(page*perPage, len(labels))]
+		if format == FormatSVG {
+			return renderSVG(sheet, grid), "image/svg+xml", nil
+		}
+		data, err := renderPNG(sheet, grid)
+		return data, "image/png", err
+	default:
+		return nil, "", fmt.Errorf("invalid format %q, use svg, png or pdf", format)
+	}
+}
+
+// canvas is a page being drawn on, in millimetres from its top left corner.
+type canvas interface {
+	// rect fills a black rectangle.
+	rect(x, y, w, h float64)
+	// text writes s with its top at y, size millimetres tall.
+	text(x, y, size float64, s string)
+}
+
+// charWidth is the advance of one character as a share of the text size,
+// close to both the PNG dot font and Helvetica.
+const charWidth = 0.6
+
+// drawSheet draws the labels of one sheet in grid order.
+func drawSheet(c canvas, labels []Label, grid Grid) {
+	for i, label := range labels {
+		col, row := i%grid.Columns, i/grid.Columns
+		x := grid.Margin + float64(col)*(grid.LabelWidth+grid.Gap)
+		y := grid.Margin + float64(row)*(grid.LabelHeight+grid.Gap)
+		drawLabel(c, label, x, y, grid.LabelWidth, grid.LabelHeight)
+	}
+}
+
+// drawLabel stacks the name, the price and the barcode with its text.
+func drawLabel(c canvas, label Label, x, y, w, h float64) {
+	pad := min(2, w*0.05)
+	nameSize, priceSize, codeSize := h*0.12, h*0.2, h*0.08
+	inner := w - 2*pad
+
+	top := y + pad
+	c.text(x+pad, top, nameSize, fit(label.Name, inner, nameSize))
+	top += nameSize * 1.3
+	c.text(x+pad, top, priceSize, fit(label.Price, inner, priceSize))
+	top += priceSize * 1.3
+
+	if label.Symbol == nil {
+		return
+	}
+	bottom := y + h - pad - codeSize*1.2
+	if bottom-top < h*0.15 {
+		return
+	}
+
+	// Ten modules of quiet zone either side keep the code readable.
+	n := float64(len(label.Symbol.Modules))
+	module := min(0.33, inner/(n+20))
+	left := x + (w-n*module)/2
+	for i := 0; i < len(label.Symbol.Modules); {
+		if !label.Symbol.Modules[i] {
+			i++
+			continue
+		}
+		start := i
+		for i < len(label.Symbol.Modules) && label.Symbol.Modules[i] {
+			i++
+		}
+		c.rect(left+float64(start)*module, top, float64(i-start)*module, bottom-top)
+	}
+	c.text(left, bottom+codeSize*0.2, codeSize, fit(label.Symbol.Text, inner, codeSize))
+}
+
+// fit shortens s to what fits in width at size, marking the cut with "..".
+func fit(s string, width, size float64) string {
+	n := int(width / (size * charWidth))
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	if n <= 2 {
+		return ""
+	}
+	return string(runes[:n-2]) + ".."
+}
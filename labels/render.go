@@ -0,0 +1,178 @@
+package labels
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+)
+
+type svgCanvas struct {
+	b *strings.Builder
+}
+
+func (c svgCanvas) rect(x, y, w, h float64) {
+	fmt.Fprintf(c.b, `<rect x="%.3f" y="%.3f" width="%.3f" height="%.3f"/>`+"\n", x, y, w, h)
+}
+
+func (c svgCanvas) text(x, y, size float64, s string) {
+	if s == "" {
+		return
+	}
+	var escaped strings.Builder
+	for _, r := range s {
+		switch r {
+		case '&':
+			escaped.WriteString("&amp;")
+		case '<':
+			escaped.WriteString("&lt;")
+		case '>':
+			escaped.WriteString("&gt;")
+		default:
+			escaped.WriteRune(r)
+		}
+	}
+	fmt.Fprintf(c.b, `<text x="%.3f" y="%.3f" font-size="%.3f">%s</text>`+"\n", x, y+size*0.8, size, escaped.String())
+}
+
+// renderSVG draws one sheet at its size in millimetres.
+func renderSVG(labels []Label, grid Grid) []byte {
+	width, height := grid.PageSize()
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%.3fmm" height="%.3fmm" viewBox="0 0 %.3f %.3f">`+"\n", width, height, width, height)
+	b.WriteString(`<rect width="100%" height="100%" fill="#fff"/>` + "\n")
+	b.WriteString(`<g fill="#000" font-family="Helvetica, Arial, sans-serif">` + "\n")
+	drawSheet(svgCanvas{b: &b}, labels, grid)
+	b.WriteString("</g>\n</svg>\n")
+	return []byte(b.String())
+}
+
+// pngDotsPerMM renders at 300 dpi.
+const pngDotsPerMM = 300 / 25.4
+
+type pngCanvas struct {
+	img *image.Gray
+}
+
+func (c pngCanvas) rect(x, y, w, h float64) {
+	x0, y0 := int(x*pngDotsPerMM+0.5), int(y*pngDotsPerMM+0.5)
+	x1, y1 := int((x+w)*pngDotsPerMM+0.5), int((y+h)*pngDotsPerMM+0.5)
+	for py := y0; py < y1; py++ {
+		for px := x0; px < x1; px++ {
+			c.img.SetGray(px, py, color.Gray{})
+		}
+	}
+}
+
+// text draws with the 5x7 dot font, its capitals about 70% of size tall
+// like a typeset face.
+func (c pngCanvas) text(x, y, size float64, s string) {
+	dot := size * 0.7 / 7
+	top := y + size*0.1
+	for i, r := range s {
+		left := x + float64(i)*size*charWidth
+		rows := glyph(r)
+		for row, bits := range rows {
+			for col := 0; col < 5; col++ {
+				if bits&(0x10>>col) != 0 {
+					c.rect(left+float64(col)*dot, top+float64(row)*dot, dot, dot)
+				}
+			}
+		}
+	}
+}
+
+// renderPNG draws one sheet in greyscale at 300 dpi.
+func renderPNG(labels []Label, grid Grid) ([]byte, error) {
+	width, height := grid.PageSize()
+	img := image.NewGray(image.Rect(0, 0, int(width*pngDotsPerMM+0.5), int(height*pngDotsPerMM+0.5)))
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+	drawSheet(pngCanvas{img: img}, labels, grid)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// pdfPointsPerMM converts millimetres to PDF points.
+const pdfPointsPerMM = 72 / 25.4
+
+type pdfCanvas struct {
+	b      *strings.Builder
+	height float64
+}
+
+func (c pdfCanvas) rect(x, y, w, h float64) {
+	fmt.Fprintf(c.b, "%.3f %.3f %.3f %.3f re f\n", x*pdfPointsPerMM, (c.height-y-h)*pdfPointsPerMM, w*pdfPointsPerMM, h*pdfPointsPerMM)
+}
+
+func (c pdfCanvas) text(x, y, size float64, s string) {
+	if s == "" {
+		return
+	}
+	var escaped strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			escaped.WriteRune('\\')
+			escaped.WriteRune(r)
+		case r < ' ' || r > '~':
+			escaped.WriteRune('?')
+		default:
+			escaped.WriteRune(r)
+		}
+	}
+	fmt.Fprintf(c.b, "BT /F1 %.3f Tf %.3f %.3f Td (%s) Tj ET\n", size*pdfPointsPerMM, x*pdfPointsPerMM, (c.height-y-size*0.8)*pdfPointsPerMM, escaped.String())
+}
+
+// renderPDF writes a PDF with a page per sheet. Text uses the standard
+// Helvetica font, which every reader has, so nothing is embedded.
+func renderPDF(labels []Label, grid Grid) []byte {
+	width, height := grid.PageSize()
+	perPage := grid.Columns * grid.Rows
+	pages := grid.Pages(len(labels))
+
+	// Objects: 1 catalog, 2 page tree, 3 font, then a page and its content
+	// stream for each sheet.
+	objects := make([]string, 0, 3+2*pages)
+	kids := make([]string, 0, pages)
+	for i := 0; i < pages; i++ {
+		kids = append(kids, fmt.Sprintf("%d 0 R", 4+2*i))
+	}
+	objects = append(objects,
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), pages),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+	)
+	for i := 0; i < pages; i++ {
+		var content strings.Builder
+		sheet := labels[min(i*perPage, len(labels)):min((i+1)*perPage, len(labels))]
+		drawSheet(pdfCanvas{b: &content, height: height}, sheet, grid)
+
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.3f %.3f] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>", width*pdfPointsPerMM, height*pdfPointsPerMM, 5+2*i),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()),
+		)
+	}
+
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = b.Len()
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return b.Bytes()
+}
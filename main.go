@@ -3,6 +3,7 @@ package main
 import (
 	"cashier-api/database"
 	"cashier-api/handlers"
+	"cashier-api/notifiers"
 	"cashier-api/repositories"
 	"cashier-api/response"
 	"cashier-api/services"
@@ -12,13 +13,17 @@ import (
 	"net/http"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 )
 
 type Config struct {
-	Port   string `mapstructure:"PORT"`
-	DBConn string `mapstructure:"DB_CONN"`
+	Port               string `mapstructure:"PORT"`
+	DBConn             string `mapstructure:"DB_CONN"`
+	LowStockWebhookURL string `mapstructure:"LOW_STOCK_WEBHOOK_URL"`
+	TimeZone           string `mapstructure:"TIMEZONE"`
+	AdminAPIKey        string `mapstructure:"ADMIN_API_KEY"`
 }
 
 func main() {
@@ -31,8 +36,20 @@ func main() {
 	}
 
 	config := Config{
-		Port:   viper.GetString("PORT"),
-		DBConn: viper.GetString("DB_CONN"),
+		Port:               viper.GetString("PORT"),
+		DBConn:             viper.GetString("DB_CONN"),
+		LowStockWebhookURL: viper.GetString("LOW_STOCK_WEBHOOK_URL"),
+		TimeZone:           viper.GetString("TIMEZONE"),
+		AdminAPIKey:        viper.GetString("ADMIN_API_KEY"),
+	}
+
+	// Dates and scheduled times without an offset are in the store time zone.
+	if config.TimeZone != "" {
+		location, err := time.LoadLocation(config.TimeZone)
+		if err != nil {
+			log.Fatal("Invalid TIMEZONE:", err)
+		}
+		time.Local = location
 	}
 
 	db, err := database.InitDB(config.DBConn)
@@ -55,6 +72,40 @@ func main() {
 	productHandler := handlers.NewProductHandler(productService)
 	http.HandleFunc("/api/products", productHandler.HandleProducts)
 	http.HandleFunc("/api/products/", productHandler.HandleProductByID)
+	http.HandleFunc("/api/products/lookup", productHandler.HandleLookup)
+
+	priceRepo := repositories.NewPriceRepository(db)
+	priceService := services.NewPriceService(priceRepo)
+	priceHandler := handlers.NewPriceHandler(priceService)
+	http.HandleFunc("/api/products/{id}/prices", priceHandler.HandlePrices)
+	http.HandleFunc("/api/products/{id}/prices/{price_id}", priceHandler.HandlePriceByID)
+
+	parentProductRepo := repositories.NewParentProductRepository(db)
+	parentProductService := services.NewParentProductService(parentProductRepo)
+	parentProductHandler := handlers.NewParentProductHandler(parentProductService)
+	http.HandleFunc("/api/parent-products", parentProductHandler.HandleParentProducts)
+	http.HandleFunc("/api/parent-products/{id}", parentProductHandler.HandleParentProductByID)
+	http.HandleFunc("/api/catalog", parentProductHandler.HandleCatalog)
+
+	modifierRepo := repositories.NewModifierRepository(db)
+	modifierService := services.NewModifierService(modifierRepo)
+	modifierHandler := handlers.NewModifierHandler(modifierService)
+	http.HandleFunc("/api/modifier-groups", modifierHandler.HandleModifierGroups)
+	http.HandleFunc("/api/modifier-groups/{id}", modifierHandler.HandleModifierGroupByID)
+	http.HandleFunc("/api/products/{id}/modifier-groups", modifierHandler.HandleProductModifierGroups)
+
+	recipeRepo := repositories.NewRecipeRepository(db)
+	recipeService := services.NewRecipeService(recipeRepo)
+	recipeHandler := handlers.NewRecipeHandler(recipeService)
+	http.HandleFunc("/api/products/{id}/recipe", recipeHandler.HandleRecipe)
+	http.HandleFunc("/api/report/ingredient-usage", recipeHandler.HandleIngredientUsage)
+
+	bundleRepo := repositories.NewBundleRepository(db)
+	bundleService := services.NewBundleService(bundleRepo)
+	bundleHandler := handlers.NewBundleHandler(bundleService)
+	http.HandleFunc("/api/bundles", bundleHandler.HandleBundles)
+	http.HandleFunc("/api/bundles/{id}", bundleHandler.HandleBundleByID)
+	http.HandleFunc("/api/report/bundles", bundleHandler.HandleSalesReport)
 
 	categoryRepo := repositories.NewCategoryRepository(db)
 	categoryService := services.NewCategoryService(categoryRepo)
@@ -62,17 +113,158 @@ func main() {
 	http.HandleFunc("/api/categories", categoryHandler.HandleCategories)
 	http.HandleFunc("/api/categories/", categoryHandler.HandleCategoryByID)
 
+	lowStockNotifiers := []notifiers.Notifier{notifiers.NewLogNotifier()}
+	if config.LowStockWebhookURL != "" {
+		lowStockNotifiers = append(lowStockNotifiers, notifiers.NewWebhookNotifier(config.LowStockWebhookURL))
+	}
+	lowStockDispatcher := notifiers.NewDispatcher(lowStockNotifiers...)
+
 	transactionRepo := repositories.NewTransactionRepository(db)
-	transactionService := services.NewTransactionService(transactionRepo)
+	transactionService := services.NewTransactionService(transactionRepo, lowStockDispatcher)
 	transactionHandler := handlers.NewTransactionHandler(transactionService)
 	http.HandleFunc("/api/checkout", transactionHandler.HandleCheckout)
+	http.HandleFunc("/api/transactions/{id}", transactionHandler.HandleTransactionByID)
+	http.HandleFunc("/api/transactions/{id}/receipt", transactionHandler.HandleReceipt)
+	http.HandleFunc("/api/transactions/{id}/refunds", transactionHandler.HandleRefunds)
 
 	http.HandleFunc("/api/report/hari-ini", transactionHandler.HandleReport)
+	http.HandleFunc("/api/report/gross-profit", transactionHandler.HandleGrossProfitReport)
+
+	stockMovementRepo := repositories.NewStockMovementRepository(db)
+	stockService := services.NewStockService(stockMovementRepo)
+	stockHandler := handlers.NewStockHandler(stockService)
+	http.HandleFunc("/api/products/{id}/stock-card", stockHandler.HandleStockCard)
+	http.HandleFunc("/api/products/{id}/stock-adjustments", stockHandler.HandleStockAdjustments)
+
+	stocktakeRepo := repositories.NewStocktakeRepository(db)
+	stocktakeService := services.NewStocktakeService(stocktakeRepo)
+	stocktakeHandler := handlers.NewStocktakeHandler(stocktakeService)
+	http.HandleFunc("/api/stocktakes", stocktakeHandler.HandleStocktakes)
+	http.HandleFunc("/api/stocktakes/{id}", stocktakeHandler.HandleStocktakeByID)
+	http.HandleFunc("/api/stocktakes/{id}/counts", stocktakeHandler.HandleCounts)
+	http.HandleFunc("/api/stocktakes/{id}/counts/{count_id}", stocktakeHandler.HandleCountByID)
+	http.HandleFunc("/api/stocktakes/{id}/finalize", stocktakeHandler.HandleFinalize)
+	http.HandleFunc("/api/stocktakes/{id}/cancel", stocktakeHandler.HandleCancel)
+	http.HandleFunc("/api/stocktakes/{id}/variance", stocktakeHandler.HandleVariance)
+
+	supplierRepo := repositories.NewSupplierRepository(db)
+	supplierService := services.NewSupplierService(supplierRepo)
+	supplierHandler := handlers.NewSupplierHandler(supplierService)
+	http.HandleFunc("/api/suppliers", supplierHandler.HandleSuppliers)
+	http.HandleFunc("/api/suppliers/", supplierHandler.HandleSupplierByID)
+
+	purchaseOrderRepo := repositories.NewPurchaseOrderRepository(db)
+	purchaseOrderService := services.NewPurchaseOrderService(purchaseOrderRepo)
+	purchaseOrderHandler := handlers.NewPurchaseOrderHandler(purchaseOrderService)
+	http.HandleFunc("/api/purchase-orders", purchaseOrderHandler.HandlePurchaseOrders)
+	http.HandleFunc("/api/purchase-orders/{id}", purchaseOrderHandler.HandlePurchaseOrderByID)
+	http.HandleFunc("/api/purchase-orders/{id}/send", purchaseOrderHandler.HandleSend)
+	http.HandleFunc("/api/purchase-orders/{id}/close", purchaseOrderHandler.HandleClose)
+	http.HandleFunc("/api/purchase-orders/{id}/receipts", purchaseOrderHandler.HandleReceipts)
+	http.HandleFunc("/api/report/open-purchase-orders", purchaseOrderHandler.HandleOpenReport)
+
+	inventoryRepo := repositories.NewInventoryRepository(db)
+	inventoryService := services.NewInventoryService(inventoryRepo)
+	inventoryHandler := handlers.NewInventoryHandler(inventoryService)
+	http.HandleFunc("/api/inventory/low-stock", inventoryHandler.HandleLowStock)
+	http.HandleFunc("/api/inventory/suggested-purchase-orders", inventoryHandler.HandleSuggestedPurchaseOrders)
+	http.HandleFunc("/api/inventory/valuation", inventoryHandler.HandleValuation)
+	http.HandleFunc("/api/inventory/near-expiry", inventoryHandler.HandleNearExpiry)
+	http.HandleFunc("/api/products/{id}/lots", inventoryHandler.HandleLots)
+
+	stockTransferRepo := repositories.NewStockTransferRepository(db)
+	stockTransferService := services.NewStockTransferService(stockTransferRepo)
+	stockTransferHandler := handlers.NewStockTransferHandler(stockTransferService)
+	http.HandleFunc("/api/stock-transfers", stockTransferHandler.HandleStockTransfers)
+	http.HandleFunc("/api/stock-transfers/{id}", stockTransferHandler.HandleStockTransferByID)
+	http.HandleFunc("/api/stock-transfers/{id}/dispatch", stockTransferHandler.HandleDispatch)
+	http.HandleFunc("/api/stock-transfers/{id}/receive", stockTransferHandler.HandleReceive)
+	http.HandleFunc("/api/stock-transfers/{id}/cancel", stockTransferHandler.HandleCancel)
+
+	outletRepo := repositories.NewOutletRepository(db)
+	outletService := services.NewOutletService(outletRepo)
+	outletHandler := handlers.NewOutletHandler(outletService)
+	http.HandleFunc("/api/outlets", handlers.AdminAuth(config.AdminAPIKey, outletHandler.HandleOutlets))
+	http.HandleFunc("/api/outlets/{id}", handlers.AdminAuth(config.AdminAPIKey, outletHandler.HandleOutletByID))
+	http.HandleFunc("/api/outlets/{id}/terminals", handlers.AdminAuth(config.AdminAPIKey, outletHandler.HandleTerminals))
+	http.HandleFunc("/api/outlets/{id}/terminals/{terminal_id}", handlers.AdminAuth(config.AdminAPIKey, outletHandler.HandleTerminalByID))
+	http.HandleFunc("/api/outlets/{id}/prices/{product_id}", handlers.AdminAuth(config.AdminAPIKey, outletHandler.HandlePrice))
+	http.HandleFunc("/api/report/outlets", outletHandler.HandleReport)
+
+	priceListRepo := repositories.NewPriceListRepository(db)
+	priceListService := services.NewPriceListService(priceListRepo)
+	priceListHandler := handlers.NewPriceListHandler(priceListService)
+	http.HandleFunc("/api/price-lists", priceListHandler.HandlePriceLists)
+	http.HandleFunc("/api/price-lists/{id}", priceListHandler.HandlePriceListByID)
+
+	customerRepo := repositories.NewCustomerRepository(db)
+	customerService := services.NewCustomerService(customerRepo)
+	customerHandler := handlers.NewCustomerHandler(customerService)
+	http.HandleFunc("/api/customers", customerHandler.HandleCustomers)
+	http.HandleFunc("/api/customers/{id}", customerHandler.HandleCustomerByID)
+	http.HandleFunc("/api/customers/{id}/history", customerHandler.HandleHistory)
+	http.HandleFunc("/api/customer-groups", customerHandler.HandleGroups)
+	http.HandleFunc("/api/customer-groups/{id}", customerHandler.HandleGroupByID)
+
+	loyaltyRepo := repositories.NewLoyaltyRepository(db)
+	loyaltyService := services.NewLoyaltyService(loyaltyRepo)
+	loyaltyHandler := handlers.NewLoyaltyHandler(loyaltyService)
+	http.HandleFunc("/api/loyalty", loyaltyHandler.HandleSettings)
+	http.HandleFunc("/api/customers/{id}/points", loyaltyHandler.HandlePoints)
+
+	storedValueRepo := repositories.NewStoredValueRepository(db)
+	storedValueService := services.NewStoredValueService(storedValueRepo)
+	storedValueHandler := handlers.NewStoredValueHandler(storedValueService)
+	http.HandleFunc("/api/gift-cards/{code}", storedValueHandler.HandleGiftCard)
+	http.HandleFunc("/api/customers/{id}/store-credit", storedValueHandler.HandleStoreCredit)
+
+	receivableRepo := repositories.NewReceivableRepository(db)
+	receivableService := services.NewReceivableService(receivableRepo)
+	receivableHandler := handlers.NewReceivableHandler(receivableService)
+	http.HandleFunc("/api/receivables", receivableHandler.HandleReport)
+	http.HandleFunc("/api/customers/{id}/statement", receivableHandler.HandleStatement)
+	http.HandleFunc("/api/customers/{id}/repayments", receivableHandler.HandleRepayments)
+
+	layawayRepo := repositories.NewLayawayRepository(db)
+	layawayService := services.NewLayawayService(layawayRepo, lowStockDispatcher)
+	layawayHandler := handlers.NewLayawayHandler(layawayService)
+	http.HandleFunc("/api/layaways", layawayHandler.HandleLayaways)
+	http.HandleFunc("/api/layaways/settings", layawayHandler.HandleSettings)
+	http.HandleFunc("/api/layaways/{id}", layawayHandler.HandleLayawayByID)
+	http.HandleFunc("/api/layaways/{id}/payments", layawayHandler.HandlePayments)
+	http.HandleFunc("/api/layaways/{id}/complete", layawayHandler.HandleComplete)
+	http.HandleFunc("/api/layaways/{id}/cancel", layawayHandler.HandleCancel)
+	http.HandleFunc("/api/report/layaways", layawayHandler.HandleReport)
+
+	orderRepo := repositories.NewOrderRepository(db)
+	orderService := services.NewOrderService(orderRepo, lowStockDispatcher)
+	orderHandler := handlers.NewOrderHandler(orderService)
+	http.HandleFunc("/api/orders", orderHandler.HandleOrders)
+	http.HandleFunc("/api/orders/{id}", orderHandler.HandleOrderByID)
+	http.HandleFunc("/api/orders/{id}/items", orderHandler.HandleItems)
+	http.HandleFunc("/api/orders/{id}/items/{item_id}", orderHandler.HandleItemByID)
+	http.HandleFunc("/api/orders/{id}/split", orderHandler.HandleSplit)
+	http.HandleFunc("/api/orders/{id}/settlements/{settlement_id}/payments", orderHandler.HandleSettlementPayments)
+	http.HandleFunc("/api/orders/{id}/settlements/{settlement_id}/receipt", orderHandler.HandleSettlementReceipt)
+	http.HandleFunc("/api/orders/{id}/cancel", orderHandler.HandleCancel)
+
+	labelRepo := repositories.NewLabelRepository(db)
+	labelService := services.NewLabelService(labelRepo)
+	labelHandler := handlers.NewLabelHandler(labelService)
+	http.HandleFunc("/api/products/{id}/label", labelHandler.HandleProductLabel)
+	http.HandleFunc("/api/labels", labelHandler.HandleLabels)
+	http.HandleFunc("/api/labels/changed", labelHandler.HandleChanged)
+	http.HandleFunc("/api/labels/print-runs", labelHandler.HandlePrintRuns)
+
+	auditLogRepo := repositories.NewAuditLogRepository(db)
+	auditLogService := services.NewAuditLogService(auditLogRepo)
+	auditLogHandler := handlers.NewAuditLogHandler(auditLogService)
+	http.HandleFunc("/api/audit-logs", auditLogHandler.HandleAuditLogs)
 
 	addr := "0.0.0.0:" + config.Port
 	fmt.Println("Server running in", addr)
 
-	err = http.ListenAndServe(addr, nil)
+	err = http.ListenAndServe(addr, handlers.RequestID(handlers.TerminalAuth(outletService, http.DefaultServeMux)))
 	if err != nil {
 		fmt.Println("Failed running server")
 	}
@@ -0,0 +1,85 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	AuditActionCreate = "create"
+	AuditActionUpdate = "update"
+	AuditActionDelete = "delete"
+
+	AuditActionStockAdjustment = "stock_adjustment"
+	AuditActionPayment         = "payment"
+	AuditActionCancel          = "cancel"
+
+	AuditEntityProduct  = "product"
+	AuditEntityCategory = "category"
+	AuditEntitySupplier = "supplier"
+	AuditEntityOutlet   = "outlet"
+
+	AuditEntityParentProduct = "parent_product"
+	AuditEntityModifierGroup = "modifier_group"
+	AuditEntityRecipe        = "recipe"
+	AuditEntityBundle        = "bundle"
+	AuditEntityPriceList     = "price_list"
+	AuditEntityCustomerGroup = "customer_group"
+	AuditEntityCustomer      = "customer"
+	AuditEntityLayaway       = "layaway"
+
+	// AuditEntityOutletPrice entries are keyed by product ID.
+	AuditEntityOutletPrice = "outlet_price"
+	// AuditEntityProductPrice entries are keyed by product ID.
+	AuditEntityProductPrice = "product_price"
+	// AuditEntityLoyaltySettings has a single entry, with ID 1.
+	AuditEntityLoyaltySettings = "loyalty_settings"
+	// AuditEntityLayawaySettings has a single entry, with ID 1.
+	AuditEntityLayawaySettings = "layaway_settings"
+)
+
+type AuditLog struct {
+	ID        int             `json:"id"`
+	Actor     string          `json:"actor"`
+	Action    string          `json:"action"`
+	Entity    string          `json:"entity"`
+	EntityID  int             `json:"entity_id"`
+	Before    json.RawMessage `json:"before"`
+	After     json.RawMessage `json:"after"`
+	Changes   json.RawMessage `json:"changes"`
+	RequestID string          `json:"request_id"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+type AuditChange struct {
+	From any `json:"from"`
+	To   any `json:"to"`
+}
+
+type AuditLogFilter struct {
+	Entity   string
+	EntityID int
+	Actor    string
+	Action   string
+	From     *time.Time
+	To       *time.Time
+	Limit    int
+}
+
+// RequestMeta identifies who made a change, which request it came from and,
+// for authenticated terminals, which outlet it applies to.
+type RequestMeta struct {
+	Actor      string
+	RequestID  string
+	TerminalID int
+	OutletID   int
+}
+
+// ActorName returns the actor recorded for a change, "anonymous" when the
+// request did not identify one.
+func (m RequestMeta) ActorName() string {
+	if m.Actor == "" {
+		return "anonymous"
+	}
+	return m.Actor
+}
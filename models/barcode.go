@@ -0,0 +1,143 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+)
+
+const (
+	BarcodeEAN13    = "ean13"
+	BarcodeUPCA     = "upca"
+	BarcodeEAN8     = "ean8"
+	BarcodeInternal = "internal"
+
+	// Scale labels are EAN-13 codes starting with 2. The first seven digits
+	// identify the item and are registered as its barcode; the next five
+	// carry the weight, in thousandths of the product's base unit, or the
+	// price of the item.
+	BarcodeScaleWeight = "scale_weight"
+	BarcodeScalePrice  = "scale_price"
+
+	ScalePrefixLength = 7
+)
+
+// ProductBarcode is one of the codes a product can be scanned by.
+type ProductBarcode struct {
+	Barcode string `json:"barcode"`
+	Kind    string `json:"kind"`
+}
+
+// BarcodeMatch is what a scanned code resolves to. Quantity and Price are set
+// for scale labels, from the weight or price they carry.
+type BarcodeMatch struct {
+	Barcode  string    `json:"barcode"`
+	Kind     string    `json:"kind"`
+	Product  Product   `json:"product"`
+	Quantity *Quantity `json:"quantity,omitempty"`
+	Price    *int      `json:"price,omitempty"`
+}
+
+// Validate checks a barcode against its kind, guessing the kind from its
+// length when none is given: 13, 12 and 8 digit codes are taken as EAN-13,
+// UPC-A and EAN-8 and must carry a valid check digit.
+func (b *ProductBarcode) Validate() error {
+	if b.Barcode == "" {
+		return fmt.Errorf("barcode is required")
+	}
+	if b.Kind == "" {
+		b.Kind = BarcodeInternal
+		if isDigits(b.Barcode) {
+			switch len(b.Barcode) {
+			case 13:
+				b.Kind = BarcodeEAN13
+			case 12:
+				b.Kind = BarcodeUPCA
+			case 8:
+				b.Kind = BarcodeEAN8
+			}
+		}
+	}
+
+	switch b.Kind {
+	case BarcodeEAN13, BarcodeUPCA, BarcodeEAN8:
+		length := map[string]int{BarcodeEAN13: 13, BarcodeUPCA: 12, BarcodeEAN8: 8}[b.Kind]
+		if len(b.Barcode) != length || !isDigits(b.Barcode) {
+			return fmt.Errorf("barcode %q is not a %d digit %s code", b.Barcode, length, b.Kind)
+		}
+		if !ValidCheckDigit(b.Barcode) {
+			return fmt.Errorf("barcode %q has an invalid check digit", b.Barcode)
+		}
+	case BarcodeScaleWeight, BarcodeScalePrice:
+		if len(b.Barcode) != ScalePrefixLength || !isDigits(b.Barcode) || b.Barcode[0] != '2' {
+			return fmt.Errorf("scale barcode %q must be the first %d digits of the label, starting with 2", b.Barcode, ScalePrefixLength)
+		}
+	case BarcodeInternal:
+		for _, r := range b.Barcode {
+			if r <= ' ' || r > '~' {
+				return fmt.Errorf("barcode %q must be printable ASCII without spaces", b.Barcode)
+			}
+		}
+	default:
+		return fmt.Errorf("invalid barcode kind %q", b.Kind)
+	}
+
+	return nil
+}
+
+// CheckDigit computes the GS1 check digit for the digits of an EAN or UPC
+// code without its check digit: weights alternate 3 and 1 from the right.
+func CheckDigit(digits string) int {
+	sum := 0
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if (len(digits)-i)%2 == 1 {
+			d *= 3
+		}
+		sum += d
+	}
+	return (10 - sum%10) % 10
+}
+
+// ValidCheckDigit reports whether the last digit of an EAN or UPC code is
+// its check digit.
+func ValidCheckDigit(code string) bool {
+	if len(code) < 2 || !isDigits(code) {
+		return false
+	}
+	return CheckDigit(code[:len(code)-1]) == int(code[len(code)-1]-'0')
+}
+
+// ParseScaleBarcode splits a scale label into the prefix identifying the
+// item and the value it carries. ok is false for anything but a valid
+// EAN-13 starting with 2.
+func ParseScaleBarcode(code string) (prefix string, value int, ok bool) {
+	if len(code) != 13 || code[0] != '2' || !ValidCheckDigit(code) {
+		return "", 0, false
+	}
+	value, err := strconv.Atoi(code[ScalePrefixLength:12])
+	if err != nil {
+		return "", 0, false
+	}
+	return code[:ScalePrefixLength], value, true
+}
+
+// QuantityForPrice is the quantity a price buys at unitPrice per unit,
+// rounded to three decimal places.
+func QuantityForPrice(price, unitPrice int) Quantity {
+	if unitPrice <= 0 {
+		return 0
+	}
+	return Quantity((int64(price)*QuantityScale + int64(unitPrice)/2) / int64(unitPrice))
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
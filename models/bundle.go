@@ -0,0 +1,98 @@
+package models
+
+import "time"
+
+// Bundle is a fixed set of products sold together at a package price.
+// Available is how many bundles the outlet's stock can still make up; it is
+// only set when the request comes from a terminal.
+type Bundle struct {
+	ID        int          `json:"id"`
+	Name      string       `json:"name"`
+	Price     int          `json:"price"`
+	Items     []BundleItem `json:"items"`
+	Available *int         `json:"available,omitempty"`
+}
+
+type BundleItem struct {
+	ProductID   int      `json:"product_id"`
+	ProductName string   `json:"product_name,omitempty"`
+	Quantity    Quantity `json:"quantity"`
+}
+
+// TransactionBundle is a bundle as it was sold. Its components are the
+// transaction details with its ID.
+type TransactionBundle struct {
+	ID       int      `json:"id"`
+	BundleID *int     `json:"bundle_id"`
+	Name     string   `json:"name"`
+	Price    int      `json:"price"`
+	Quantity Quantity `json:"quantity"`
+	Subtotal int      `json:"subtotal"`
+}
+
+type BundleSalesFilter struct {
+	OutletID int
+	From     *time.Time
+	To       *time.Time
+}
+
+// BundleSalesRow totals the sales of one bundle. Refunds of its components
+// are netted out of revenue and cost.
+type BundleSalesRow struct {
+	BundleID      *int     `json:"bundle_id"`
+	Name          string   `json:"name"`
+	Quantity      Quantity `json:"quantity"`
+	Revenue       int      `json:"revenue"`
+	Cost          int      `json:"cost"`
+	GrossProfit   int      `json:"gross_profit"`
+	MarginPercent float64  `json:"margin_percent"`
+}
+
+type BundleSalesReport struct {
+	Total BundleSalesRow   `json:"total"`
+	Rows  []BundleSalesRow `json:"rows"`
+}
+
+// AllocateBundlePrice splits amount across components in proportion to
+// their weights, usually each component's regular price times quantity.
+// Shares are rounded down and the pennies left over go to the largest
+// remainders, so the shares always add up to amount. Components share
+// equally when every weight is zero.
+func AllocateBundlePrice(amount int, weights []int) []int {
+	shares := make([]int, len(weights))
+	if len(weights) == 0 {
+		return shares
+	}
+
+	total := 0
+	for _, w := range weights {
+		total += w
+	}
+	if total == 0 {
+		weights = make([]int, len(shares))
+		for i := range weights {
+			weights[i] = 1
+		}
+		total = len(weights)
+	}
+
+	remainders := make([]int, len(weights))
+	left := amount
+	for i, w := range weights {
+		shares[i] = amount * w / total
+		remainders[i] = amount * w % total
+		left -= shares[i]
+	}
+	for ; left > 0; left-- {
+		best := 0
+		for i := range remainders {
+			if remainders[i] > remainders[best] {
+				best = i
+			}
+		}
+		shares[best]++
+		remainders[best] = -1
+	}
+
+	return shares
+}
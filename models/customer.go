@@ -0,0 +1,107 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Customer is an entry in the customer directory. Phone is in E.164 and
+// unique; Tags are free-form labels such as "member" or "reseller".
+// CreditLimit is how much the customer may owe on account; 0 means they
+// cannot buy on account.
+type Customer struct {
+	ID              int       `json:"id"`
+	Name            string    `json:"name"`
+	Phone           string    `json:"phone"`
+	Email           string    `json:"email"`
+	Address         string    `json:"address"`
+	Tags            []string  `json:"tags"`
+	CustomerGroupID *int      `json:"customer_group_id"`
+	CreditLimit     int       `json:"credit_limit"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// CustomerFilter searches customers by name, phone or email, and by tag.
+type CustomerFilter struct {
+	Query string
+	Tag   string
+	Limit int
+}
+
+// CustomerStats sums up a customer's purchases. Refunds are netted out of
+// LifetimeValue. DaysBetweenVisits and VisitsPerMonth are averages over the
+// time from the first visit to the last, and zero until the second visit.
+type CustomerStats struct {
+	Visits            int        `json:"visits"`
+	LifetimeValue     int        `json:"lifetime_value"`
+	AverageSpend      int        `json:"average_spend"`
+	FirstVisit        *time.Time `json:"first_visit"`
+	LastVisit         *time.Time `json:"last_visit"`
+	DaysBetweenVisits float64    `json:"days_between_visits"`
+	VisitsPerMonth    float64    `json:"visits_per_month"`
+}
+
+// CustomerPurchase is one sale to a customer.
+type CustomerPurchase struct {
+	TransactionID int       `json:"transaction_id"`
+	OutletID      int       `json:"outlet_id"`
+	OutletName    string    `json:"outlet_name"`
+	TotalAmount   int       `json:"total_amount"`
+	RefundAmount  int       `json:"refund_amount"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type CustomerHistory struct {
+	Customer  Customer           `json:"customer"`
+	Stats     CustomerStats      `json:"stats"`
+	Purchases []CustomerPurchase `json:"purchases"`
+}
+
+// NormalizePhone writes a phone number in E.164. Numbers without a country
+// code are Indonesian: 0812-3456-789, 812 3456 789, 62812... and +62 0812...
+// all become +628123456789. Numbers with another country code are kept.
+func NormalizePhone(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	invalid := fmt.Errorf("invalid phone number %q", s)
+	digits := strings.Map(func(r rune) rune {
+		switch {
+		case r >= '0' && r <= '9':
+			return r
+		case r == ' ' || r == '-' || r == '.' || r == '(' || r == ')':
+			return -1
+		case r == '+':
+			return r
+		default:
+			return 'x'
+		}
+	}, s)
+	if strings.ContainsRune(digits, 'x') || strings.LastIndex(digits, "+") > 0 {
+		return "", invalid
+	}
+
+	digits = strings.TrimPrefix(digits, "+")
+	switch {
+	case strings.HasPrefix(s, "+") || strings.HasPrefix(digits, "00"):
+		digits = strings.TrimPrefix(digits, "00")
+	case strings.HasPrefix(digits, "62"):
+	case strings.HasPrefix(digits, "0"):
+		digits = "62" + digits[1:]
+	case strings.HasPrefix(digits, "8"):
+		digits = "62" + digits
+	default:
+		return "", invalid
+	}
+
+	if national, ok := strings.CutPrefix(digits, "62"); ok {
+		national = strings.TrimPrefix(national, "0")
+		if len(national) < 7 || len(national) > 12 || national[0] == '0' {
+			return "", invalid
+		}
+		digits = "62" + national
+	}
+	if len(digits) < 8 || len(digits) > 15 || digits[0] == '0' {
+		return "", invalid
+	}
+	return "+" + digits, nil
+}
@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// LowStockEvent is emitted when a checkout takes a product to or below its
+// minimum stock.
+type LowStockEvent struct {
+	OutletID      int       `json:"outlet_id"`
+	ProductID     int       `json:"product_id"`
+	ProductName   string    `json:"product_name"`
+	Stock         Quantity  `json:"stock"`
+	MinStock      Quantity  `json:"min_stock"`
+	ReorderQty    Quantity  `json:"reorder_qty"`
+	TransactionID int       `json:"transaction_id"`
+	OccurredAt    time.Time `json:"occurred_at"`
+}
+
+type LowStockItem struct {
+	ProductID         int      `json:"product_id"`
+	ProductName       string   `json:"product_name"`
+	Stock             Quantity `json:"stock"`
+	MinStock          Quantity `json:"min_stock"`
+	ReorderQty        Quantity `json:"reorder_qty"`
+	OnOrder           Quantity `json:"on_order"`
+	SuggestedQuantity Quantity `json:"suggested_quantity"`
+	UnitCost          int      `json:"unit_cost"`
+	SupplierID        *int     `json:"supplier_id"`
+	SupplierName      string   `json:"supplier_name,omitempty"`
+}
+
+type SuggestedPurchaseOrders struct {
+	PurchaseOrders []PurchaseOrder `json:"purchase_orders"`
+	// Skipped lists low-stock products that could not be ordered because
+	// they have no preferred supplier.
+	Skipped []LowStockItem `json:"skipped"`
+}
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// LabelPrintRequest prints shelf labels for ProductIDs, or for every
+// product when none are given. ChangedOnly keeps only products whose price
+// differs from the one on their last printed label, or that were never
+// printed.
+type LabelPrintRequest struct {
+	ProductIDs  []int `json:"product_ids"`
+	ChangedOnly bool  `json:"changed_only"`
+}
+
+type LabelPrintRun struct {
+	ID        int       `json:"id"`
+	OutletID  *int      `json:"outlet_id"`
+	PrintedBy string    `json:"printed_by"`
+	CreatedAt time.Time `json:"created_at"`
+	Products  []Product `json:"products"`
+}
+
+// LabelChange is a product whose shelf label shows an old price.
+// PrintedPrice is nil when it was never printed.
+type LabelChange struct {
+	ProductID    int    `json:"product_id"`
+	ProductName  string `json:"product_name"`
+	Price        int    `json:"price"`
+	PrintedPrice *int   `json:"printed_price"`
+}
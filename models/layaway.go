@@ -0,0 +1,123 @@
+package models
+
+import "time"
+
+const (
+	LayawayOpen      = "open"
+	LayawayCompleted = "completed"
+	LayawayCancelled = "cancelled"
+)
+
+// LayawaySettings configure layaways. A layaway needs a deposit of at least
+// MinDepositPercent of its total and is due TermDays after it is opened. On
+// cancellation the customer forfeits ForfeitPercent of what they paid, or
+// ExpiredForfeitPercent once it is past due, and at least ForfeitMin either
+// way.
+type LayawaySettings struct {
+	MinDepositPercent     int       `json:"min_deposit_percent"`
+	TermDays              int       `json:"term_days"`
+	ForfeitPercent        int       `json:"forfeit_percent"`
+	ForfeitMin            int       `json:"forfeit_min"`
+	ExpiredForfeitPercent int       `json:"expired_forfeit_percent"`
+	UpdatedAt             time.Time `json:"updated_at"`
+}
+
+// Forfeit returns how much of paid a customer forfeits when their layaway
+// is cancelled, before or after it fell due.
+func (s LayawaySettings) Forfeit(paid int, expired bool) int {
+	percent := s.ForfeitPercent
+	if expired {
+		percent = s.ExpiredForfeitPercent
+	}
+	return min(max(paid*percent/100, s.ForfeitMin), paid)
+}
+
+// Layaway holds goods for a customer at the prices of the day it was opened
+// while they pay for them in installments. Stock is reserved until the
+// layaway is completed into the sale TransactionID, or cancelled.
+type Layaway struct {
+	ID              int              `json:"id"`
+	OutletID        int              `json:"outlet_id"`
+	CustomerID      *int             `json:"customer_id"`
+	CustomerName    string           `json:"customer_name,omitempty"`
+	Status          string           `json:"status"`
+	TotalAmount     int              `json:"total_amount"`
+	PaidAmount      int              `json:"paid_amount"`
+	Balance         int              `json:"balance"`
+	DueAt           time.Time        `json:"due_at"`
+	Note            string           `json:"note"`
+	Items           []LayawayItem    `json:"items,omitempty"`
+	Payments        []LayawayPayment `json:"payments,omitempty"`
+	TransactionID   *int             `json:"transaction_id,omitempty"`
+	ForfeitedAmount int              `json:"forfeited_amount"`
+	RefundedAmount  int              `json:"refunded_amount"`
+	RefundMethod    string           `json:"refund_method,omitempty"`
+	CancelReason    string           `json:"cancel_reason,omitempty"`
+	CreatedBy       string           `json:"created_by"`
+	CreatedAt       time.Time        `json:"created_at"`
+	CompletedAt     *time.Time       `json:"completed_at,omitempty"`
+	CancelledAt     *time.Time       `json:"cancelled_at,omitempty"`
+}
+
+type LayawayItem struct {
+	ID          int      `json:"id"`
+	ProductID   int      `json:"product_id"`
+	ProductName string   `json:"product_name"`
+	Quantity    Quantity `json:"quantity"`
+	Subtotal    int      `json:"subtotal"`
+	PriceListID *int     `json:"price_list_id,omitempty"`
+}
+
+// LayawayPayment is an installment paid towards a layaway.
+type LayawayPayment struct {
+	ID        int       `json:"id"`
+	Method    string    `json:"method"`
+	Amount    int       `json:"amount"`
+	CreatedBy string    `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// LayawayRequest opens a layaway for CustomerID on Items, priced as a
+// checkout would price them. Payments are the deposit.
+type LayawayRequest struct {
+	CustomerID *int           `json:"customer_id"`
+	Items      []CheckoutItem `json:"items"`
+	Payments   []Payment      `json:"payments"`
+	Note       string         `json:"note"`
+}
+
+// LayawayCancelRequest cancels a layaway. What is not forfeited is paid
+// back in cash, or as store credit with StoreCredit.
+type LayawayCancelRequest struct {
+	Reason      string `json:"reason"`
+	StoreCredit bool   `json:"store_credit"`
+}
+
+type LayawayFilter struct {
+	Status     string
+	CustomerID int
+	OutletID   int
+	Limit      int
+}
+
+type LayawayReportFilter struct {
+	OutletID int
+	From     *time.Time
+	To       *time.Time
+}
+
+// LayawayReport separates money held for layaways from revenue. Deposits
+// held are what customers have paid on layaways still open, owed back to
+// them or to become revenue. Over the period, revenue is recognised when a
+// layaway is completed and forfeits when one is cancelled.
+type LayawayReport struct {
+	OpenLayaways      int `json:"open_layaways"`
+	DepositsHeld      int `json:"deposits_held"`
+	BalanceDue        int `json:"balance_due"`
+	DepositsReceived  int `json:"deposits_received"`
+	Completed         int `json:"completed"`
+	RevenueRecognized int `json:"revenue_recognized"`
+	Cancelled         int `json:"cancelled"`
+	ForfeitedIncome   int `json:"forfeited_income"`
+	DepositsRefunded  int `json:"deposits_refunded"`
+}
@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+// DateLayout is the format of calendar dates such as lot expiry dates.
+const DateLayout = "2006-01-02"
+
+type Lot struct {
+	ID          int       `json:"id"`
+	OutletID    int       `json:"outlet_id"`
+	ProductID   int       `json:"product_id"`
+	ProductName string    `json:"product_name,omitempty"`
+	BatchNumber string    `json:"batch_number"`
+	ExpiryDate  *string   `json:"expiry_date"`
+	Remaining   Quantity  `json:"remaining"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// LotAllocation is a quantity moved into or out of one lot.
+type LotAllocation struct {
+	LotID       int      `json:"lot_id,omitempty"`
+	BatchNumber string   `json:"batch_number"`
+	ExpiryDate  *string  `json:"expiry_date"`
+	Quantity    Quantity `json:"quantity"`
+}
+
+// NearExpiryItem is a lot expiring within the report window. DaysLeft is
+// negative once the lot has expired.
+type NearExpiryItem struct {
+	Lot
+	DaysLeft int  `json:"days_left"`
+	Expired  bool `json:"expired"`
+	Value    int  `json:"value"`
+}
+
+type NearExpiryReport struct {
+	Days          int              `json:"days"`
+	TotalQuantity Quantity         `json:"total_quantity"`
+	TotalValue    int              `json:"total_value"`
+	Items         []NearExpiryItem `json:"items"`
+}
+
+// ValidDate reports whether s is a calendar date in DateLayout.
+func ValidDate(s string) bool {
+	_, err := time.Parse(DateLayout, s)
+	return err == nil
+}
@@ -0,0 +1,75 @@
+package models
+
+import "time"
+
+const (
+	PointsReasonEarn    = "earn"
+	PointsReasonRedeem  = "redeem"
+	PointsReasonReverse = "reverse"
+	PointsReasonRestore = "restore"
+	PointsReasonExpire  = "expire"
+	PointsReasonAdjust  = "adjust"
+)
+
+// LoyaltySettings configure the points programme. Customers earn a point for
+// every SpendPerPoint rupiah spent and redeem a point for PointValue rupiah,
+// at least MinRedeem points at a time. Points expire ExpiryDays after they
+// were earned, or never when ExpiryDays is 0.
+type LoyaltySettings struct {
+	Enabled       bool          `json:"enabled"`
+	SpendPerPoint int           `json:"spend_per_point"`
+	PointValue    int           `json:"point_value"`
+	MinRedeem     int           `json:"min_redeem"`
+	ExpiryDays    int           `json:"expiry_days"`
+	Rules         []LoyaltyRule `json:"rules"`
+	UpdatedAt     time.Time     `json:"updated_at"`
+}
+
+// LoyaltyRule multiplies the spend that earns points on a category or a
+// product; a product's rule wins over its category's. A Multiplier of 0
+// excludes them from earning.
+type LoyaltyRule struct {
+	CategoryID *int     `json:"category_id"`
+	ProductID  *int     `json:"product_id"`
+	Name       string   `json:"name,omitempty"`
+	Multiplier Quantity `json:"multiplier"`
+}
+
+// PointsEntry is one line of a customer's points ledger. Points is positive
+// for earned, restored and added points, negative for redeemed, reversed,
+// expired and removed ones. ExpiresAt is when points added expire.
+type PointsEntry struct {
+	ID            int        `json:"id"`
+	CustomerID    int        `json:"customer_id"`
+	Points        int        `json:"points"`
+	Reason        string     `json:"reason"`
+	TransactionID *int       `json:"transaction_id,omitempty"`
+	RefundID      *int       `json:"refund_id,omitempty"`
+	Note          string     `json:"note,omitempty"`
+	ExpiresAt     *time.Time `json:"expires_at,omitempty"`
+	CreatedBy     string     `json:"created_by,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// PointsExpiry is a number of points that expire together.
+type PointsExpiry struct {
+	Points    int       `json:"points"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// PointsAccount is a customer's points balance, what of it expires when, and
+// the latest ledger entries, newest first.
+type PointsAccount struct {
+	CustomerID int            `json:"customer_id"`
+	Balance    int            `json:"balance"`
+	Value      int            `json:"value"`
+	Expiring   []PointsExpiry `json:"expiring"`
+	Ledger     []PointsEntry  `json:"ledger"`
+}
+
+// PointsAdjustment adds points to a customer's balance, or removes them when
+// Points is negative.
+type PointsAdjustment struct {
+	Points int    `json:"points"`
+	Note   string `json:"note"`
+}
@@ -0,0 +1,33 @@
+package models
+
+type ModifierGroup struct {
+	ID        int        `json:"id"`
+	Name      string     `json:"name"`
+	MinSelect int        `json:"min_select"`
+	MaxSelect int        `json:"max_select"`
+	Modifiers []Modifier `json:"modifiers"`
+}
+
+// Modifier is one choice in a group. Price is added to the item's unit
+// price. When ProductID is set, each item sold uses up Quantity units of that
+// ingredient.
+type Modifier struct {
+	ID              int      `json:"id"`
+	ModifierGroupID int      `json:"modifier_group_id"`
+	Name            string   `json:"name"`
+	Price           int      `json:"price"`
+	ProductID       *int     `json:"product_id"`
+	Quantity        Quantity `json:"quantity"`
+}
+
+type ProductModifierGroupsRequest struct {
+	ModifierGroupIDs []int `json:"modifier_group_ids"`
+}
+
+// TransactionDetailModifier is a modifier as it was sold.
+type TransactionDetailModifier struct {
+	ModifierID *int   `json:"modifier_id"`
+	Name       string `json:"name"`
+	Price      int    `json:"price"`
+	CostAmount int    `json:"cost_amount"`
+}
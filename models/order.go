@@ -0,0 +1,139 @@
+package models
+
+import "time"
+
+const (
+	OrderOpen      = "open"
+	OrderSplit     = "split"
+	OrderSettled   = "settled"
+	OrderCancelled = "cancelled"
+
+	SettlementUnpaid = "unpaid"
+	SettlementPaid   = "paid"
+
+	// A bill is split by items, each share paying for the items it names,
+	// into equal shares, or into custom amounts.
+	SplitItems   = "items"
+	SplitEqual   = "equal"
+	SplitAmounts = "amounts"
+)
+
+// Order is a table's open tab. Items are added while it is open and priced
+// as a checkout would price them. Splitting the bill records the order as
+// the sale TransactionID, taking its stock once, and divides it into
+// settlements that are each paid on their own. The order is settled once
+// they all are.
+type Order struct {
+	ID            int               `json:"id"`
+	OutletID      int               `json:"outlet_id"`
+	TableName     string            `json:"table_name"`
+	CustomerID    *int              `json:"customer_id"`
+	CustomerName  string            `json:"customer_name,omitempty"`
+	Status        string            `json:"status"`
+	SplitMode     string            `json:"split_mode,omitempty"`
+	TotalAmount   int               `json:"total_amount"`
+	PaidAmount    int               `json:"paid_amount"`
+	Balance       int               `json:"balance"`
+	Note          string            `json:"note"`
+	Items         []OrderItem       `json:"items,omitempty"`
+	Settlements   []OrderSettlement `json:"settlements,omitempty"`
+	TransactionID *int              `json:"transaction_id,omitempty"`
+	CancelReason  string            `json:"cancel_reason,omitempty"`
+	CreatedBy     string            `json:"created_by"`
+	CreatedAt     time.Time         `json:"created_at"`
+	SplitAt       *time.Time        `json:"split_at,omitempty"`
+	SettledAt     *time.Time        `json:"settled_at,omitempty"`
+	CancelledAt   *time.Time        `json:"cancelled_at,omitempty"`
+}
+
+// OrderItem is an item as it was ordered. Name and Subtotal are priced at
+// today's prices while the order is open and fixed once its bill is split.
+type OrderItem struct {
+	ID           int       `json:"id"`
+	ProductID    int       `json:"product_id,omitempty"`
+	Barcode      string    `json:"barcode,omitempty"`
+	BundleID     int       `json:"bundle_id,omitempty"`
+	Quantity     Quantity  `json:"quantity"`
+	Unit         string    `json:"unit,omitempty"`
+	ModifierIDs  []int     `json:"modifier_ids,omitempty"`
+	Name         string    `json:"name"`
+	Subtotal     int       `json:"subtotal"`
+	SettlementID *int      `json:"settlement_id,omitempty"`
+	CreatedBy    string    `json:"created_by"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// CheckoutItem returns the item as it would be sold in a checkout.
+func (item OrderItem) CheckoutItem() CheckoutItem {
+	return CheckoutItem{
+		ProductID:   item.ProductID,
+		Barcode:     item.Barcode,
+		BundleID:    item.BundleID,
+		Quantity:    item.Quantity,
+		Unit:        item.Unit,
+		ModifierIDs: item.ModifierIDs,
+	}
+}
+
+// OrderSettlement is one share of a split bill. Payments are what it was
+// paid with and Change the cash handed back on top.
+type OrderSettlement struct {
+	ID       int        `json:"id"`
+	Amount   int        `json:"amount"`
+	Change   int        `json:"change"`
+	Status   string     `json:"status"`
+	ItemIDs  []int      `json:"item_ids,omitempty"`
+	Payments []Payment  `json:"payments"`
+	PaidBy   string     `json:"paid_by,omitempty"`
+	PaidAt   *time.Time `json:"paid_at,omitempty"`
+}
+
+// OrderRequest opens an order for a table, with the customer when known.
+type OrderRequest struct {
+	TableName  string         `json:"table_name"`
+	CustomerID *int           `json:"customer_id"`
+	Items      []CheckoutItem `json:"items"`
+	Note       string         `json:"note"`
+}
+
+type OrderItemsRequest struct {
+	Items []CheckoutItem `json:"items"`
+}
+
+// SplitRequest splits a bill. By items, Items lists the order item IDs
+// each share pays for, and every item must be in exactly one share; into
+// equal shares, Shares is how many; by amounts, Amounts are the shares and
+// must add up to the total. A bill paid whole is one equal share.
+type SplitRequest struct {
+	Mode    string  `json:"mode"`
+	Shares  int     `json:"shares"`
+	Amounts []int   `json:"amounts"`
+	Items   [][]int `json:"items"`
+}
+
+type SettlementRequest struct {
+	Payments []Payment `json:"payments"`
+}
+
+type OrderCancelRequest struct {
+	Reason string `json:"reason"`
+}
+
+type OrderFilter struct {
+	Status   string
+	OutletID int
+	Limit    int
+}
+
+// SplitEqually divides total into shares that differ by at most one, the
+// larger ones first.
+func SplitEqually(total, shares int) []int {
+	amounts := make([]int, shares)
+	for i := range amounts {
+		amounts[i] = total / shares
+		if i < total%shares {
+			amounts[i]++
+		}
+	}
+	return amounts
+}
@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+type Outlet struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	Address   string    `json:"address"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Terminal is a till that authenticates with an API key. The key is only
+// returned when the terminal is created.
+type Terminal struct {
+	ID        int       `json:"id"`
+	OutletID  int       `json:"outlet_id"`
+	Name      string    `json:"name"`
+	APIKey    string    `json:"api_key,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type OutletPrice struct {
+	OutletID  int  `json:"outlet_id"`
+	ProductID int  `json:"product_id"`
+	Price     *int `json:"price"`
+}
+
+type OutletSummary struct {
+	OutletID         int     `json:"outlet_id"`
+	OutletName       string  `json:"outlet_name"`
+	TotalTransaction int     `json:"total_transaksi"`
+	TotalRevenue     int     `json:"total_revenue"`
+	TotalRefund      int     `json:"total_refund"`
+	TotalCost        int     `json:"total_cost"`
+	GrossProfit      int     `json:"gross_profit"`
+	MarginPercent    float64 `json:"margin_percent"`
+}
+
+type OutletReport struct {
+	Total   OutletSummary   `json:"total"`
+	Outlets []OutletSummary `json:"outlets"`
+}
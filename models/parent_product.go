@@ -0,0 +1,91 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// VariantAxis is one dimension variants differ by, such as size.
+type VariantAxis struct {
+	Name   string   `json:"name"`
+	Values []string `json:"values"`
+}
+
+type ParentProduct struct {
+	ID         int           `json:"id"`
+	Name       string        `json:"name"`
+	CategoryID *int          `json:"category_id"`
+	Axes       []VariantAxis `json:"axes"`
+	Variants   []Product     `json:"variants,omitempty"`
+}
+
+// Catalog lists sellable products with variants grouped under their parent.
+type Catalog struct {
+	Parents  []ParentProduct `json:"parents"`
+	Products []Product       `json:"products"`
+}
+
+// ValidateAxes checks that every axis has a unique name and unique values.
+func ValidateAxes(axes []VariantAxis) error {
+	if len(axes) == 0 {
+		return fmt.Errorf("at least one option axis is required")
+	}
+
+	names := make(map[string]bool, len(axes))
+	for _, axis := range axes {
+		if axis.Name == "" || len(axis.Values) == 0 {
+			return fmt.Errorf("option axes need a name and at least one value")
+		}
+		if names[axis.Name] {
+			return fmt.Errorf("option axis %q is listed more than once", axis.Name)
+		}
+		names[axis.Name] = true
+
+		values := make(map[string]bool, len(axis.Values))
+		for _, value := range axis.Values {
+			if value == "" || values[value] {
+				return fmt.Errorf("option axis %q has an empty or repeated value", axis.Name)
+			}
+			values[value] = true
+		}
+	}
+
+	return nil
+}
+
+// ValidateOptions checks that options pick exactly one allowed value on every
+// axis of the parent.
+func (p *ParentProduct) ValidateOptions(options map[string]string) error {
+	if len(options) != len(p.Axes) {
+		return fmt.Errorf("variant options must set each of %s", p.axisNames())
+	}
+	for _, axis := range p.Axes {
+		value, ok := options[axis.Name]
+		if !ok {
+			return fmt.Errorf("variant options must set each of %s", p.axisNames())
+		}
+		if !slices.Contains(axis.Values, value) {
+			return fmt.Errorf("invalid %s %q", axis.Name, value)
+		}
+	}
+	return nil
+}
+
+// VariantName names a variant after its parent and option values in axis
+// order, e.g. "Kopi Susu Large / Less Sugar".
+func (p *ParentProduct) VariantName(options map[string]string) string {
+	values := make([]string, 0, len(p.Axes))
+	for _, axis := range p.Axes {
+		values = append(values, options[axis.Name])
+	}
+	return p.Name + " " + strings.Join(values, " / ")
+}
+
+func (p *ParentProduct) axisNames() string {
+	names := make([]string, 0, len(p.Axes))
+	for _, axis := range p.Axes {
+		names = append(names, axis.Name)
+	}
+	return strings.Join(names, ", ")
+}
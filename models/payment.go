@@ -0,0 +1,31 @@
+package models
+
+const (
+	PaymentCash     = "cash"
+	PaymentCard     = "card"
+	PaymentQRIS     = "qris"
+	PaymentTransfer = "transfer"
+	PaymentPoints   = "points"
+
+	// Stored value tenders spend a gift card, given by its Code, or the
+	// customer's store credit.
+	PaymentGiftCard    = "gift_card"
+	PaymentStoreCredit = "store_credit"
+
+	// PaymentOnAccount puts the amount on the customer's account, to be
+	// repaid later.
+	PaymentOnAccount = "on_account"
+)
+
+// Payment is one part of how a sale was paid, or of how a refund was paid
+// back. Amount is in rupiah; a points payment also carries the Points it
+// spent, or gave back on a refund. Gift card and store credit payments name
+// the AccountID they used, and gift cards their Code.
+type Payment struct {
+	ID        int    `json:"id,omitempty"`
+	Method    string `json:"method"`
+	Amount    int    `json:"amount"`
+	Points    int    `json:"points,omitempty"`
+	Code      string `json:"code,omitempty"`
+	AccountID *int   `json:"account_id,omitempty"`
+}
@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// PriceList sets product prices by quantity sold. The default list applies
+// to customers without a group price list and to walk-in sales.
+type PriceList struct {
+	ID        int             `json:"id"`
+	Name      string          `json:"name"`
+	IsDefault bool            `json:"is_default"`
+	Items     []PriceListItem `json:"items"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// PriceListItem prices a product from MinQuantity base units up to the
+// product's next item on the list.
+type PriceListItem struct {
+	ProductID   int      `json:"product_id"`
+	ProductName string   `json:"product_name,omitempty"`
+	MinQuantity Quantity `json:"min_quantity"`
+	Price       int      `json:"price"`
+}
+
+// CustomerGroup sells to its customers at its price list.
+type CustomerGroup struct {
+	ID          int       `json:"id"`
+	Name        string    `json:"name"`
+	PriceListID *int      `json:"price_list_id"`
+	CreatedAt   time.Time `json:"created_at"`
+}
@@ -1,8 +1,53 @@
 package models
 
+const (
+	CostingMethodAverage = "average"
+	CostingMethodFIFO    = "fifo"
+)
+
 type Product struct {
-	ID    int    `json:"id"`
-	Name  string `json:"name"`
-	Price int    `json:"price"`
-	Stock int    `json:"stock"`
+	ID            int      `json:"id"`
+	Name          string   `json:"name"`
+	Price         int      `json:"price"`
+	Stock         Quantity `json:"stock"`
+	CategoryID    *int     `json:"category_id"`
+	Cost          int      `json:"cost"`
+	CostingMethod string   `json:"costing_method"`
+	MinStock      Quantity `json:"min_stock"`
+	ReorderQty    Quantity `json:"reorder_qty"`
+	SupplierID    *int     `json:"supplier_id"`
+	TrackLots     bool     `json:"track_lots"`
+
+	// Stock, price and cost are per Unit, the base unit. Units lists other
+	// units the product is sold or bought in; PurchaseUnit is the unit
+	// purchase orders use by default, the base unit when empty.
+	Unit         string        `json:"unit"`
+	PurchaseUnit string        `json:"purchase_unit"`
+	Units        []ProductUnit `json:"units"`
+
+	// Composite products are made to order: a sale uses up the components
+	// in their recipe instead of the product's own stock.
+	IsComposite bool `json:"is_composite"`
+
+	// Gift cards are sold to load a new card with their price, or with the
+	// amount given at checkout. They hold no stock.
+	IsGiftCard bool `json:"is_gift_card"`
+
+	// Variants belong to a parent product and pick one value on each of its
+	// option axes.
+	ParentID *int              `json:"parent_id"`
+	Options  map[string]string `json:"options,omitempty"`
+	SKU      string            `json:"sku"`
+	Barcode  string            `json:"barcode"`
+
+	// Barcodes are all the codes the product scans by, including Barcode,
+	// its primary one.
+	Barcodes []ProductBarcode `json:"barcodes"`
+}
+
+// ProductUnit is Factor base units of a product under another name, such as
+// a box of 12.
+type ProductUnit struct {
+	Name   string   `json:"name"`
+	Factor Quantity `json:"factor"`
 }
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// DateTimeLayout is the format of a wall clock time in the store time zone.
+const DateTimeLayout = "2006-01-02 15:04"
+
+const (
+	PriceStatusPast      = "past"
+	PriceStatusCurrent   = "current"
+	PriceStatusScheduled = "scheduled"
+)
+
+// ProductPrice is a product's base price from EffectiveAt until the next
+// entry in its history takes effect.
+type ProductPrice struct {
+	ID          int       `json:"id"`
+	ProductID   int       `json:"product_id"`
+	Price       int       `json:"price"`
+	EffectiveAt time.Time `json:"effective_at"`
+	Status      string    `json:"status"`
+	CreatedBy   string    `json:"created_by"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// ProductPriceRequest changes a product's base price at EffectiveAt, or at
+// once when it is empty.
+type ProductPriceRequest struct {
+	Price       int    `json:"price"`
+	EffectiveAt string `json:"effective_at"`
+}
+
+type ProductPriceHistory struct {
+	ProductID   int            `json:"product_id"`
+	ProductName string         `json:"product_name"`
+	Price       int            `json:"price"`
+	Prices      []ProductPrice `json:"prices"`
+}
+
+// ParseStoreTime reads a time as RFC 3339, or as DateTimeLayout or
+// DateLayout (midnight) in the store time zone.
+func ParseStoreTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{DateTimeLayout, "2006-01-02T15:04", DateLayout} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, errors.New("Invalid effective_at, use YYYY-MM-DD HH:MM")
+}
@@ -0,0 +1,130 @@
+package models
+
+import "time"
+
+const (
+	PurchaseOrderStatusDraft             = "draft"
+	PurchaseOrderStatusSent              = "sent"
+	PurchaseOrderStatusPartiallyReceived = "partially_received"
+	PurchaseOrderStatusReceived          = "received"
+	PurchaseOrderStatusClosed            = "closed"
+
+	StockReferenceGoodsReceipt = "goods_receipt"
+)
+
+type PurchaseOrder struct {
+	ID           int                 `json:"id"`
+	OutletID     int                 `json:"outlet_id"`
+	SupplierID   int                 `json:"supplier_id"`
+	SupplierName string              `json:"supplier_name,omitempty"`
+	Status       string              `json:"status"`
+	Note         string              `json:"note"`
+	TotalAmount  int                 `json:"total_amount"`
+	CreatedBy    string              `json:"created_by"`
+	CreatedAt    time.Time           `json:"created_at"`
+	SentAt       *time.Time          `json:"sent_at,omitempty"`
+	ClosedAt     *time.Time          `json:"closed_at,omitempty"`
+	Lines        []PurchaseOrderLine `json:"lines,omitempty"`
+}
+
+type PurchaseOrderLine struct {
+	ID               int      `json:"id"`
+	PurchaseOrderID  int      `json:"purchase_order_id"`
+	ProductID        int      `json:"product_id"`
+	ProductName      string   `json:"product_name,omitempty"`
+	Quantity         Quantity `json:"quantity"`
+	UnitCost         int      `json:"unit_cost"`
+	ReceivedQuantity Quantity `json:"received_quantity"`
+	Subtotal         int      `json:"subtotal"`
+
+	// Quantities and the unit cost are per Unit, which holds Factor base
+	// units of the product.
+	Unit   string   `json:"unit"`
+	Factor Quantity `json:"factor"`
+}
+
+// PurchaseOrderLineRequest orders Quantity of Unit at UnitCost each. Unit
+// defaults to the product's purchase unit.
+type PurchaseOrderLineRequest struct {
+	ProductID int      `json:"product_id"`
+	Quantity  Quantity `json:"quantity"`
+	Unit      string   `json:"unit"`
+	UnitCost  int      `json:"unit_cost"`
+}
+
+// PurchaseOrderRequest creates or changes a draft order. OutletID defaults to
+// the outlet of the calling terminal.
+type PurchaseOrderRequest struct {
+	SupplierID int                        `json:"supplier_id"`
+	OutletID   int                        `json:"outlet_id"`
+	Note       string                     `json:"note"`
+	Lines      []PurchaseOrderLineRequest `json:"lines"`
+}
+
+type PurchaseOrderFilter struct {
+	SupplierID int
+	OutletID   int
+	Status     string
+}
+
+// GoodsReceiptLineRequest receives one line. Units of a lot-tracked product
+// go into the lot named by BatchNumber, which is created on first receipt.
+type GoodsReceiptLineRequest struct {
+	PurchaseOrderLineID int      `json:"purchase_order_line_id"`
+	Quantity            Quantity `json:"quantity"`
+	BatchNumber         string   `json:"batch_number"`
+	ExpiryDate          *string  `json:"expiry_date"`
+}
+
+// GoodsReceiptRequest receives stock against a purchase order. Receiving more
+// than is outstanding on a line is rejected unless AllowOverReceipt is set.
+type GoodsReceiptRequest struct {
+	Lines            []GoodsReceiptLineRequest `json:"lines"`
+	AllowOverReceipt bool                      `json:"allow_over_receipt"`
+	Note             string                    `json:"note"`
+}
+
+type GoodsReceipt struct {
+	ID              int                `json:"id"`
+	PurchaseOrderID int                `json:"purchase_order_id"`
+	Note            string             `json:"note"`
+	ReceivedBy      string             `json:"received_by"`
+	CreatedAt       time.Time          `json:"created_at"`
+	Lines           []GoodsReceiptLine `json:"lines"`
+}
+
+type GoodsReceiptLine struct {
+	ID                  int      `json:"id"`
+	PurchaseOrderLineID int      `json:"purchase_order_line_id"`
+	ProductID           int      `json:"product_id"`
+	Quantity            Quantity `json:"quantity"`
+	Unit                string   `json:"unit"`
+	UnitCost            int      `json:"unit_cost"`
+	BatchNumber         string   `json:"batch_number,omitempty"`
+	ExpiryDate          *string  `json:"expiry_date,omitempty"`
+}
+
+// OpenPurchaseOrder is one order of the open report. OutstandingQuantity is
+// in base units.
+type OpenPurchaseOrder struct {
+	ID                  int       `json:"id"`
+	Status              string    `json:"status"`
+	CreatedAt           time.Time `json:"created_at"`
+	OrderedValue        int       `json:"ordered_value"`
+	ReceivedValue       int       `json:"received_value"`
+	OutstandingQuantity Quantity  `json:"outstanding_quantity"`
+	OutstandingValue    int       `json:"outstanding_value"`
+}
+
+type SupplierOpenOrders struct {
+	SupplierID       int                 `json:"supplier_id"`
+	SupplierName     string              `json:"supplier_name"`
+	OrderCount       int                 `json:"order_count"`
+	OutstandingValue int                 `json:"outstanding_value"`
+	Orders           []OpenPurchaseOrder `json:"orders"`
+}
+
+type OpenPurchaseOrderReport struct {
+	TotalOutstandingValue int                  `json:"total_outstanding_value"`
+	Suppliers             []SupplierOpenOrders `json:"suppliers"`
+}
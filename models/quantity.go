@@ -0,0 +1,173 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// QuantityScale is the number of Quantity units in one whole unit: quantities
+// are kept to three decimal places.
+const QuantityScale = 1000
+
+// Quantity is a decimal quantity with three decimal places, held as a whole
+// number of thousandths so sums never drift. It reads and writes JSON as a
+// number and the database as NUMERIC.
+type Quantity int64
+
+// Units returns n whole units as a Quantity.
+func Units(n int) Quantity {
+	return Quantity(n) * QuantityScale
+}
+
+// ParseQuantity reads a decimal such as "0.75" or "-2". More than three
+// decimal places is an error rather than a silent rounding.
+func ParseQuantity(s string) (Quantity, error) {
+	s = strings.TrimSpace(s)
+	negative := strings.HasPrefix(s, "-")
+	digits := strings.TrimPrefix(s, "-")
+
+	whole, fraction, _ := strings.Cut(digits, ".")
+	if whole == "" && fraction == "" {
+		return 0, fmt.Errorf("invalid quantity %q", s)
+	}
+	fraction = strings.TrimRight(fraction, "0")
+	if len(fraction) > 3 {
+		return 0, fmt.Errorf("quantity %q has more than 3 decimal places", s)
+	}
+
+	var q int64
+	if whole != "" {
+		n, err := strconv.ParseInt(whole, 10, 64)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid quantity %q", s)
+		}
+		q = n * QuantityScale
+	}
+	if fraction != "" {
+		n, err := strconv.ParseInt(fraction+strings.Repeat("0", 3-len(fraction)), 10, 64)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid quantity %q", s)
+		}
+		q += n
+	}
+	if negative {
+		q = -q
+	}
+	return Quantity(q), nil
+}
+
+func (q Quantity) String() string {
+	sign := ""
+	n := int64(q)
+	if n < 0 {
+		sign, n = "-", -n
+	}
+	s := sign + strconv.FormatInt(n/QuantityScale, 10)
+	if fraction := n % QuantityScale; fraction != 0 {
+		s += "." + strings.TrimRight(fmt.Sprintf("%03d", fraction), "0")
+	}
+	return s
+}
+
+// Ceil returns the smallest whole number of units that covers q.
+func (q Quantity) Ceil() int {
+	n := int(q / QuantityScale)
+	if q > 0 && q%QuantityScale != 0 {
+		n++
+	}
+	return n
+}
+
+// IsWhole reports whether q is a whole number of units.
+func (q Quantity) IsWhole() bool {
+	return q%QuantityScale == 0
+}
+
+// Mul multiplies two quantities, such as a recipe quantity by the number of
+// items sold, rounding half away from zero to three decimal places.
+func (q Quantity) Mul(n Quantity) Quantity {
+	return Quantity(q.Times(int(n)))
+}
+
+// Prorate returns the share of amount that part is of whole, rounded down,
+// such as the refund due on part of a sold line.
+func Prorate(amount int, part, whole Quantity) int {
+	if whole == 0 {
+		return 0
+	}
+	return int(int64(amount) * int64(part) / int64(whole))
+}
+
+// PerUnit divides amount by quantity, such as a line cost into a unit cost,
+// rounding half away from zero. It returns 0 for a zero quantity.
+func PerUnit(amount int, quantity Quantity) int {
+	if quantity == 0 {
+		return 0
+	}
+	if quantity < 0 {
+		amount, quantity = -amount, -quantity
+	}
+	v := int64(amount) * QuantityScale
+	if v < 0 {
+		return int((v - int64(quantity)/2) / int64(quantity))
+	}
+	return int((v + int64(quantity)/2) / int64(quantity))
+}
+
+// Times multiplies q by an amount per unit, such as a price or cost,
+// rounding half away from zero.
+func (q Quantity) Times(perUnit int) int {
+	v := int64(q) * int64(perUnit)
+	if v < 0 {
+		return int((v - QuantityScale/2) / QuantityScale)
+	}
+	return int((v + QuantityScale/2) / QuantityScale)
+}
+
+func (q Quantity) MarshalJSON() ([]byte, error) {
+	return []byte(q.String()), nil
+}
+
+// UnmarshalJSON accepts a JSON number or a string holding one.
+func (q *Quantity) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		s = string(data)
+	}
+	parsed, err := ParseQuantity(s)
+	if err != nil {
+		return err
+	}
+	*q = parsed
+	return nil
+}
+
+// Scan reads a NUMERIC column.
+func (q *Quantity) Scan(src any) error {
+	switch v := src.(type) {
+	case []byte:
+		return q.Scan(string(v))
+	case string:
+		parsed, err := ParseQuantity(v)
+		if err != nil {
+			return err
+		}
+		*q = parsed
+	case int64:
+		*q = Units(int(v))
+	case nil:
+		*q = 0
+	default:
+		return fmt.Errorf("cannot scan %T into Quantity", src)
+	}
+	return nil
+}
+
+// Value writes q as a decimal string, which PostgreSQL casts to NUMERIC
+// without loss.
+func (q Quantity) Value() (driver.Value, error) {
+	return q.String(), nil
+}
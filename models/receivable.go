@@ -0,0 +1,117 @@
+package models
+
+import "time"
+
+const (
+	StatementInvoice   = "invoice"
+	StatementRefund    = "refund"
+	StatementRepayment = "repayment"
+)
+
+// Invoice is a sale, or the part of one, a customer bought on account.
+// Outstanding is Amount less what refunds credited back and what repayments
+// settled.
+type Invoice struct {
+	PaymentID       int       `json:"-"`
+	TransactionID   int       `json:"transaction_id"`
+	Amount          int       `json:"amount"`
+	Refunded        int       `json:"refunded"`
+	Repaid          int       `json:"repaid"`
+	Outstanding     int       `json:"outstanding"`
+	DaysOutstanding int       `json:"days_outstanding"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// Aging buckets what a customer owes by the age of the invoice it is owed
+// on, in days since the sale.
+type Aging struct {
+	Current    int `json:"current"`
+	Days31To60 int `json:"days_31_60"`
+	Days61To90 int `json:"days_61_90"`
+	Over90     int `json:"over_90"`
+	Total      int `json:"total"`
+}
+
+// Add puts what is outstanding on an invoice into its bucket.
+func (a *Aging) Add(invoice Invoice) {
+	switch {
+	case invoice.DaysOutstanding <= 30:
+		a.Current += invoice.Outstanding
+	case invoice.DaysOutstanding <= 60:
+		a.Days31To60 += invoice.Outstanding
+	case invoice.DaysOutstanding <= 90:
+		a.Days61To90 += invoice.Outstanding
+	default:
+		a.Over90 += invoice.Outstanding
+	}
+	a.Total += invoice.Outstanding
+}
+
+// Repayment is money a customer paid towards their account. Without
+// Allocations it settles their oldest invoices first.
+type Repayment struct {
+	ID          int                   `json:"id"`
+	CustomerID  int                   `json:"customer_id"`
+	Method      string                `json:"method"`
+	Amount      int                   `json:"amount"`
+	Note        string                `json:"note"`
+	Allocations []RepaymentAllocation `json:"allocations"`
+	CreatedBy   string                `json:"created_by"`
+	CreatedAt   time.Time             `json:"created_at"`
+}
+
+// RepaymentAllocation is the part of a repayment that settles the invoice
+// of a sale.
+type RepaymentAllocation struct {
+	TransactionID int `json:"transaction_id"`
+	Amount        int `json:"amount"`
+}
+
+// StatementEntry is one line of a customer's statement. Invoices are
+// debits; refunds to the account and repayments are credits. Balance is
+// what the customer owes after the entry.
+type StatementEntry struct {
+	Kind          string    `json:"kind"`
+	TransactionID *int      `json:"transaction_id,omitempty"`
+	RefundID      *int      `json:"refund_id,omitempty"`
+	RepaymentID   *int      `json:"repayment_id,omitempty"`
+	Debit         int       `json:"debit"`
+	Credit        int       `json:"credit"`
+	Balance       int       `json:"balance"`
+	Note          string    `json:"note,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// Statement is a customer's account over a period, with the invoices still
+// open at the time it is drawn up.
+type Statement struct {
+	Customer        Customer         `json:"customer"`
+	OpeningBalance  int              `json:"opening_balance"`
+	Entries         []StatementEntry `json:"entries"`
+	ClosingBalance  int              `json:"closing_balance"`
+	AvailableCredit int              `json:"available_credit"`
+	OpenInvoices    []Invoice        `json:"open_invoices"`
+	Aging           Aging            `json:"aging"`
+}
+
+type StatementFilter struct {
+	From *time.Time
+	To   *time.Time
+}
+
+// Receivable is what one customer owes, for the aging report.
+type Receivable struct {
+	CustomerID  int    `json:"customer_id"`
+	Name        string `json:"name"`
+	Phone       string `json:"phone"`
+	CreditLimit int    `json:"credit_limit"`
+	Invoices    int    `json:"invoices"`
+	Aging       Aging  `json:"aging"`
+}
+
+// ReceivablesReport is every customer who owes something, most owed first,
+// with the totals over all of them.
+type ReceivablesReport struct {
+	Customers []Receivable `json:"customers"`
+	Total     Aging        `json:"total"`
+}
@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// RecipeItem is one component of a composite product and the quantity of it
+// used per unit sold.
+type RecipeItem struct {
+	ComponentID   int      `json:"component_id"`
+	ComponentName string   `json:"component_name,omitempty"`
+	Quantity      Quantity `json:"quantity"`
+}
+
+type RecipeRequest struct {
+	Items []RecipeItem `json:"items"`
+}
+
+// IngredientUsage is a component used by a sale of a composite product.
+type IngredientUsage struct {
+	ProductID  int      `json:"product_id"`
+	Quantity   Quantity `json:"quantity"`
+	CostAmount int      `json:"cost_amount"`
+}
+
+type IngredientUsageFilter struct {
+	OutletID int
+	From     *time.Time
+	To       *time.Time
+}
+
+// IngredientUsageRow totals one component's usage over a period.
+type IngredientUsageRow struct {
+	ProductID  int      `json:"product_id"`
+	Name       string   `json:"name"`
+	Quantity   Quantity `json:"quantity"`
+	CostAmount int      `json:"cost_amount"`
+}
+
+type IngredientUsageReport struct {
+	TotalCost int                  `json:"total_cost"`
+	Rows      []IngredientUsageRow `json:"rows"`
+}
@@ -0,0 +1,80 @@
+package models
+
+import "time"
+
+const (
+	StockReasonSale       = "sale"
+	StockReasonRefund     = "refund"
+	StockReasonReceipt    = "receipt"
+	StockReasonAdjustment = "adjustment"
+	StockReasonTransfer   = "transfer"
+	StockReasonWaste      = "waste"
+
+	StockReferenceTransaction = "transaction"
+	StockReferenceRefund      = "refund"
+	StockReferenceProduct     = "product"
+)
+
+type StockMovement struct {
+	ID            int       `json:"id"`
+	OutletID      int       `json:"outlet_id"`
+	ProductID     int       `json:"product_id"`
+	Quantity      Quantity  `json:"quantity"`
+	Reason        string    `json:"reason"`
+	ReferenceType string    `json:"reference_type,omitempty"`
+	ReferenceID   int       `json:"reference_id,omitempty"`
+	Balance       Quantity  `json:"balance"`
+	UnitCost      int       `json:"unit_cost"`
+	CostAmount    int       `json:"cost_amount"`
+	Note          string    `json:"note,omitempty"`
+	Actor         string    `json:"actor"`
+	CreatedAt     time.Time `json:"created_at"`
+
+	// Lots are the lots of a lot-tracked product the movement went into or
+	// came out of.
+	Lots []LotAllocation `json:"lots,omitempty"`
+
+	// MinStock is the product's low-stock threshold when the movement was
+	// applied.
+	MinStock Quantity `json:"-"`
+}
+
+// CrossedMinStock reports whether this movement took the balance from above
+// the product's low-stock threshold to at or below it.
+func (m *StockMovement) CrossedMinStock() bool {
+	before := m.Balance - m.Quantity
+	return m.MinStock > 0 && before > m.MinStock && m.Balance <= m.MinStock
+}
+
+type StockCard struct {
+	OutletID       int             `json:"outlet_id"`
+	ProductID      int             `json:"product_id"`
+	ProductName    string          `json:"product_name"`
+	OpeningBalance Quantity        `json:"opening_balance"`
+	TotalIn        Quantity        `json:"total_in"`
+	TotalOut       Quantity        `json:"total_out"`
+	ClosingBalance Quantity        `json:"closing_balance"`
+	Movements      []StockMovement `json:"movements"`
+}
+
+// StockAdjustmentRequest changes stock either by a relative Delta or to an
+// absolute CountedQuantity; exactly one of the two must be set. LotID moves
+// a lot-tracked product's stock in and out of one lot, such as writing off
+// an expired lot, instead of first-expiry-first-out.
+type StockAdjustmentRequest struct {
+	Delta           *Quantity `json:"delta"`
+	CountedQuantity *Quantity `json:"counted_quantity"`
+	LotID           *int      `json:"lot_id"`
+	Reason          string    `json:"reason"`
+	Note            string    `json:"note"`
+}
+
+// IsAdjustmentReason reports whether reason may be used for a manual stock
+// adjustment. Sales, refunds, receipts and transfers have their own flows.
+func IsAdjustmentReason(reason string) bool {
+	switch reason {
+	case StockReasonAdjustment, StockReasonWaste:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,98 @@
+package models
+
+import "time"
+
+const (
+	StockTransferStatusRequested = "requested"
+	StockTransferStatusInTransit = "in_transit"
+	StockTransferStatusReceived  = "received"
+	StockTransferStatusCancelled = "cancelled"
+
+	StockReferenceStockTransfer = "stock_transfer"
+)
+
+type StockTransfer struct {
+	ID                  int                 `json:"id"`
+	SourceOutletID      int                 `json:"source_outlet_id"`
+	DestinationOutletID int                 `json:"destination_outlet_id"`
+	Status              string              `json:"status"`
+	Note                string              `json:"note"`
+	RequestedBy         string              `json:"requested_by"`
+	CreatedAt           time.Time           `json:"created_at"`
+	DispatchedBy        string              `json:"dispatched_by,omitempty"`
+	DispatchedAt        *time.Time          `json:"dispatched_at,omitempty"`
+	ReceivedBy          string              `json:"received_by,omitempty"`
+	ReceivedAt          *time.Time          `json:"received_at,omitempty"`
+	CancelledAt         *time.Time          `json:"cancelled_at,omitempty"`
+	Lines               []StockTransferLine `json:"lines,omitempty"`
+}
+
+// StockTransferLine is one product of a transfer. Discrepancy is the received
+// quantity less the dispatched quantity, so a shortage is negative.
+type StockTransferLine struct {
+	ID                 int      `json:"id"`
+	StockTransferID    int      `json:"stock_transfer_id"`
+	ProductID          int      `json:"product_id"`
+	ProductName        string   `json:"product_name,omitempty"`
+	Quantity           Quantity `json:"quantity"`
+	DispatchedQuantity Quantity `json:"dispatched_quantity"`
+	ReceivedQuantity   Quantity `json:"received_quantity"`
+	Discrepancy        Quantity `json:"discrepancy"`
+	DiscrepancyNote    string   `json:"discrepancy_note,omitempty"`
+	UnitCost           int      `json:"unit_cost"`
+}
+
+type StockTransferLineRequest struct {
+	ProductID int      `json:"product_id"`
+	Quantity  Quantity `json:"quantity"`
+}
+
+// StockTransferRequest asks the source outlet for stock. The destination
+// defaults to the outlet of the calling terminal.
+type StockTransferRequest struct {
+	SourceOutletID      int                        `json:"source_outlet_id"`
+	DestinationOutletID int                        `json:"destination_outlet_id"`
+	Note                string                     `json:"note"`
+	Lines               []StockTransferLineRequest `json:"lines"`
+}
+
+type StockTransferFilter struct {
+	OutletID int
+	Status   string
+}
+
+type StockTransferQuantityRequest struct {
+	StockTransferLineID int      `json:"stock_transfer_line_id"`
+	Quantity            Quantity `json:"quantity"`
+	Note                string   `json:"note"`
+}
+
+// StockTransferDispatchRequest sends a transfer. Lines not listed are sent in
+// full; a quantity of 0 leaves a line behind.
+type StockTransferDispatchRequest struct {
+	Lines []StockTransferQuantityRequest `json:"lines"`
+}
+
+// StockTransferReceiveRequest receives a transfer. Lines not listed are taken
+// as received in full; a different quantity is recorded as a discrepancy.
+type StockTransferReceiveRequest struct {
+	Lines []StockTransferQuantityRequest `json:"lines"`
+}
+
+type InventoryValuationRow struct {
+	OutletID          int      `json:"outlet_id"`
+	OutletName        string   `json:"outlet_name"`
+	OnHandQuantity    Quantity `json:"on_hand_quantity"`
+	OnHandValue       int      `json:"on_hand_value"`
+	InTransitQuantity Quantity `json:"in_transit_quantity"`
+	InTransitValue    int      `json:"in_transit_value"`
+	TotalValue        int      `json:"total_value"`
+}
+
+// InventoryValuation values stock on hand at current cost and stock in
+// transit at the cost it was dispatched at. Goods in transit are counted
+// under their destination outlet only.
+type InventoryValuation struct {
+	Total   InventoryValuationRow   `json:"total"`
+	Outlets []InventoryValuationRow `json:"outlets"`
+}
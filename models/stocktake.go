@@ -0,0 +1,72 @@
+package models
+
+import "time"
+
+const (
+	StocktakeStatusOpen      = "open"
+	StocktakeStatusFinalized = "finalized"
+	StocktakeStatusCancelled = "cancelled"
+
+	StockReferenceStocktake = "stocktake"
+)
+
+type Stocktake struct {
+	ID          int             `json:"id"`
+	OutletID    int             `json:"outlet_id"`
+	Status      string          `json:"status"`
+	Note        string          `json:"note"`
+	CreatedBy   string          `json:"created_by"`
+	CreatedAt   time.Time       `json:"created_at"`
+	FinalizedBy string          `json:"finalized_by,omitempty"`
+	FinalizedAt *time.Time      `json:"finalized_at,omitempty"`
+	Items       []StocktakeItem `json:"items,omitempty"`
+}
+
+// StocktakeItem is one product line of a session. Counted, system and
+// variance figures are nil until the product has been counted.
+type StocktakeItem struct {
+	ProductID        int       `json:"product_id"`
+	ProductName      string    `json:"product_name"`
+	SnapshotQuantity Quantity  `json:"snapshot_quantity"`
+	MovementSince    *Quantity `json:"movement_since_snapshot"`
+	SystemQuantity   *Quantity `json:"system_quantity"`
+	CountedQuantity  *Quantity `json:"counted_quantity"`
+	CountEntries     int       `json:"count_entries"`
+	Variance         *Quantity `json:"variance"`
+	UnitValue        int       `json:"unit_value"`
+	VarianceValue    int       `json:"variance_value"`
+}
+
+type StocktakeCount struct {
+	ID             int       `json:"id"`
+	StocktakeID    int       `json:"stocktake_id"`
+	ProductID      int       `json:"product_id"`
+	Quantity       Quantity  `json:"quantity"`
+	Location       string    `json:"location,omitempty"`
+	CountedBy      string    `json:"counted_by"`
+	SystemQuantity Quantity  `json:"system_quantity"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+type StocktakeCountLine struct {
+	ProductID int      `json:"product_id"`
+	Quantity  Quantity `json:"quantity"`
+	Location  string   `json:"location"`
+}
+
+type StocktakeCountRequest struct {
+	Counts []StocktakeCountLine `json:"counts"`
+}
+
+type StocktakeVarianceReport struct {
+	StocktakeID    int             `json:"stocktake_id"`
+	Status         string          `json:"status"`
+	CountedItems   int             `json:"counted_items"`
+	UncountedItems int             `json:"uncounted_items"`
+	ShortageUnits  Quantity        `json:"shortage_units"`
+	SurplusUnits   Quantity        `json:"surplus_units"`
+	ShortageValue  int             `json:"shortage_value"`
+	SurplusValue   int             `json:"surplus_value"`
+	NetValue       int             `json:"net_value"`
+	Items          []StocktakeItem `json:"items"`
+}
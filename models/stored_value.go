@@ -0,0 +1,82 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	StoredValueGiftCard    = "gift_card"
+	StoredValueStoreCredit = "store_credit"
+
+	StoredValueStatusActive = "active"
+	StoredValueStatusVoid   = "void"
+
+	StoredValueReasonIssue  = "issue"
+	StoredValueReasonRedeem = "redeem"
+	StoredValueReasonRefund = "refund"
+	StoredValueReasonVoid   = "void"
+	StoredValueReasonAdjust = "adjust"
+)
+
+// StoredValueAccount holds money a customer can pay with: a gift card,
+// found by its Code, or a customer's store credit. IssuedAmount is what a
+// gift card was loaded with when sold.
+type StoredValueAccount struct {
+	ID            int                `json:"id"`
+	Kind          string             `json:"kind"`
+	Code          string             `json:"code,omitempty"`
+	CustomerID    *int               `json:"customer_id,omitempty"`
+	IssuedAmount  int                `json:"issued_amount"`
+	Balance       int                `json:"balance"`
+	Status        string             `json:"status"`
+	TransactionID *int               `json:"transaction_id,omitempty"`
+	CreatedAt     time.Time          `json:"created_at"`
+	Ledger        []StoredValueEntry `json:"ledger,omitempty"`
+}
+
+// StoredValueEntry is one change to an account's balance. Amount is
+// positive for money loaded or paid back onto it and negative for money
+// spent or written off; Balance is the account's balance after it.
+type StoredValueEntry struct {
+	ID            int       `json:"id"`
+	AccountID     int       `json:"account_id"`
+	Amount        int       `json:"amount"`
+	Balance       int       `json:"balance"`
+	Reason        string    `json:"reason"`
+	TransactionID *int      `json:"transaction_id,omitempty"`
+	RefundID      *int      `json:"refund_id,omitempty"`
+	Note          string    `json:"note,omitempty"`
+	CreatedBy     string    `json:"created_by,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// StoredValueAdjustment adds money to an account, or takes it off when
+// Amount is negative.
+type StoredValueAdjustment struct {
+	Amount int    `json:"amount"`
+	Note   string `json:"note"`
+}
+
+// NormalizeGiftCardCode reads a gift card code as printed or typed: letters
+// are upper-cased and spaces and dashes dropped, so "abcd-efgh 2345" is
+// "ABCDEFGH2345".
+func NormalizeGiftCardCode(s string) (string, error) {
+	code := strings.Map(func(r rune) rune {
+		switch {
+		case r == ' ' || r == '-':
+			return -1
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z' || r >= '0' && r <= '9':
+			return r
+		default:
+			return '?'
+		}
+	}, s)
+	if len(code) < 6 || len(code) > 32 || strings.ContainsRune(code, '?') {
+		return "", fmt.Errorf("invalid gift card code %q", s)
+	}
+	return code, nil
+}
@@ -1,39 +1,195 @@
 package models
 
-import "time"
+import (
+	"math"
+	"time"
+)
 
+// Transaction is a sale. TotalAmount is what the customer owes, after
+// DiscountAmount taken off by redeeming points; Payments settle it, and
+// Change is the cash handed back on top.
 type Transaction struct {
-	ID          int                 `json:"id"`
-	TotalAmount int                 `json:"total_amount"`
-	CreatedAt   time.Time           `json:"created_at"`
-	Details     []TransactionDetail `json:"details"`
+	ID             int                 `json:"id"`
+	OutletID       int                 `json:"outlet_id"`
+	OutletName     string              `json:"outlet_name,omitempty"`
+	CustomerID     *int                `json:"customer_id"`
+	TotalAmount    int                 `json:"total_amount"`
+	DiscountAmount int                 `json:"discount_amount"`
+	Change         int                 `json:"change"`
+	PointsRedeemed int                 `json:"points_redeemed"`
+	PointsEarned   int                 `json:"points_earned"`
+	CreatedAt      time.Time           `json:"created_at"`
+	Details        []TransactionDetail `json:"details"`
+	Bundles        []TransactionBundle `json:"bundles,omitempty"`
+	Payments       []Payment           `json:"payments"`
 }
 
 type TransactionDetail struct {
-	ID            int    `json:"id"`
-	TransactionID int    `json:"transaction_id"`
-	ProductID     int    `json:"product_id"`
-	ProductName   string `json:"product_name,omitempty"`
-	Quantity      int    `json:"quantity"`
-	Subtotal      int    `json:"subtotal"`
+	ID            int      `json:"id"`
+	TransactionID int      `json:"transaction_id"`
+	ProductID     int      `json:"product_id"`
+	ProductName   string   `json:"product_name,omitempty"`
+	Quantity      Quantity `json:"quantity"`
+	Subtotal      int      `json:"subtotal"`
+	CostAmount    int      `json:"cost_amount"`
+
+	// DiscountAmount is the line's share of the points discount, already
+	// taken off Subtotal. PointsEarned is its share of the points the sale
+	// earned.
+	DiscountAmount int `json:"discount_amount"`
+	PointsEarned   int `json:"points_earned"`
+
+	// PriceListID is the price list the line was priced from, nil for the
+	// product's regular price.
+	PriceListID   *int   `json:"price_list_id"`
+	PriceListName string `json:"price_list_name,omitempty"`
+
+	// TransactionBundleID is set on the components of a bundle sold, whose
+	// Subtotal is their share of the package price.
+	TransactionBundleID *int `json:"transaction_bundle_id,omitempty"`
+
+	// OrderSettlementID is the settlement that pays for the line when the
+	// order it was sold on had its bill split by items.
+	OrderSettlementID *int `json:"order_settlement_id,omitempty"`
+
+	// Modifiers are the options chosen with the item. Their prices are
+	// included in Subtotal and the ingredients they used in CostAmount.
+	Modifiers []TransactionDetailModifier `json:"modifiers,omitempty"`
+
+	// Ingredients are the components a composite product used up. Their cost
+	// is included in CostAmount.
+	Ingredients []IngredientUsage `json:"ingredients,omitempty"`
+
+	// Lots are the lots a lot-tracked product was sold from.
+	Lots []LotAllocation `json:"lots,omitempty"`
+
+	// GiftCards are the cards a gift card line loaded, one per unit sold.
+	GiftCards []StoredValueAccount `json:"gift_cards,omitempty"`
 }
 
+// CheckoutItem sells either a product, with its modifiers, or a bundle.
+// Quantity is in Unit, one of the product's units, or in its base unit when
+// Unit is empty. A product may be given by a scanned Barcode instead of its
+// ID; the quantity then defaults to one, and scale labels set it from the
+// weight or price they carry. A gift card loads GiftCardAmount, or the
+// product's price, onto a card with GiftCardCode when it is pre-printed.
 type CheckoutItem struct {
-	ProductID int `json:"product_id"`
-	Quantity  int `json:"quantity"`
+	ProductID      int      `json:"product_id"`
+	Barcode        string   `json:"barcode"`
+	BundleID       int      `json:"bundle_id"`
+	Quantity       Quantity `json:"quantity"`
+	Unit           string   `json:"unit"`
+	ModifierIDs    []int    `json:"modifier_ids"`
+	GiftCardCode   string   `json:"gift_card_code"`
+	GiftCardAmount int      `json:"gift_card_amount"`
 }
 
+// CheckoutRequest sells Items, to CustomerID when given, at the prices of
+// the customer's price list. RedeemPoints takes the customer's points off
+// the total as a discount; a points payment spends them as a tender
+// instead. Without Payments the sale is paid in cash.
 type CheckoutRequest struct {
-	Items []CheckoutItem `json:"items"`
+	CustomerID   *int           `json:"customer_id"`
+	Items        []CheckoutItem `json:"items"`
+	RedeemPoints int            `json:"redeem_points"`
+	Payments     []Payment      `json:"payments"`
 }
 
 type TopSellProduct struct {
-	Name         string `json:"nama"`
-	QuantitySell int    `json:"qty_terjual"`
+	Name         string   `json:"nama"`
+	QuantitySell Quantity `json:"qty_terjual"`
 }
 
 type Report struct {
 	TotalRevenue     int            `json:"total_revenue"`
 	TotalTransaction int            `json:"total_transaksi"`
+	TotalCost        int            `json:"total_cost"`
+	GrossProfit      int            `json:"gross_profit"`
+	MarginPercent    float64        `json:"margin_percent"`
 	TopSellProduct   TopSellProduct `json:"produk_terlaris"`
 }
+
+const (
+	GrossProfitByProduct  = "product"
+	GrossProfitByParent   = "parent"
+	GrossProfitByCategory = "category"
+	GrossProfitByDay      = "day"
+	GrossProfitByMonth    = "month"
+)
+
+type GrossProfitFilter struct {
+	GroupBy  string
+	OutletID int
+	From     *time.Time
+	To       *time.Time
+}
+
+// GrossProfitRow is one group of a gross profit report. Refunds are netted
+// out of quantity, revenue and cost.
+type GrossProfitRow struct {
+	ID            int      `json:"id,omitempty"`
+	Name          string   `json:"name"`
+	Quantity      Quantity `json:"quantity"`
+	Revenue       int      `json:"revenue"`
+	Cost          int      `json:"cost"`
+	GrossProfit   int      `json:"gross_profit"`
+	MarginPercent float64  `json:"margin_percent"`
+}
+
+type GrossProfitReport struct {
+	GroupBy string           `json:"group_by"`
+	Total   GrossProfitRow   `json:"total"`
+	Rows    []GrossProfitRow `json:"rows"`
+}
+
+// MarginPercent returns gross profit as a percentage of revenue, rounded to
+// two decimals.
+func MarginPercent(grossProfit, revenue int) float64 {
+	if revenue == 0 {
+		return 0
+	}
+	return math.Round(float64(grossProfit)*10000/float64(revenue)) / 100
+}
+
+type RefundItemRequest struct {
+	TransactionDetailID int      `json:"transaction_detail_id"`
+	Quantity            Quantity `json:"quantity"`
+}
+
+// RefundRequest refunds the listed detail lines; an empty Items list refunds
+// everything not refunded yet. With StoreCredit, what was paid in cash,
+// card, QRIS or transfer is refunded as store credit for the sale's
+// customer, or for CustomerID when the sale had none.
+type RefundRequest struct {
+	Items       []RefundItemRequest `json:"items"`
+	Reason      string              `json:"reason"`
+	StoreCredit bool                `json:"store_credit"`
+	CustomerID  *int                `json:"customer_id"`
+}
+
+// Refund returns part of a sale. TotalAmount is paid back through Payments,
+// split across the sale's payments. PointsReversed are earned points taken
+// back, fewer than the items earned when the customer has spent them
+// already; PointsRestored are points redeemed as a discount given back.
+type Refund struct {
+	ID             int          `json:"id"`
+	TransactionID  int          `json:"transaction_id"`
+	OutletID       int          `json:"outlet_id"`
+	TotalAmount    int          `json:"total_amount"`
+	PointsReversed int          `json:"points_reversed"`
+	PointsRestored int          `json:"points_restored"`
+	Reason         string       `json:"reason,omitempty"`
+	CreatedAt      time.Time    `json:"created_at"`
+	Items          []RefundItem `json:"items"`
+	Payments       []Payment    `json:"payments"`
+}
+
+type RefundItem struct {
+	ID                  int      `json:"id"`
+	TransactionDetailID int      `json:"transaction_detail_id"`
+	ProductID           int      `json:"product_id"`
+	Quantity            Quantity `json:"quantity"`
+	Amount              int      `json:"amount"`
+	CostAmount          int      `json:"cost_amount"`
+	PointsReversed      int      `json:"points_reversed"`
+}
@@ -0,0 +1,17 @@
+package notifiers
+
+import (
+	"cashier-api/models"
+	"log"
+)
+
+type LogNotifier struct{}
+
+func NewLogNotifier() *LogNotifier {
+	return &LogNotifier{}
+}
+
+func (n *LogNotifier) NotifyLowStock(event models.LowStockEvent) error {
+	log.Printf("Low stock: %s (id %d) at %s, minimum %s, reorder %s", event.ProductName, event.ProductID, event.Stock, event.MinStock, event.ReorderQty)
+	return nil
+}
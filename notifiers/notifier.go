@@ -0,0 +1,33 @@
+package notifiers
+
+import (
+	"cashier-api/models"
+	"log"
+)
+
+// Notifier delivers low-stock events to an outside party.
+type Notifier interface {
+	NotifyLowStock(event models.LowStockEvent) error
+}
+
+// Dispatcher fans an event out to every registered notifier. Delivery runs in
+// the background so a slow endpoint never holds up a checkout; failures are
+// logged.
+type Dispatcher struct {
+	notifiers []Notifier
+}
+
+func NewDispatcher(notifiers ...Notifier) *Dispatcher {
+	return &Dispatcher{notifiers: notifiers}
+}
+
+func (d *Dispatcher) NotifyLowStock(event models.LowStockEvent) error {
+	for _, n := range d.notifiers {
+		go func(n Notifier) {
+			if err := n.NotifyLowStock(event); err != nil {
+				log.Printf("low stock notification for product %d failed: %v", event.ProductID, err)
+			}
+		}(n)
+	}
+	return nil
+}
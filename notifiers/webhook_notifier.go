@@ -0,0 +1,46 @@
+package notifiers
+
+import (
+	"bytes"
+	"cashier-api/models"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// WebhookNotifier POSTs each event as JSON to a configured URL.
+type WebhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	return &WebhookNotifier{
+		url:    url,
+		client: &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+type webhookPayload struct {
+	Event string               `json:"event"`
+	Data  models.LowStockEvent `json:"data"`
+}
+
+func (n *WebhookNotifier) NotifyLowStock(event models.LowStockEvent) error {
+	body, err := json.Marshal(webhookPayload{Event: "low_stock", Data: event})
+	if err != nil {
+		return err
+	}
+
+	resp, err := n.client.Post(n.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with %s", resp.Status)
+	}
+	return nil
+}
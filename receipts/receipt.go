@@ -0,0 +1,193 @@
+package receipts
+
+import (
+	"cashier-api/models"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Width is the number of characters on a receipt line, which suits 58mm
+// thermal printers.
+const Width = 32
+
+// Text renders a sale as a plain-text receipt. Each line shows the quantity,
+// product and subtotal, followed by its modifiers and their unit prices. A
+// bundle shows its package price once, with its components listed beneath.
+// Gift cards sold are listed by code. The total is followed by how the sale
+// was paid, gift cards by the last digits of their code, the change given
+// and the loyalty points used and earned.
+func Text(transaction *models.Transaction) string {
+	var b strings.Builder
+	rule := strings.Repeat("-", Width) + "\n"
+
+	if transaction.OutletName != "" {
+		b.WriteString(center(transaction.OutletName))
+	}
+	b.WriteString(center("Transaction #" + strconv.Itoa(transaction.ID)))
+	b.WriteString(center(transaction.CreatedAt.Format("2006-01-02 15:04")))
+	b.WriteString(rule)
+	writeDetails(&b, transaction)
+	b.WriteString(rule)
+	if transaction.DiscountAmount != 0 {
+		b.WriteString(columns("POINTS DISCOUNT", strconv.Itoa(-transaction.DiscountAmount)))
+	}
+	b.WriteString(columns("TOTAL", strconv.Itoa(transaction.TotalAmount)))
+	writePayments(&b, transaction.Payments, transaction.Change)
+	if transaction.PointsRedeemed != 0 || transaction.PointsEarned != 0 {
+		b.WriteString(rule)
+		if transaction.PointsRedeemed != 0 {
+			b.WriteString(columns("Points redeemed", strconv.Itoa(transaction.PointsRedeemed)))
+		}
+		if transaction.PointsEarned != 0 {
+			b.WriteString(columns("Points earned", strconv.Itoa(transaction.PointsEarned)))
+		}
+	}
+
+	return b.String()
+}
+
+// Settlement renders one share of a split bill as a plain-text receipt. A
+// share of a bill split by items lists only its own items; any other share
+// lists the whole order, its total and the share of it paid. An unpaid
+// share shows what is due, a paid one how it was paid and the change given.
+func Settlement(order *models.Order, settlement *models.OrderSettlement, transaction *models.Transaction) string {
+	var b strings.Builder
+	rule := strings.Repeat("-", Width) + "\n"
+
+	share := 0
+	for i := range order.Settlements {
+		if order.Settlements[i].ID == settlement.ID {
+			share = i + 1
+		}
+	}
+	printedAt := transaction.CreatedAt
+	if settlement.PaidAt != nil {
+		printedAt = *settlement.PaidAt
+	}
+
+	if transaction.OutletName != "" {
+		b.WriteString(center(transaction.OutletName))
+	}
+	title := "Order #" + strconv.Itoa(order.ID)
+	if order.TableName != "" {
+		title += " - " + order.TableName
+	}
+	b.WriteString(center(title))
+	b.WriteString(center(fmt.Sprintf("Bill %d of %d", share, len(order.Settlements))))
+	b.WriteString(center(printedAt.Format("2006-01-02 15:04")))
+	b.WriteString(rule)
+
+	sale := *transaction
+	if order.SplitMode == models.SplitItems {
+		sale.Details = make([]models.TransactionDetail, 0)
+		for _, detail := range transaction.Details {
+			if detail.OrderSettlementID != nil && *detail.OrderSettlementID == settlement.ID {
+				sale.Details = append(sale.Details, detail)
+			}
+		}
+	}
+	writeDetails(&b, &sale)
+	b.WriteString(rule)
+
+	if order.SplitMode == models.SplitItems {
+		b.WriteString(columns("TOTAL", strconv.Itoa(settlement.Amount)))
+	} else {
+		b.WriteString(columns("ORDER TOTAL", strconv.Itoa(order.TotalAmount)))
+		b.WriteString(columns(fmt.Sprintf("SHARE %d/%d", share, len(order.Settlements)), strconv.Itoa(settlement.Amount)))
+	}
+	if settlement.Status == models.SettlementUnpaid {
+		b.WriteString(columns("DUE", strconv.Itoa(settlement.Amount)))
+	} else {
+		writePayments(&b, settlement.Payments, settlement.Change)
+	}
+
+	return b.String()
+}
+
+// writeDetails writes the lines of a sale.
+func writeDetails(b *strings.Builder, transaction *models.Transaction) {
+	printed := make(map[int]bool)
+	for _, detail := range transaction.Details {
+		if detail.TransactionBundleID != nil {
+			id := *detail.TransactionBundleID
+			if !printed[id] {
+				printed[id] = true
+				writeBundle(b, transaction, id)
+			}
+			continue
+		}
+
+		b.WriteString(columns(fmt.Sprintf("%sx %s", detail.Quantity, detail.ProductName), strconv.Itoa(detail.Subtotal)))
+		for _, modifier := range detail.Modifiers {
+			label := "  + " + modifier.Name
+			if modifier.Price != 0 {
+				label += fmt.Sprintf(" (%+d)", modifier.Price)
+			}
+			b.WriteString(columns(label, ""))
+		}
+		for _, card := range detail.GiftCards {
+			b.WriteString(columns("  Card "+card.Code, ""))
+		}
+	}
+}
+
+// writePayments writes how a sale was paid and the change given.
+func writePayments(b *strings.Builder, payments []models.Payment, change int) {
+	// Cash is shown as tendered: the change came off the last cash payment.
+	lastCash := -1
+	for i, payment := range payments {
+		if payment.Method == models.PaymentCash {
+			lastCash = i
+		}
+	}
+	for i, payment := range payments {
+		label, amount := strings.ToUpper(strings.ReplaceAll(payment.Method, "_", " ")), payment.Amount
+		if payment.Points != 0 {
+			label += fmt.Sprintf(" (%d pts)", payment.Points)
+		}
+		if len(payment.Code) > 4 {
+			label += " *" + payment.Code[len(payment.Code)-4:]
+		}
+		if i == lastCash {
+			amount += change
+		}
+		b.WriteString(columns(label, strconv.Itoa(amount)))
+	}
+	if change != 0 {
+		b.WriteString(columns("CHANGE", strconv.Itoa(change)))
+	}
+}
+
+func writeBundle(b *strings.Builder, transaction *models.Transaction, id int) {
+	for _, bundle := range transaction.Bundles {
+		if bundle.ID == id {
+			b.WriteString(columns(fmt.Sprintf("%sx %s", bundle.Quantity, bundle.Name), strconv.Itoa(bundle.Subtotal)))
+		}
+	}
+	for _, detail := range transaction.Details {
+		if detail.TransactionBundleID != nil && *detail.TransactionBundleID == id {
+			b.WriteString(columns(fmt.Sprintf("  - %sx %s", detail.Quantity, detail.ProductName), ""))
+		}
+	}
+}
+
+func center(text string) string {
+	if pad := (Width - len(text)) / 2; pad > 0 {
+		text = strings.Repeat(" ", pad) + text
+	}
+	return text + "\n"
+}
+
+// columns puts left and right on one line, wrapping left onto a line of its
+// own when both do not fit.
+func columns(left, right string) string {
+	if right == "" {
+		return left + "\n"
+	}
+	gap := Width - len(left) - len(right)
+	if gap < 1 {
+		return left + "\n" + strings.Repeat(" ", max(Width-len(right), 0)) + right + "\n"
+	}
+	return left + strings.Repeat(" ", gap) + right + "\n"
+}
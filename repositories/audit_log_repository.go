@@ -0,0 +1,130 @@
+package repositories
+
+import (
+	"cashier-api/models"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+type AuditLogRepository struct {
+	db *sql.DB
+}
+
+func NewAuditLogRepository(db *sql.DB) *AuditLogRepository {
+	return &AuditLogRepository{db: db}
+}
+
+func (repo *AuditLogRepository) GetAll(filter models.AuditLogFilter) ([]models.AuditLog, error) {
+	query := "SELECT id, actor, action, entity, entity_id, before_data, after_data, changes, request_id, created_at FROM audit_logs WHERE 1 = 1"
+
+	args := []interface{}{}
+	if filter.Entity != "" {
+		args = append(args, filter.Entity)
+		query += fmt.Sprintf(" AND entity = $%d", len(args))
+	}
+	if filter.EntityID != 0 {
+		args = append(args, filter.EntityID)
+		query += fmt.Sprintf(" AND entity_id = $%d", len(args))
+	}
+	if filter.Actor != "" {
+		args = append(args, filter.Actor)
+		query += fmt.Sprintf(" AND actor = $%d", len(args))
+	}
+	if filter.Action != "" {
+		args = append(args, filter.Action)
+		query += fmt.Sprintf(" AND action = $%d", len(args))
+	}
+	if filter.From != nil {
+		args = append(args, *filter.From)
+		query += fmt.Sprintf(" AND created_at >= $%d", len(args))
+	}
+	if filter.To != nil {
+		args = append(args, *filter.To)
+		query += fmt.Sprintf(" AND created_at < $%d", len(args))
+	}
+
+	limit := filter.Limit
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+	args = append(args, limit)
+	query += fmt.Sprintf(" ORDER BY id DESC LIMIT $%d", len(args))
+
+	rows, err := repo.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	logs := make([]models.AuditLog, 0)
+	for rows.Next() {
+		var log models.AuditLog
+		var before, after, changes []byte
+		err := rows.Scan(&log.ID, &log.Actor, &log.Action, &log.Entity, &log.EntityID, &before, &after, &changes, &log.RequestID, &log.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		log.Before = before
+		log.After = after
+		log.Changes = changes
+		logs = append(logs, log)
+	}
+
+	return logs, rows.Err()
+}
+
+// insertAuditLog records a mutation inside the caller's transaction so the
+// audit row commits or rolls back together with the change itself.
+func insertAuditLog(tx *sql.Tx, meta models.RequestMeta, action, entity string, entityID int, before, after any) error {
+	beforeJSON, beforeMap, err := auditSnapshot(before)
+	if err != nil {
+		return err
+	}
+	afterJSON, afterMap, err := auditSnapshot(after)
+	if err != nil {
+		return err
+	}
+
+	changes := make(map[string]models.AuditChange)
+	for key, value := range afterMap {
+		if old, ok := beforeMap[key]; !ok || !reflect.DeepEqual(old, value) {
+			changes[key] = models.AuditChange{From: beforeMap[key], To: value}
+		}
+	}
+	for key, old := range beforeMap {
+		if _, ok := afterMap[key]; !ok {
+			changes[key] = models.AuditChange{From: old, To: nil}
+		}
+	}
+	changesJSON, err := json.Marshal(changes)
+	if err != nil {
+		return err
+	}
+
+	query := "INSERT INTO audit_logs (actor, action, entity, entity_id, before_data, after_data, changes, request_id) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)"
+	_, err = tx.Exec(query, meta.ActorName(), action, entity, entityID, beforeJSON, afterJSON, string(changesJSON), meta.RequestID)
+	return err
+}
+
+// auditSnapshot returns the JSON form of v as a query argument (NULL for a nil
+// value) together with its top-level fields, which are used to compute the
+// change set.
+func auditSnapshot(v any) (any, map[string]any, error) {
+	if v == nil || (reflect.ValueOf(v).Kind() == reflect.Ptr && reflect.ValueOf(v).IsNil()) {
+		return nil, map[string]any{}, nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	fields := make(map[string]any)
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, nil, err
+	}
+
+	return string(data), fields, nil
+}
@@ -0,0 +1,149 @@
+package repositories
+
+import (
+	"cashier-api/models"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// saveProductBarcodes replaces the codes a product scans by. The primary
+// barcode is always one of them.
+func saveProductBarcodes(tx *sql.Tx, product *models.Product) error {
+	barcodes := make([]models.ProductBarcode, 0, len(product.Barcodes)+1)
+	if product.Barcode != "" {
+		barcodes = append(barcodes, models.ProductBarcode{Barcode: product.Barcode})
+	}
+	for _, barcode := range product.Barcodes {
+		if barcode.Barcode == product.Barcode && barcode.Barcode != "" {
+			barcodes[0].Kind = barcode.Kind
+			continue
+		}
+		for _, other := range barcodes {
+			if other.Barcode == barcode.Barcode {
+				return fmt.Errorf("barcode %q is listed more than once", barcode.Barcode)
+			}
+		}
+		barcodes = append(barcodes, barcode)
+	}
+	for i := range barcodes {
+		if err := barcodes[i].Validate(); err != nil {
+			return err
+		}
+	}
+	slices.SortFunc(barcodes, func(a, b models.ProductBarcode) int {
+		return strings.Compare(a.Barcode, b.Barcode)
+	})
+
+	_, err := tx.Exec("DELETE FROM product_barcodes WHERE product_id = $1", product.ID)
+	if err != nil {
+		return err
+	}
+	for _, barcode := range barcodes {
+		var taken bool
+		err := tx.QueryRow("SELECT EXISTS (SELECT 1 FROM product_barcodes WHERE barcode = $1)", barcode.Barcode).Scan(&taken)
+		if err != nil {
+			return err
+		}
+		if taken {
+			return fmt.Errorf("barcode %q is already used", barcode.Barcode)
+		}
+
+		_, err = tx.Exec("INSERT INTO product_barcodes (barcode, product_id, kind) VALUES ($1, $2, $3)", barcode.Barcode, product.ID, barcode.Kind)
+		if err != nil {
+			return err
+		}
+	}
+
+	product.Barcodes = barcodes
+	return nil
+}
+
+// findBarcode resolves a scanned code to a product as seen from an outlet.
+// A code registered as it is wins; otherwise a scale label is matched by its
+// prefix, and the weight or price it carries is read off the rest.
+func findBarcode(db queryer, code string, outletID int) (*models.BarcodeMatch, error) {
+	prefix, value, isScale := models.ParseScaleBarcode(code)
+
+	var productID int
+	match := models.BarcodeMatch{Barcode: code}
+	query := `
+		SELECT product_id, kind
+		FROM product_barcodes
+		WHERE barcode = $1 OR (barcode = $2 AND kind IN ($3, $4))
+		ORDER BY barcode = $1 DESC
+		LIMIT 1`
+	err := db.QueryRow(query, code, prefix, models.BarcodeScaleWeight, models.BarcodeScalePrice).Scan(&productID, &match.Kind)
+	if err == sql.ErrNoRows {
+		return nil, errors.New("Barcode not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	query, args := productQuery(outletID)
+	query += " AND p.id = $2"
+	args = append(args, productID)
+	if err := scanProduct(db.QueryRow(query, args...), &match.Product); err != nil {
+		return nil, err
+	}
+
+	switch match.Kind {
+	case models.BarcodeScaleWeight, models.BarcodeScalePrice:
+		if !isScale {
+			return nil, fmt.Errorf("barcode %q is a scale prefix; scan the full label", code)
+		}
+		quantity := models.Quantity(value)
+		if match.Kind == models.BarcodeScalePrice {
+			quantity = models.QuantityForPrice(value, match.Product.Price)
+			match.Price = &value
+		}
+		if quantity <= 0 {
+			return nil, fmt.Errorf("scale label %q carries no quantity", code)
+		}
+		match.Quantity = &quantity
+	}
+
+	return &match, nil
+}
+
+// scanBarcodes resolves the checkout lines given by barcode. Lines from a
+// price label keep the label's price as their subtotal.
+func scanBarcodes(tx *sql.Tx, lines []saleLine, outletID int) error {
+	for i := range lines {
+		item := &lines[i].item
+		if item.Barcode == "" {
+			continue
+		}
+		if item.ProductID != 0 {
+			return fmt.Errorf("barcode %q cannot be given with a product_id", item.Barcode)
+		}
+
+		match, err := findBarcode(tx, item.Barcode, outletID)
+		if err != nil {
+			return err
+		}
+		item.ProductID = match.Product.ID
+
+		switch {
+		case match.Quantity != nil:
+			if item.Unit != "" {
+				return fmt.Errorf("scale label %q cannot take a unit", item.Barcode)
+			}
+			item.Quantity = *match.Quantity
+		case item.Quantity == 0:
+			item.Quantity = models.Units(1)
+		}
+		if match.Price != nil {
+			if len(item.ModifierIDs) > 0 {
+				return fmt.Errorf("price label %q cannot take modifiers", item.Barcode)
+			}
+			lines[i].subtotal = *match.Price
+			lines[i].priced = true
+		}
+	}
+
+	return nil
+}
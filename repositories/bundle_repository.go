@@ -0,0 +1,428 @@
+package repositories
+
+import (
+	"cashier-api/models"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+type BundleRepository struct {
+	db *sql.DB
+}
+
+func NewBundleRepository(db *sql.DB) *BundleRepository {
+	return &BundleRepository{db: db}
+}
+
+// GetAll lists bundles. With an outlet each bundle says how many its stock
+// can still make up.
+func (repo *BundleRepository) GetAll(outletID int) ([]models.Bundle, error) {
+	rows, err := repo.db.Query("SELECT id, name, price FROM bundles ORDER BY name, id")
+	if err != nil {
+		return nil, err
+	}
+
+	bundles := make([]models.Bundle, 0)
+	for rows.Next() {
+		var bundle models.Bundle
+		if err := rows.Scan(&bundle.ID, &bundle.Name, &bundle.Price); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		bundles = append(bundles, bundle)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range bundles {
+		if err := loadBundle(repo.db, &bundles[i], outletID); err != nil {
+			return nil, err
+		}
+	}
+
+	return bundles, nil
+}
+
+func (repo *BundleRepository) GetByID(id, outletID int) (*models.Bundle, error) {
+	bundle, err := getBundle(repo.db, id, "")
+	if err != nil {
+		return nil, err
+	}
+	if err := loadBundle(repo.db, bundle, outletID); err != nil {
+		return nil, err
+	}
+	return bundle, nil
+}
+
+func (repo *BundleRepository) Create(bundle *models.Bundle, meta models.RequestMeta) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := validateBundle(tx, bundle); err != nil {
+		return err
+	}
+
+	err = tx.QueryRow("INSERT INTO bundles (name, price) VALUES ($1, $2) RETURNING id", bundle.Name, bundle.Price).Scan(&bundle.ID)
+	if err != nil {
+		return err
+	}
+
+	if err := insertBundleItems(tx, bundle); err != nil {
+		return err
+	}
+
+	err = insertAuditLog(tx, meta, models.AuditActionCreate, models.AuditEntityBundle, bundle.ID, nil, bundle)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (repo *BundleRepository) Update(bundle *models.Bundle, meta models.RequestMeta) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	before, err := getBundle(tx, bundle.ID, "FOR UPDATE")
+	if err != nil {
+		return err
+	}
+	if err := loadBundle(tx, before, 0); err != nil {
+		return err
+	}
+
+	if err := validateBundle(tx, bundle); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("UPDATE bundles SET name = $1, price = $2 WHERE id = $3", bundle.Name, bundle.Price, bundle.ID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("DELETE FROM bundle_items WHERE bundle_id = $1", bundle.ID)
+	if err != nil {
+		return err
+	}
+	if err := insertBundleItems(tx, bundle); err != nil {
+		return err
+	}
+
+	err = insertAuditLog(tx, meta, models.AuditActionUpdate, models.AuditEntityBundle, bundle.ID, before, bundle)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (repo *BundleRepository) Delete(id int, meta models.RequestMeta) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	before, err := getBundle(tx, id, "FOR UPDATE")
+	if err != nil {
+		return err
+	}
+	if err := loadBundle(tx, before, 0); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("DELETE FROM bundles WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	err = insertAuditLog(tx, meta, models.AuditActionDelete, models.AuditEntityBundle, id, before, nil)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetSalesReport totals bundle sales. Revenue is the package price actually
+// charged; refunds of components are netted out.
+func (repo *BundleRepository) GetSalesReport(filter models.BundleSalesFilter) (*models.BundleSalesReport, error) {
+	args := []interface{}{}
+	where := ""
+	if filter.OutletID != 0 {
+		args = append(args, filter.OutletID)
+		where += fmt.Sprintf(" AND t.outlet_id = $%d", len(args))
+	}
+	if filter.From != nil {
+		args = append(args, *filter.From)
+		where += fmt.Sprintf(" AND t.created_at >= $%d", len(args))
+	}
+	if filter.To != nil {
+		args = append(args, *filter.To)
+		where += fmt.Sprintf(" AND t.created_at < $%d", len(args))
+	}
+
+	query := `
+		WITH sold AS (
+			SELECT tb.id, tb.bundle_id, tb.name, tb.quantity
+			FROM transaction_bundles tb
+			JOIN transactions t ON t.id = tb.transaction_id
+			WHERE 1 = 1` + where + `
+		), lines AS (
+			SELECT td.transaction_bundle_id AS id,
+				SUM(td.subtotal) - COALESCE(SUM(r.amount), 0) AS revenue,
+				SUM(td.cost_amount) - COALESCE(SUM(r.cost_amount), 0) AS cost
+			FROM transaction_details td
+			LEFT JOIN (
+				SELECT transaction_detail_id, SUM(amount) AS amount, SUM(cost_amount) AS cost_amount
+				FROM refund_items
+				GROUP BY transaction_detail_id
+			) r ON r.transaction_detail_id = td.id
+			WHERE td.transaction_bundle_id IN (SELECT id FROM sold)
+			GROUP BY td.transaction_bundle_id
+		)
+		SELECT s.bundle_id, COALESCE(b.name, s.name), SUM(s.quantity), COALESCE(SUM(l.revenue), 0), COALESCE(SUM(l.cost), 0)
+		FROM sold s
+		LEFT JOIN lines l ON l.id = s.id
+		LEFT JOIN bundles b ON b.id = s.bundle_id
+		GROUP BY 1, 2
+		ORDER BY 2
+	`
+	rows, err := repo.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	report := models.BundleSalesReport{
+		Total: models.BundleSalesRow{Name: "Total"},
+		Rows:  make([]models.BundleSalesRow, 0),
+	}
+	for rows.Next() {
+		var row models.BundleSalesRow
+		if err := rows.Scan(&row.BundleID, &row.Name, &row.Quantity, &row.Revenue, &row.Cost); err != nil {
+			return nil, err
+		}
+		row.GrossProfit = row.Revenue - row.Cost
+		row.MarginPercent = models.MarginPercent(row.GrossProfit, row.Revenue)
+		report.Rows = append(report.Rows, row)
+
+		report.Total.Quantity += row.Quantity
+		report.Total.Revenue += row.Revenue
+		report.Total.Cost += row.Cost
+	}
+	report.Total.GrossProfit = report.Total.Revenue - report.Total.Cost
+	report.Total.MarginPercent = models.MarginPercent(report.Total.GrossProfit, report.Total.Revenue)
+
+	return &report, rows.Err()
+}
+
+func validateBundle(tx *sql.Tx, bundle *models.Bundle) error {
+	if bundle.Name == "" {
+		return errors.New("Name is required")
+	}
+	if bundle.Price < 0 {
+		return errors.New("Price must not be negative")
+	}
+	if len(bundle.Items) == 0 {
+		return errors.New("Bundle has no items")
+	}
+
+	seen := make(map[int]bool)
+	for i := range bundle.Items {
+		item := &bundle.Items[i]
+		if item.Quantity <= 0 {
+			return fmt.Errorf("invalid quantity for product id %d", item.ProductID)
+		}
+		if seen[item.ProductID] {
+			return fmt.Errorf("product id %d is listed more than once", item.ProductID)
+		}
+		seen[item.ProductID] = true
+
+		err := tx.QueryRow("SELECT name FROM products WHERE id = $1", item.ProductID).Scan(&item.ProductName)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("product id %d not found", item.ProductID)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func insertBundleItems(tx *sql.Tx, bundle *models.Bundle) error {
+	for _, item := range bundle.Items {
+		_, err := tx.Exec("INSERT INTO bundle_items (bundle_id, product_id, quantity) VALUES ($1, $2, $3)", bundle.ID, item.ProductID, item.Quantity)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getBundle loads a bundle without its items. lock is an optional row
+// locking clause.
+func getBundle(db queryer, id int, lock string) (*models.Bundle, error) {
+	var bundle models.Bundle
+	err := db.QueryRow("SELECT id, name, price FROM bundles WHERE id = $1 "+lock, id).Scan(&bundle.ID, &bundle.Name, &bundle.Price)
+	if err == sql.ErrNoRows {
+		return nil, errors.New("Bundle not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &bundle, nil
+}
+
+// loadBundle fills in a bundle's items and, when outletID is set, how many
+// bundles the outlet can make up. A composite component is limited by the
+// ingredients of its recipe.
+func loadBundle(db queryer, bundle *models.Bundle, outletID int) error {
+	rows, err := db.Query(`
+		SELECT bi.product_id, p.name, bi.quantity
+		FROM bundle_items bi
+		JOIN products p ON p.id = bi.product_id
+		WHERE bi.bundle_id = $1
+		ORDER BY bi.product_id
+	`, bundle.ID)
+	if err != nil {
+		return err
+	}
+
+	bundle.Items = make([]models.BundleItem, 0)
+	for rows.Next() {
+		var item models.BundleItem
+		if err := rows.Scan(&item.ProductID, &item.ProductName, &item.Quantity); err != nil {
+			rows.Close()
+			return err
+		}
+		bundle.Items = append(bundle.Items, item)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if outletID == 0 {
+		return nil
+	}
+
+	query := `
+		WITH available AS (
+			SELECT p.id, CASE WHEN p.is_composite THEN COALESCE((
+				SELECT MIN(FLOOR(COALESCE(cs.stock, 0) / ri.quantity))
+				FROM recipe_items ri
+				LEFT JOIN outlet_stocks cs ON cs.product_id = ri.component_id AND cs.outlet_id = $2
+				WHERE ri.product_id = p.id
+			), 0) ELSE COALESCE(os.stock, 0) END AS stock
+			FROM products p
+			LEFT JOIN outlet_stocks os ON os.product_id = p.id AND os.outlet_id = $2
+			WHERE p.id IN (SELECT product_id FROM bundle_items WHERE bundle_id = $1)
+		)
+		SELECT COALESCE(MIN(FLOOR(a.stock / bi.quantity)), 0)::INT
+		FROM bundle_items bi
+		JOIN available a ON a.id = bi.product_id
+		WHERE bi.bundle_id = $1
+	`
+	var available int
+	if err := db.QueryRow(query, bundle.ID, outletID).Scan(&available); err != nil {
+		return err
+	}
+	bundle.Available = &available
+
+	return nil
+}
+
+// saleLine is one product line of a checkout, with the index of the
+// checkout item it came from. Lines expanded from a bundle carry the index
+// of the bundle sold and their share of its price; priced lines come from a
+// scale label and carry its price.
+type saleLine struct {
+	item     models.CheckoutItem
+	index    int
+	bundle   int
+	subtotal int
+	priced   bool
+}
+
+// expandBundles turns the bundles in a checkout into lines for their
+// components. The package price is allocated across components in
+// proportion to their regular prices at the outlet.
+func expandBundles(tx *sql.Tx, items []models.CheckoutItem, outletID int) ([]saleLine, []models.TransactionBundle, error) {
+	lines := make([]saleLine, 0, len(items))
+	bundles := make([]models.TransactionBundle, 0)
+
+	for index, item := range items {
+		if item.BundleID == 0 {
+			lines = append(lines, saleLine{item: item, index: index, bundle: -1})
+			continue
+		}
+		if item.ProductID != 0 || item.Barcode != "" || item.Unit != "" || len(item.ModifierIDs) > 0 {
+			return nil, nil, fmt.Errorf("bundle id %d cannot take a product_id, barcode, unit or modifiers", item.BundleID)
+		}
+		if item.Quantity <= 0 || !item.Quantity.IsWhole() {
+			return nil, nil, fmt.Errorf("invalid quantity for bundle id %d", item.BundleID)
+		}
+
+		bundle, err := getBundle(tx, item.BundleID, "")
+		if err != nil {
+			return nil, nil, fmt.Errorf("bundle id %d not found", item.BundleID)
+		}
+
+		rows, err := tx.Query(`
+			SELECT bi.product_id, bi.quantity, COALESCE(os.price, `+priceColumn+`)
+			FROM bundle_items bi
+			JOIN products p ON p.id = bi.product_id
+			LEFT JOIN outlet_stocks os ON os.product_id = p.id AND os.outlet_id = $2
+			WHERE bi.bundle_id = $1
+			ORDER BY bi.product_id
+		`, item.BundleID, outletID)
+		if err != nil {
+			return nil, nil, err
+		}
+		components := make([]models.CheckoutItem, 0)
+		weights := make([]int, 0)
+		for rows.Next() {
+			var component models.CheckoutItem
+			var price int
+			if err := rows.Scan(&component.ProductID, &component.Quantity, &price); err != nil {
+				rows.Close()
+				return nil, nil, err
+			}
+			component.Quantity = component.Quantity.Mul(item.Quantity)
+			components = append(components, component)
+			weights = append(weights, component.Quantity.Times(price))
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, nil, err
+		}
+		if len(components) == 0 {
+			return nil, nil, fmt.Errorf("bundle id %d has no items", item.BundleID)
+		}
+
+		sold := models.TransactionBundle{
+			BundleID: &bundle.ID,
+			Name:     bundle.Name,
+			Price:    bundle.Price,
+			Quantity: item.Quantity,
+			Subtotal: item.Quantity.Times(bundle.Price),
+		}
+		shares := models.AllocateBundlePrice(sold.Subtotal, weights)
+		for i, component := range components {
+			lines = append(lines, saleLine{item: component, index: index, bundle: len(bundles), subtotal: shares[i]})
+		}
+		bundles = append(bundles, sold)
+	}
+
+	return lines, bundles, nil
+}
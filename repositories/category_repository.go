@@ -35,10 +35,25 @@ func (repo *CategoryRepository) GetAll() ([]models.Category, error) {
 	return categories, nil
 }
 
-func (repo *CategoryRepository) Create(category *models.Category) error {
+func (repo *CategoryRepository) Create(category *models.Category, meta models.RequestMeta) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
 	query := "INSERT INTO categories (name, description) VALUES ($1, $2) RETURNING id"
-	err := repo.db.QueryRow(query, category.Name, category.Description).Scan(&category.ID)
-	return err
+	err = tx.QueryRow(query, category.Name, category.Description).Scan(&category.ID)
+	if err != nil {
+		return err
+	}
+
+	err = insertAuditLog(tx, meta, models.AuditActionCreate, models.AuditEntityCategory, category.ID, nil, category)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
 func (repo *CategoryRepository) GetByID(id int) (*models.Category, error) {
@@ -56,39 +71,68 @@ func (repo *CategoryRepository) GetByID(id int) (*models.Category, error) {
 	return &category, nil
 }
 
-func (repo *CategoryRepository) Update(category *models.Category) error {
-	query := "UPDATE categories SET name = $1, description = $2 WHERE id = $3"
-	result, err := repo.db.Exec(query, category.Name, category.Description, category.ID)
+func (repo *CategoryRepository) Update(category *models.Category, meta models.RequestMeta) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	before, err := getCategoryForUpdate(tx, category.ID)
 	if err != nil {
 		return err
 	}
 
-	rows, err := result.RowsAffected()
+	query := "UPDATE categories SET name = $1, description = $2 WHERE id = $3"
+	_, err = tx.Exec(query, category.Name, category.Description, category.ID)
 	if err != nil {
 		return err
 	}
 
-	if rows == 0 {
-		return errors.New("Category not found")
+	err = insertAuditLog(tx, meta, models.AuditActionUpdate, models.AuditEntityCategory, category.ID, before, category)
+	if err != nil {
+		return err
 	}
 
-	return nil
+	return tx.Commit()
 }
 
-func (repo *CategoryRepository) Delete(id int) error {
-	query := "DELETE FROM categories WHERE id = $1"
-	result, err := repo.db.Exec(query, id)
+func (repo *CategoryRepository) Delete(id int, meta models.RequestMeta) error {
+	tx, err := repo.db.Begin()
 	if err != nil {
 		return err
 	}
-	rows, err := result.RowsAffected()
+	defer tx.Rollback()
+
+	before, err := getCategoryForUpdate(tx, id)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("DELETE FROM categories WHERE id = $1", id)
 	if err != nil {
 		return err
 	}
 
-	if rows == 0 {
-		return errors.New("Category not found")
+	err = insertAuditLog(tx, meta, models.AuditActionDelete, models.AuditEntityCategory, id, before, nil)
+	if err != nil {
+		return err
 	}
 
-	return err
+	return tx.Commit()
+}
+
+func getCategoryForUpdate(tx *sql.Tx, id int) (*models.Category, error) {
+	query := "SELECT id, name, description FROM categories WHERE id = $1 FOR UPDATE"
+
+	var category models.Category
+	err := tx.QueryRow(query, id).Scan(&category.ID, &category.Name, &category.Description)
+	if err == sql.ErrNoRows {
+		return nil, errors.New("Category not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &category, nil
 }
@@ -0,0 +1,133 @@
+package repositories
+
+import (
+	"cashier-api/models"
+	"database/sql"
+)
+
+// receiveCost takes incoming units worth amount into the product's cost.
+// Under moving average the product cost becomes the weighted average of the
+// stock on hand and the new units; under FIFO the units open a new cost
+// layer.
+func receiveCost(tx *sql.Tx, productID int, stockBefore, quantity models.Quantity, amount int, method string, currentCost int) error {
+	switch method {
+	case models.CostingMethodFIFO:
+		_, err := tx.Exec("INSERT INTO cost_layers (product_id, quantity, remaining, unit_cost) VALUES ($1, $2, $2, $3)", productID, quantity, models.PerUnit(amount, quantity))
+		if err != nil {
+			return err
+		}
+		return refreshFIFOCost(tx, productID)
+	default:
+		newCost := models.PerUnit(amount, quantity)
+		if stockBefore > 0 {
+			newCost = models.PerUnit(stockBefore.Times(currentCost)+amount, stockBefore+quantity)
+		}
+		if newCost != currentCost {
+			_, err := tx.Exec("UPDATE products SET cost = $1 WHERE id = $2", newCost, productID)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// issueCost values outgoing units. FIFO consumes the oldest open layers and
+// prices anything beyond them at the product cost; moving average uses the
+// product cost throughout.
+func issueCost(tx *sql.Tx, productID int, quantity models.Quantity, method string, currentCost int) (int, error) {
+	if method != models.CostingMethodFIFO {
+		return quantity.Times(currentCost), nil
+	}
+
+	rows, err := tx.Query("SELECT id, remaining, unit_cost FROM cost_layers WHERE product_id = $1 AND remaining > 0 ORDER BY id FOR UPDATE", productID)
+	if err != nil {
+		return 0, err
+	}
+
+	type layer struct {
+		id        int64
+		remaining models.Quantity
+		unitCost  int
+	}
+	layers := make([]layer, 0)
+	for rows.Next() {
+		var l layer
+		if err := rows.Scan(&l.id, &l.remaining, &l.unitCost); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		layers = append(layers, l)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	amount := 0
+	left := quantity
+	for _, l := range layers {
+		if left == 0 {
+			break
+		}
+		take := min(left, l.remaining)
+		_, err := tx.Exec("UPDATE cost_layers SET remaining = remaining - $1 WHERE id = $2", take, l.id)
+		if err != nil {
+			return 0, err
+		}
+		amount += take.Times(l.unitCost)
+		left -= take
+	}
+	amount += left.Times(currentCost)
+
+	if err := refreshFIFOCost(tx, productID); err != nil {
+		return 0, err
+	}
+
+	return amount, nil
+}
+
+// refreshFIFOCost keeps products.cost at the average of the open layers so
+// FIFO products still show a meaningful unit cost. The last cost is kept when
+// no layers are open.
+func refreshFIFOCost(tx *sql.Tx, productID int) error {
+	query := `
+		UPDATE products SET cost = layers.cost
+		FROM (
+			SELECT ROUND(SUM(remaining * unit_cost) / SUM(remaining))::INT AS cost
+			FROM cost_layers
+			WHERE product_id = $1 AND remaining > 0
+			HAVING SUM(remaining) > 0
+		) layers
+		WHERE products.id = $1
+	`
+	_, err := tx.Exec(query, productID)
+	return err
+}
+
+// switchCostingMethod converts a product between valuation methods without
+// changing the value of its stock: moving to FIFO opens one layer for the
+// stock on hand at the current cost, moving to average collapses the open
+// layers into their weighted average.
+func switchCostingMethod(tx *sql.Tx, product *models.Product, method string) error {
+	if method == models.CostingMethodFIFO {
+		if product.Stock > 0 {
+			_, err := tx.Exec("INSERT INTO cost_layers (product_id, quantity, remaining, unit_cost) VALUES ($1, $2, $2, $3)", product.ID, product.Stock, product.Cost)
+			if err != nil {
+				return err
+			}
+		}
+	} else {
+		if err := refreshFIFOCost(tx, product.ID); err != nil {
+			return err
+		}
+		_, err := tx.Exec("UPDATE cost_layers SET remaining = 0 WHERE product_id = $1 AND remaining > 0", product.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := tx.Exec("UPDATE products SET costing_method = $1 WHERE id = $2", method, product.ID)
+	return err
+}
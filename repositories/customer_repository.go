@@ -0,0 +1,451 @@
+package repositories
+
+import (
+	"cashier-api/models"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+type CustomerRepository struct {
+	db *sql.DB
+}
+
+func NewCustomerRepository(db *sql.DB) *CustomerRepository {
+	return &CustomerRepository{db: db}
+}
+
+const customerColumns = "c.id, c.name, COALESCE(c.phone, ''), c.email, c.address, c.tags, c.customer_group_id, c.credit_limit, c.created_at"
+
+func scanCustomer(row rowScanner, customer *models.Customer) error {
+	err := row.Scan(&customer.ID, &customer.Name, &customer.Phone, &customer.Email, &customer.Address, pq.Array(&customer.Tags), &customer.CustomerGroupID, &customer.CreditLimit, &customer.CreatedAt)
+	if customer.Tags == nil {
+		customer.Tags = make([]string, 0)
+	}
+	return err
+}
+
+// GetAll searches customers. The query matches part of the name or email,
+// or the phone number in any format NormalizePhone accepts.
+func (repo *CustomerRepository) GetAll(filter models.CustomerFilter) ([]models.Customer, error) {
+	query := "SELECT " + customerColumns + " FROM customers c WHERE 1 = 1"
+	args := []interface{}{}
+	if filter.Query != "" {
+		args = append(args, "%"+filter.Query+"%")
+		where := fmt.Sprintf("c.name ILIKE $%d OR c.email ILIKE $%d OR c.phone LIKE $%d", len(args), len(args), len(args))
+		if phone, err := models.NormalizePhone(filter.Query); err == nil {
+			args = append(args, phone)
+			where += fmt.Sprintf(" OR c.phone = $%d", len(args))
+		}
+		query += " AND (" + where + ")"
+	}
+	if filter.Tag != "" {
+		args = append(args, strings.ToLower(filter.Tag))
+		query += fmt.Sprintf(" AND $%d = ANY (c.tags)", len(args))
+	}
+
+	limit := filter.Limit
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+	args = append(args, limit)
+	query += fmt.Sprintf(" ORDER BY c.name, c.id LIMIT $%d", len(args))
+
+	rows, err := repo.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	customers := make([]models.Customer, 0)
+	for rows.Next() {
+		var customer models.Customer
+		if err := scanCustomer(rows, &customer); err != nil {
+			return nil, err
+		}
+		customers = append(customers, customer)
+	}
+
+	return customers, rows.Err()
+}
+
+func (repo *CustomerRepository) GetByID(id int) (*models.Customer, error) {
+	return getCustomer(repo.db, id, "")
+}
+
+func (repo *CustomerRepository) Create(customer *models.Customer, meta models.RequestMeta) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := validateCustomer(tx, customer); err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO customers (name, phone, email, address, tags, customer_group_id, credit_limit)
+		VALUES ($1, NULLIF($2, ''), $3, $4, $5, $6, $7)
+		RETURNING id, created_at`
+	err = tx.QueryRow(query, customer.Name, customer.Phone, customer.Email, customer.Address, pq.Array(customer.Tags), customer.CustomerGroupID, customer.CreditLimit).Scan(&customer.ID, &customer.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	err = insertAuditLog(tx, meta, models.AuditActionCreate, models.AuditEntityCustomer, customer.ID, nil, customer)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (repo *CustomerRepository) Update(customer *models.Customer, meta models.RequestMeta) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	before, err := getCustomer(tx, customer.ID, "FOR UPDATE")
+	if err != nil {
+		return err
+	}
+	if err := validateCustomer(tx, customer); err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE customers
+		SET name = $1, phone = NULLIF($2, ''), email = $3, address = $4, tags = $5, customer_group_id = $6, credit_limit = $7
+		WHERE id = $8`
+	_, err = tx.Exec(query, customer.Name, customer.Phone, customer.Email, customer.Address, pq.Array(customer.Tags), customer.CustomerGroupID, customer.CreditLimit, customer.ID)
+	if err != nil {
+		return err
+	}
+	customer.CreatedAt = before.CreatedAt
+
+	err = insertAuditLog(tx, meta, models.AuditActionUpdate, models.AuditEntityCustomer, customer.ID, before, customer)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetHistory returns a customer's purchases, newest first and at most
+// limit of them, with stats over all their purchases.
+func (repo *CustomerRepository) GetHistory(id, limit int) (*models.CustomerHistory, error) {
+	customer, err := getCustomer(repo.db, id, "")
+	if err != nil {
+		return nil, err
+	}
+	history := models.CustomerHistory{Customer: *customer}
+
+	query := `
+		WITH purchases AS (
+			SELECT t.created_at, t.total_amount - COALESCE((
+				SELECT SUM(r.total_amount) FROM refunds r WHERE r.transaction_id = t.id
+			), 0) AS amount
+			FROM transactions t
+			WHERE t.customer_id = $1
+		)
+		SELECT COUNT(*), COALESCE(SUM(amount), 0), MIN(created_at), MAX(created_at)
+		FROM purchases
+	`
+	stats := &history.Stats
+	err = repo.db.QueryRow(query, id).Scan(&stats.Visits, &stats.LifetimeValue, &stats.FirstVisit, &stats.LastVisit)
+	if err != nil {
+		return nil, err
+	}
+	if stats.Visits > 0 {
+		stats.AverageSpend = int(math.Round(float64(stats.LifetimeValue) / float64(stats.Visits)))
+	}
+	if stats.Visits > 1 {
+		days := stats.LastVisit.Sub(*stats.FirstVisit).Hours() / 24
+		stats.DaysBetweenVisits = math.Round(days/float64(stats.Visits-1)*10) / 10
+		if days > 0 {
+			stats.VisitsPerMonth = math.Round(float64(stats.Visits)/days*30*100) / 100
+		}
+	}
+
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+	rows, err := repo.db.Query(`
+		SELECT t.id, COALESCE(t.outlet_id, 0), COALESCE(o.name, ''), t.total_amount, COALESCE((
+			SELECT SUM(r.total_amount) FROM refunds r WHERE r.transaction_id = t.id
+		), 0), t.created_at
+		FROM transactions t
+		LEFT JOIN outlets o ON o.id = t.outlet_id
+		WHERE t.customer_id = $1
+		ORDER BY t.created_at DESC, t.id DESC
+		LIMIT $2
+	`, id, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history.Purchases = make([]models.CustomerPurchase, 0)
+	for rows.Next() {
+		var purchase models.CustomerPurchase
+		if err := rows.Scan(&purchase.TransactionID, &purchase.OutletID, &purchase.OutletName, &purchase.TotalAmount, &purchase.RefundAmount, &purchase.CreatedAt); err != nil {
+			return nil, err
+		}
+		history.Purchases = append(history.Purchases, purchase)
+	}
+
+	return &history, rows.Err()
+}
+
+// Delete removes a customer. Their past sales stay, without the customer.
+// A customer who still owes something on account, or has a layaway open,
+// cannot be removed.
+func (repo *CustomerRepository) Delete(id int, meta models.RequestMeta) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	before, err := getCustomer(tx, id, "FOR UPDATE")
+	if err != nil {
+		return err
+	}
+	outstanding, err := customerOutstanding(tx, id)
+	if err != nil {
+		return err
+	}
+	if outstanding > 0 {
+		return fmt.Errorf("customer still owes %d on account", outstanding)
+	}
+	var layaways int
+	err = tx.QueryRow("SELECT COUNT(*) FROM layaways WHERE customer_id = $1 AND status = $2", id, models.LayawayOpen).Scan(&layaways)
+	if err != nil {
+		return err
+	}
+	if layaways > 0 {
+		return fmt.Errorf("customer has %d open layaways", layaways)
+	}
+
+	_, err = tx.Exec("DELETE FROM customers WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	err = insertAuditLog(tx, meta, models.AuditActionDelete, models.AuditEntityCustomer, id, before, nil)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (repo *CustomerRepository) GetGroups() ([]models.CustomerGroup, error) {
+	rows, err := repo.db.Query("SELECT id, name, price_list_id, created_at FROM customer_groups ORDER BY name, id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	groups := make([]models.CustomerGroup, 0)
+	for rows.Next() {
+		var group models.CustomerGroup
+		if err := rows.Scan(&group.ID, &group.Name, &group.PriceListID, &group.CreatedAt); err != nil {
+			return nil, err
+		}
+		groups = append(groups, group)
+	}
+
+	return groups, rows.Err()
+}
+
+func (repo *CustomerRepository) GetGroupByID(id int) (*models.CustomerGroup, error) {
+	return getCustomerGroup(repo.db, id, "")
+}
+
+func (repo *CustomerRepository) CreateGroup(group *models.CustomerGroup, meta models.RequestMeta) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := validateCustomerGroup(tx, group); err != nil {
+		return err
+	}
+
+	err = tx.QueryRow("INSERT INTO customer_groups (name, price_list_id) VALUES ($1, $2) RETURNING id, created_at", group.Name, group.PriceListID).Scan(&group.ID, &group.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	err = insertAuditLog(tx, meta, models.AuditActionCreate, models.AuditEntityCustomerGroup, group.ID, nil, group)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (repo *CustomerRepository) UpdateGroup(group *models.CustomerGroup, meta models.RequestMeta) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	before, err := getCustomerGroup(tx, group.ID, "FOR UPDATE")
+	if err != nil {
+		return err
+	}
+	if err := validateCustomerGroup(tx, group); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("UPDATE customer_groups SET name = $1, price_list_id = $2 WHERE id = $3", group.Name, group.PriceListID, group.ID)
+	if err != nil {
+		return err
+	}
+	group.CreatedAt = before.CreatedAt
+
+	err = insertAuditLog(tx, meta, models.AuditActionUpdate, models.AuditEntityCustomerGroup, group.ID, before, group)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// DeleteGroup removes a group; its customers are left without one.
+func (repo *CustomerRepository) DeleteGroup(id int, meta models.RequestMeta) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	before, err := getCustomerGroup(tx, id, "FOR UPDATE")
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("DELETE FROM customer_groups WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	err = insertAuditLog(tx, meta, models.AuditActionDelete, models.AuditEntityCustomerGroup, id, before, nil)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// getCustomer loads a customer. lock is an optional row locking clause.
+func getCustomer(db queryer, id int, lock string) (*models.Customer, error) {
+	var customer models.Customer
+	err := scanCustomer(db.QueryRow("SELECT "+customerColumns+" FROM customers c WHERE c.id = $1 "+lock, id), &customer)
+	if err == sql.ErrNoRows {
+		return nil, errors.New("Customer not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &customer, nil
+}
+
+// getCustomerGroup loads a customer group. lock is an optional row locking
+// clause.
+func getCustomerGroup(db queryer, id int, lock string) (*models.CustomerGroup, error) {
+	var group models.CustomerGroup
+	err := db.QueryRow("SELECT id, name, price_list_id, created_at FROM customer_groups WHERE id = $1 "+lock, id).Scan(&group.ID, &group.Name, &group.PriceListID, &group.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, errors.New("Customer group not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &group, nil
+}
+
+// validateCustomer normalises a customer's phone number and tags, and
+// checks that no other customer has the phone number.
+func validateCustomer(tx *sql.Tx, customer *models.Customer) error {
+	customer.Name = strings.TrimSpace(customer.Name)
+	if customer.Name == "" {
+		return errors.New("Name is required")
+	}
+	customer.Email = strings.TrimSpace(customer.Email)
+	if customer.Email != "" && !strings.Contains(customer.Email, "@") {
+		return fmt.Errorf("invalid email %q", customer.Email)
+	}
+	if customer.CreditLimit < 0 {
+		return errors.New("Invalid credit_limit")
+	}
+
+	if customer.Phone != "" {
+		phone, err := models.NormalizePhone(customer.Phone)
+		if err != nil {
+			return err
+		}
+		customer.Phone = phone
+
+		var existing int
+		err = tx.QueryRow("SELECT id FROM customers WHERE phone = $1 AND id <> $2", customer.Phone, customer.ID).Scan(&existing)
+		if err == nil {
+			return fmt.Errorf("phone %s already belongs to customer id %d", customer.Phone, existing)
+		}
+		if err != sql.ErrNoRows {
+			return err
+		}
+	}
+
+	tags := make([]string, 0, len(customer.Tags))
+	seen := make(map[string]bool)
+	for _, tag := range customer.Tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	customer.Tags = tags
+
+	if customer.CustomerGroupID != nil {
+		if _, err := getCustomerGroup(tx, *customer.CustomerGroupID, "FOR SHARE"); err != nil {
+			return fmt.Errorf("customer group id %d not found", *customer.CustomerGroupID)
+		}
+	}
+	return nil
+}
+
+func validateCustomerGroup(tx *sql.Tx, group *models.CustomerGroup) error {
+	if group.Name == "" {
+		return errors.New("Name is required")
+	}
+	var taken bool
+	err := tx.QueryRow("SELECT EXISTS (SELECT 1 FROM customer_groups WHERE name = $1 AND id <> $2)", group.Name, group.ID).Scan(&taken)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return fmt.Errorf("customer group %q already exists", group.Name)
+	}
+	if group.PriceListID != nil {
+		if _, err := getPriceList(tx, *group.PriceListID, "FOR SHARE"); err != nil {
+			return fmt.Errorf("price list id %d not found", *group.PriceListID)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,10 @@
+package repositories
+
+import "database/sql"
+
+// queryer is satisfied by both *sql.DB and *sql.Tx so read helpers can run
+// inside or outside a transaction.
+type queryer interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
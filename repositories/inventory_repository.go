@@ -0,0 +1,274 @@
+package repositories
+
+import (
+	"cashier-api/models"
+	"database/sql"
+)
+
+type InventoryRepository struct {
+	db *sql.DB
+}
+
+func NewInventoryRepository(db *sql.DB) *InventoryRepository {
+	return &InventoryRepository{db: db}
+}
+
+func (repo *InventoryRepository) GetLowStock(outletID int) ([]models.LowStockItem, error) {
+	return getLowStockItems(repo.db, outletID)
+}
+
+// CreateSuggestedPurchaseOrders turns every low-stock product that still
+// needs ordering into draft purchase order lines, one order per preferred
+// supplier, delivered to the caller's outlet. Products without a supplier are
+// returned as skipped.
+func (repo *InventoryRepository) CreateSuggestedPurchaseOrders(meta models.RequestMeta) (*models.SuggestedPurchaseOrders, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	items, err := getLowStockItems(tx, meta.OutletID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := models.SuggestedPurchaseOrders{
+		PurchaseOrders: make([]models.PurchaseOrder, 0),
+		Skipped:        make([]models.LowStockItem, 0),
+	}
+
+	suppliers := make([]int, 0)
+	lines := make(map[int][]models.PurchaseOrderLineRequest)
+	for _, item := range items {
+		if item.SuggestedQuantity == 0 {
+			continue
+		}
+		if item.SupplierID == nil {
+			result.Skipped = append(result.Skipped, item)
+			continue
+		}
+
+		// Suggestions are in base units; they are ordered in whole purchase
+		// units at the matching cost.
+		var unit string
+		if err := tx.QueryRow("SELECT purchase_unit FROM products WHERE id = $1", item.ProductID).Scan(&unit); err != nil {
+			return nil, err
+		}
+		factor, err := unitFactor(tx, item.ProductID, unit)
+		if err != nil {
+			return nil, err
+		}
+
+		supplierID := *item.SupplierID
+		if _, ok := lines[supplierID]; !ok {
+			suppliers = append(suppliers, supplierID)
+		}
+		lines[supplierID] = append(lines[supplierID], models.PurchaseOrderLineRequest{
+			ProductID: item.ProductID,
+			Quantity:  models.Units(int((item.SuggestedQuantity + factor - 1) / factor)),
+			Unit:      unit,
+			UnitCost:  factor.Times(item.UnitCost),
+		})
+	}
+
+	for _, supplierID := range suppliers {
+		var id int
+		query := "INSERT INTO purchase_orders (outlet_id, supplier_id, status, note, created_by) VALUES ($1, $2, $3, $4, $5) RETURNING id"
+		err := tx.QueryRow(query, meta.OutletID, supplierID, models.PurchaseOrderStatusDraft, "Suggested from low stock", meta.ActorName()).Scan(&id)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := insertPurchaseOrderLines(tx, id, lines[supplierID]); err != nil {
+			return nil, err
+		}
+
+		order, err := getPurchaseOrder(tx, id, "")
+		if err != nil {
+			return nil, err
+		}
+		result.PurchaseOrders = append(result.PurchaseOrders, *order)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// GetValuation values every outlet's stock in a single statement, so goods
+// move between on hand and in transit without being counted twice or
+// missed. Stock in transit counts towards its destination outlet.
+func (repo *InventoryRepository) GetValuation() (*models.InventoryValuation, error) {
+	query := `
+		SELECT o.id, o.name,
+			COALESCE(h.quantity, 0), COALESCE(h.value, 0),
+			COALESCE(t.quantity, 0), COALESCE(t.value, 0)
+		FROM outlets o
+		LEFT JOIN (
+			SELECT os.outlet_id, SUM(os.stock) AS quantity, SUM(ROUND(os.stock * p.cost))::BIGINT AS value
+			FROM outlet_stocks os
+			JOIN products p ON p.id = os.product_id
+			GROUP BY os.outlet_id
+		) h ON h.outlet_id = o.id
+		LEFT JOIN (
+			SELECT st.destination_outlet_id AS outlet_id, SUM(l.dispatched_quantity) AS quantity, SUM(ROUND(l.dispatched_quantity * l.unit_cost))::BIGINT AS value
+			FROM stock_transfers st
+			JOIN stock_transfer_lines l ON l.stock_transfer_id = st.id
+			WHERE st.status = $1
+			GROUP BY st.destination_outlet_id
+		) t ON t.outlet_id = o.id
+		ORDER BY o.id
+	`
+	rows, err := repo.db.Query(query, models.StockTransferStatusInTransit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	valuation := models.InventoryValuation{
+		Total:   models.InventoryValuationRow{OutletName: "Total"},
+		Outlets: make([]models.InventoryValuationRow, 0),
+	}
+	for rows.Next() {
+		var row models.InventoryValuationRow
+		err := rows.Scan(&row.OutletID, &row.OutletName, &row.OnHandQuantity, &row.OnHandValue, &row.InTransitQuantity, &row.InTransitValue)
+		if err != nil {
+			return nil, err
+		}
+		row.TotalValue = row.OnHandValue + row.InTransitValue
+		valuation.Outlets = append(valuation.Outlets, row)
+
+		valuation.Total.OnHandQuantity += row.OnHandQuantity
+		valuation.Total.OnHandValue += row.OnHandValue
+		valuation.Total.InTransitQuantity += row.InTransitQuantity
+		valuation.Total.InTransitValue += row.InTransitValue
+		valuation.Total.TotalValue += row.TotalValue
+	}
+
+	return &valuation, rows.Err()
+}
+
+// GetLots lists the lots of a product that still hold stock, in the order
+// they are sold from.
+func (repo *InventoryRepository) GetLots(productID, outletID int) ([]models.Lot, error) {
+	query := `
+		SELECT l.id, l.outlet_id, l.product_id, p.name, l.batch_number, TO_CHAR(l.expiry_date, 'YYYY-MM-DD'), l.remaining, l.created_at
+		FROM lots l
+		JOIN products p ON p.id = l.product_id
+		WHERE l.product_id = $1 AND l.remaining > 0 AND ($2 = 0 OR l.outlet_id = $2)
+		ORDER BY l.outlet_id, l.expiry_date NULLS LAST, l.id
+	`
+	rows, err := repo.db.Query(query, productID, outletID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lots := make([]models.Lot, 0)
+	for rows.Next() {
+		var lot models.Lot
+		err := rows.Scan(&lot.ID, &lot.OutletID, &lot.ProductID, &lot.ProductName, &lot.BatchNumber, &lot.ExpiryDate, &lot.Remaining, &lot.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		lots = append(lots, lot)
+	}
+
+	return lots, rows.Err()
+}
+
+// GetNearExpiry lists lots with stock that expire within days from today,
+// including lots already expired, valued at the product cost.
+func (repo *InventoryRepository) GetNearExpiry(days, outletID int) (*models.NearExpiryReport, error) {
+	query := `
+		SELECT l.id, l.outlet_id, l.product_id, p.name, l.batch_number, TO_CHAR(l.expiry_date, 'YYYY-MM-DD'), l.remaining, l.created_at,
+			l.expiry_date - CURRENT_DATE, ROUND(l.remaining * p.cost)::BIGINT
+		FROM lots l
+		JOIN products p ON p.id = l.product_id
+		WHERE l.remaining > 0 AND l.expiry_date <= CURRENT_DATE + $1::INT AND ($2 = 0 OR l.outlet_id = $2)
+		ORDER BY l.expiry_date, p.name, l.outlet_id
+	`
+	rows, err := repo.db.Query(query, days, outletID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	report := models.NearExpiryReport{Days: days, Items: make([]models.NearExpiryItem, 0)}
+	for rows.Next() {
+		var item models.NearExpiryItem
+		err := rows.Scan(&item.ID, &item.OutletID, &item.ProductID, &item.ProductName, &item.BatchNumber, &item.ExpiryDate, &item.Remaining, &item.CreatedAt, &item.DaysLeft, &item.Value)
+		if err != nil {
+			return nil, err
+		}
+		item.Expired = item.DaysLeft < 0
+		report.Items = append(report.Items, item)
+
+		report.TotalQuantity += item.Remaining
+		report.TotalValue += item.Value
+	}
+
+	return &report, rows.Err()
+}
+
+// getLowStockItems lists products at or below their minimum stock at one
+// outlet, or across all outlets when outletID is 0. Quantities still
+// outstanding on draft, sent or partially received purchase orders for the
+// same outlet count as on order and reduce the suggested quantity.
+func getLowStockItems(db queryer, outletID int) ([]models.LowStockItem, error) {
+	query := `
+		WITH stock AS (
+			SELECT p.id AS product_id, CASE WHEN $4 = 0 THEN p.stock ELSE COALESCE(os.stock, 0) END AS stock
+			FROM products p
+			LEFT JOIN outlet_stocks os ON os.product_id = p.id AND os.outlet_id = $4
+		)
+		SELECT
+			p.id, p.name, st.stock, p.min_stock, p.reorder_qty, p.cost, p.supplier_id, COALESCE(s.name, ''),
+			COALESCE((
+				SELECT SUM(GREATEST(l.quantity - l.received_quantity, 0) * l.factor)
+				FROM purchase_order_lines l
+				JOIN purchase_orders po ON po.id = l.purchase_order_id
+				WHERE l.product_id = p.id AND po.status IN ($1, $2, $3) AND ($4 = 0 OR po.outlet_id = $4)
+			), 0)
+		FROM products p
+		JOIN stock st ON st.product_id = p.id
+		LEFT JOIN suppliers s ON s.id = p.supplier_id
+		WHERE p.min_stock > 0 AND st.stock <= p.min_stock AND NOT p.is_composite
+		ORDER BY s.name NULLS LAST, p.name
+	`
+	rows, err := db.Query(query, models.PurchaseOrderStatusDraft, models.PurchaseOrderStatusSent, models.PurchaseOrderStatusPartiallyReceived, outletID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]models.LowStockItem, 0)
+	for rows.Next() {
+		var item models.LowStockItem
+		err := rows.Scan(&item.ProductID, &item.ProductName, &item.Stock, &item.MinStock, &item.ReorderQty, &item.UnitCost, &item.SupplierID, &item.SupplierName, &item.OnOrder)
+		if err != nil {
+			return nil, err
+		}
+		item.SuggestedQuantity = suggestedOrderQuantity(item)
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+// suggestedOrderQuantity orders the reorder quantity, or enough to lift the
+// stock above the minimum when no reorder quantity is set. Nothing is
+// suggested when open orders already lift it above the minimum.
+func suggestedOrderQuantity(item models.LowStockItem) models.Quantity {
+	shortfall := item.MinStock - item.Stock - item.OnOrder + models.Units(1)
+	if shortfall <= 0 {
+		return 0
+	}
+	if item.ReorderQty > 0 {
+		return max(item.ReorderQty, shortfall)
+	}
+	return shortfall
+}
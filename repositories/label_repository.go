@@ -0,0 +1,161 @@
+package repositories
+
+import (
+	"cashier-api/models"
+	"database/sql"
+
+	"github.com/lib/pq"
+)
+
+type LabelRepository struct {
+	db *sql.DB
+}
+
+func NewLabelRepository(db *sql.DB) *LabelRepository {
+	return &LabelRepository{db: db}
+}
+
+// GetProducts loads products to label, as seen from an outlet, in the order
+// of ids.
+func (repo *LabelRepository) GetProducts(ids []int, outletID int) ([]models.Product, error) {
+	return getLabelProducts(repo.db, ids, outletID)
+}
+
+func (repo *LabelRepository) GetChanged(outletID int) ([]models.LabelChange, error) {
+	return getLabelChanges(repo.db, outletID)
+}
+
+// CreatePrintRun picks the products to print and records the price each is
+// printed with at the caller's outlet. A run with no products is not
+// recorded.
+func (repo *LabelRepository) CreatePrintRun(req models.LabelPrintRequest, meta models.RequestMeta) (*models.LabelPrintRun, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	ids := req.ProductIDs
+	if req.ChangedOnly {
+		changes, err := getLabelChanges(tx, meta.OutletID)
+		if err != nil {
+			return nil, err
+		}
+		requested := make(map[int]bool, len(req.ProductIDs))
+		for _, id := range req.ProductIDs {
+			requested[id] = true
+		}
+		ids = make([]int, 0, len(changes))
+		for _, change := range changes {
+			if len(req.ProductIDs) == 0 || requested[change.ProductID] {
+				ids = append(ids, change.ProductID)
+			}
+		}
+	} else if len(ids) == 0 {
+		rows, err := tx.Query("SELECT id FROM products ORDER BY name, id")
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var id int
+			if err := rows.Scan(&id); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			ids = append(ids, id)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	run := models.LabelPrintRun{PrintedBy: meta.ActorName()}
+	run.Products, err = getLabelProducts(tx, ids, meta.OutletID)
+	if err != nil {
+		return nil, err
+	}
+	if len(run.Products) == 0 {
+		return &run, nil
+	}
+
+	if meta.OutletID != 0 {
+		run.OutletID = &meta.OutletID
+	}
+	err = tx.QueryRow("INSERT INTO label_print_runs (outlet_id, printed_by) VALUES ($1, $2) RETURNING id, created_at", run.OutletID, run.PrintedBy).Scan(&run.ID, &run.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	for _, product := range run.Products {
+		_, err = tx.Exec("INSERT INTO label_prints (print_run_id, product_id, price) VALUES ($1, $2, $3)", run.ID, product.ID, product.Price)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &run, nil
+}
+
+func getLabelProducts(db queryer, ids []int, outletID int) ([]models.Product, error) {
+	query, args := productQuery(outletID)
+	query += " AND p.id = ANY ($2) ORDER BY ARRAY_POSITION($2, p.id)"
+	args = append(args, pq.Array(ids))
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	products := make([]models.Product, 0, len(ids))
+	for rows.Next() {
+		var product models.Product
+		if err := scanProduct(rows, &product); err != nil {
+			return nil, err
+		}
+		products = append(products, product)
+	}
+
+	return products, rows.Err()
+}
+
+// getLabelChanges lists products whose price at an outlet differs from the
+// price on the last label printed for that outlet, or that were never
+// printed there.
+func getLabelChanges(db queryer, outletID int) ([]models.LabelChange, error) {
+	query := `
+		SELECT p.id, p.name, COALESCE(os.price, ` + priceColumn + `), lp.price
+		FROM products p
+		LEFT JOIN outlet_stocks os ON os.product_id = p.id AND os.outlet_id = $1
+		LEFT JOIN LATERAL (
+			SELECT lp.price
+			FROM label_prints lp
+			JOIN label_print_runs r ON r.id = lp.print_run_id
+			WHERE lp.product_id = p.id AND r.outlet_id IS NOT DISTINCT FROM NULLIF($1, 0)
+			ORDER BY r.id DESC
+			LIMIT 1
+		) lp ON TRUE
+		WHERE lp.price IS NULL OR lp.price <> COALESCE(os.price, ` + priceColumn + `)
+		ORDER BY p.name, p.id
+	`
+	rows, err := db.Query(query, outletID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	changes := make([]models.LabelChange, 0)
+	for rows.Next() {
+		var change models.LabelChange
+		if err := rows.Scan(&change.ProductID, &change.ProductName, &change.Price, &change.PrintedPrice); err != nil {
+			return nil, err
+		}
+		changes = append(changes, change)
+	}
+
+	return changes, rows.Err()
+}
@@ -0,0 +1,689 @@
+package repositories
+
+import (
+	"cashier-api/models"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+type LayawayRepository struct {
+	db *sql.DB
+}
+
+func NewLayawayRepository(db *sql.DB) *LayawayRepository {
+	return &LayawayRepository{db: db}
+}
+
+const layawayColumns = `l.id, l.outlet_id, l.customer_id, COALESCE(c.name, ''), l.status, l.total_amount, l.paid_amount, l.due_at, l.note,
+	l.transaction_id, l.forfeited_amount, l.refunded_amount, l.refund_method, l.cancel_reason, l.created_by, l.created_at, l.completed_at, l.cancelled_at`
+
+func scanLayaway(row rowScanner, layaway *models.Layaway) error {
+	err := row.Scan(&layaway.ID, &layaway.OutletID, &layaway.CustomerID, &layaway.CustomerName, &layaway.Status, &layaway.TotalAmount, &layaway.PaidAmount, &layaway.DueAt, &layaway.Note,
+		&layaway.TransactionID, &layaway.ForfeitedAmount, &layaway.RefundedAmount, &layaway.RefundMethod, &layaway.CancelReason, &layaway.CreatedBy, &layaway.CreatedAt, &layaway.CompletedAt, &layaway.CancelledAt)
+	layaway.Balance = layaway.TotalAmount - layaway.PaidAmount
+	return err
+}
+
+func getLayawaySettings(db queryer) (*models.LayawaySettings, error) {
+	var settings models.LayawaySettings
+	err := db.QueryRow("SELECT min_deposit_percent, term_days, forfeit_percent, forfeit_min, expired_forfeit_percent, updated_at FROM layaway_settings WHERE id = 1").Scan(
+		&settings.MinDepositPercent, &settings.TermDays, &settings.ForfeitPercent, &settings.ForfeitMin, &settings.ExpiredForfeitPercent, &settings.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+func (repo *LayawayRepository) GetSettings() (*models.LayawaySettings, error) {
+	return getLayawaySettings(repo.db)
+}
+
+// UpdateSettings replaces the layaway settings. Layaways already open keep
+// their due date; the forfeiture rules in force when one is cancelled apply.
+func (repo *LayawayRepository) UpdateSettings(settings *models.LayawaySettings, meta models.RequestMeta) error {
+	if err := validateLayawaySettings(settings); err != nil {
+		return err
+	}
+
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	before, err := getLayawaySettings(tx)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE layaway_settings
+		SET min_deposit_percent = $1, term_days = $2, forfeit_percent = $3, forfeit_min = $4, expired_forfeit_percent = $5, updated_at = NOW()
+		WHERE id = 1
+		RETURNING updated_at`
+	err = tx.QueryRow(query, settings.MinDepositPercent, settings.TermDays, settings.ForfeitPercent, settings.ForfeitMin, settings.ExpiredForfeitPercent).Scan(&settings.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	err = insertAuditLog(tx, meta, models.AuditActionUpdate, models.AuditEntityLayawaySettings, 1, before, settings)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (repo *LayawayRepository) GetAll(filter models.LayawayFilter) ([]models.Layaway, error) {
+	query := "SELECT " + layawayColumns + " FROM layaways l LEFT JOIN customers c ON c.id = l.customer_id WHERE 1 = 1"
+	args := []interface{}{}
+
+	if filter.Status != "" {
+		switch filter.Status {
+		case models.LayawayOpen, models.LayawayCompleted, models.LayawayCancelled:
+		default:
+			return nil, fmt.Errorf("invalid status %q", filter.Status)
+		}
+		args = append(args, filter.Status)
+		query += fmt.Sprintf(" AND l.status = $%d", len(args))
+	}
+	if filter.CustomerID != 0 {
+		args = append(args, filter.CustomerID)
+		query += fmt.Sprintf(" AND l.customer_id = $%d", len(args))
+	}
+	if filter.OutletID != 0 {
+		args = append(args, filter.OutletID)
+		query += fmt.Sprintf(" AND l.outlet_id = $%d", len(args))
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+	if limit > 500 {
+		limit = 500
+	}
+	args = append(args, limit)
+	query += fmt.Sprintf(" ORDER BY l.created_at DESC, l.id DESC LIMIT $%d", len(args))
+
+	rows, err := repo.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	layaways := make([]models.Layaway, 0)
+	for rows.Next() {
+		var layaway models.Layaway
+		if err := scanLayaway(rows, &layaway); err != nil {
+			return nil, err
+		}
+		layaways = append(layaways, layaway)
+	}
+	return layaways, rows.Err()
+}
+
+func (repo *LayawayRepository) GetByID(id int) (*models.Layaway, error) {
+	return getLayaway(repo.db, id, "")
+}
+
+// getLayaway loads a layaway with its items and payments. lock is an
+// optional row locking clause such as "FOR UPDATE".
+func getLayaway(db queryer, id int, lock string) (*models.Layaway, error) {
+	var layaway models.Layaway
+	if lock != "" {
+		lock += " OF l"
+	}
+	err := scanLayaway(db.QueryRow("SELECT "+layawayColumns+" FROM layaways l LEFT JOIN customers c ON c.id = l.customer_id WHERE l.id = $1 "+lock, id), &layaway)
+	if err == sql.ErrNoRows {
+		return nil, errors.New("Layaway not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query(`
+		SELECT li.id, li.product_id, p.name, li.quantity, li.subtotal, li.price_list_id
+		FROM layaway_items li
+		JOIN products p ON p.id = li.product_id
+		WHERE li.layaway_id = $1
+		ORDER BY li.id
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	layaway.Items = make([]models.LayawayItem, 0)
+	for rows.Next() {
+		var item models.LayawayItem
+		if err := rows.Scan(&item.ID, &item.ProductID, &item.ProductName, &item.Quantity, &item.Subtotal, &item.PriceListID); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		layaway.Items = append(layaway.Items, item)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = db.Query("SELECT id, method, amount, created_by, created_at FROM layaway_payments WHERE layaway_id = $1 ORDER BY created_at, id", id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	layaway.Payments = make([]models.LayawayPayment, 0)
+	for rows.Next() {
+		var payment models.LayawayPayment
+		if err := rows.Scan(&payment.ID, &payment.Method, &payment.Amount, &payment.CreatedBy, &payment.CreatedAt); err != nil {
+			return nil, err
+		}
+		layaway.Payments = append(layaway.Payments, payment)
+	}
+	return &layaway, rows.Err()
+}
+
+// Create opens a layaway on a customer's items at today's prices, takes the
+// deposit and reserves the stock at the outlet. Only plain products can be
+// put on layaway: not bundles, products with modifiers or recipes, or gift
+// cards.
+func (repo *LayawayRepository) Create(req models.LayawayRequest, meta models.RequestMeta) (*models.Layaway, error) {
+	if req.CustomerID == nil {
+		return nil, errors.New("A customer is required for a layaway")
+	}
+	if len(req.Items) == 0 {
+		return nil, errors.New("Items are required")
+	}
+
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// The customer is locked before any stock, as in a checkout.
+	if err := lockCustomer(tx, *req.CustomerID); err != nil {
+		return nil, err
+	}
+
+	sale, err := priceSale(tx, models.CheckoutRequest{CustomerID: req.CustomerID, Items: req.Items}, meta.OutletID)
+	if err != nil {
+		return nil, err
+	}
+	if len(sale.bundles) > 0 {
+		return nil, errors.New("Bundles cannot be put on layaway")
+	}
+	for i, detail := range sale.details {
+		if sale.composite[i] || len(sale.modifiers[i]) > 0 || len(detail.GiftCards) > 0 {
+			return nil, fmt.Errorf("product id %d cannot be put on layaway", detail.ProductID)
+		}
+	}
+	if sale.total <= 0 {
+		return nil, errors.New("A layaway must have something to pay")
+	}
+
+	settings, err := getLayawaySettings(tx)
+	if err != nil {
+		return nil, err
+	}
+	deposit := 0
+	for i := range req.Payments {
+		if err := validateInstallment(&req.Payments[i]); err != nil {
+			return nil, err
+		}
+		deposit += req.Payments[i].Amount
+	}
+	minDeposit := (sale.total*settings.MinDepositPercent + 99) / 100
+	if deposit < minDeposit {
+		return nil, fmt.Errorf("a deposit of at least %d is required", minDeposit)
+	}
+	if deposit > sale.total {
+		return nil, fmt.Errorf("deposit of %d exceeds the total of %d", deposit, sale.total)
+	}
+
+	layaway := models.Layaway{
+		OutletID:    meta.OutletID,
+		CustomerID:  req.CustomerID,
+		Status:      models.LayawayOpen,
+		TotalAmount: sale.total,
+		PaidAmount:  deposit,
+		Note:        strings.TrimSpace(req.Note),
+		CreatedBy:   meta.ActorName(),
+	}
+	query := `
+		INSERT INTO layaways (outlet_id, customer_id, status, total_amount, paid_amount, due_at, note, created_by)
+		VALUES ($1, $2, $3, $4, $5, NOW() + MAKE_INTERVAL(days => $6), $7, $8)
+		RETURNING id`
+	err = tx.QueryRow(query, layaway.OutletID, layaway.CustomerID, layaway.Status, layaway.TotalAmount, layaway.PaidAmount, settings.TermDays, layaway.Note, layaway.CreatedBy).Scan(&layaway.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]models.LayawayItem, 0, len(sale.details))
+	for _, detail := range sale.details {
+		item := models.LayawayItem{ProductID: detail.ProductID, Quantity: detail.Quantity, Subtotal: detail.Subtotal, PriceListID: detail.PriceListID}
+		_, err := tx.Exec("INSERT INTO layaway_items (layaway_id, product_id, quantity, subtotal, price_list_id) VALUES ($1, $2, $3, $4, $5)", layaway.ID, item.ProductID, item.Quantity, item.Subtotal, item.PriceListID)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	if err := reserveStock(tx, layaway.OutletID, items); err != nil {
+		return nil, err
+	}
+
+	for _, payment := range req.Payments {
+		_, err := tx.Exec("INSERT INTO layaway_payments (layaway_id, method, amount, created_by) VALUES ($1, $2, $3, $4)", layaway.ID, payment.Method, payment.Amount, meta.ActorName())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return getLayaway(repo.db, layaway.ID, "")
+}
+
+// AddPayment takes an installment towards an open layaway. A layaway cannot
+// be paid beyond its balance.
+func (repo *LayawayRepository) AddPayment(id int, payment models.Payment, meta models.RequestMeta) (*models.Layaway, error) {
+	if err := validateInstallment(&payment); err != nil {
+		return nil, err
+	}
+
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	layaway, err := getLayaway(tx, id, "FOR UPDATE")
+	if err != nil {
+		return nil, err
+	}
+	if layaway.Status != models.LayawayOpen {
+		return nil, fmt.Errorf("layaway is %s", layaway.Status)
+	}
+	if meta.OutletID != layaway.OutletID {
+		return nil, fmt.Errorf("layaway is held at outlet id %d", layaway.OutletID)
+	}
+	if payment.Amount > layaway.Balance {
+		return nil, fmt.Errorf("layaway has only %d left to pay", layaway.Balance)
+	}
+
+	_, err = tx.Exec("INSERT INTO layaway_payments (layaway_id, method, amount, created_by) VALUES ($1, $2, $3, $4)", id, payment.Method, payment.Amount, meta.ActorName())
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec("UPDATE layaways SET paid_amount = paid_amount + $1 WHERE id = $2", payment.Amount, id); err != nil {
+		return nil, err
+	}
+
+	after, err := getLayaway(tx, id, "")
+	if err != nil {
+		return nil, err
+	}
+	if err := insertAuditLog(tx, meta, models.AuditActionPayment, models.AuditEntityLayaway, id, layaway, after); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return getLayaway(repo.db, id, "")
+}
+
+// Complete hands over the goods of a layaway that is paid in full. It
+// becomes a sale at the prices it was opened at, paid with its
+// installments, and takes its reserved stock out of the outlet. Besides the
+// layaway it returns the sale's low stock events, for the caller to deliver
+// once committed.
+func (repo *LayawayRepository) Complete(id int, meta models.RequestMeta) (*models.Layaway, []models.LowStockEvent, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback()
+
+	layaway, err := getLayaway(tx, id, "FOR UPDATE")
+	if err != nil {
+		return nil, nil, err
+	}
+	if layaway.Status != models.LayawayOpen {
+		return nil, nil, fmt.Errorf("layaway is %s", layaway.Status)
+	}
+	if layaway.Balance > 0 {
+		return nil, nil, fmt.Errorf("layaway still has %d to pay", layaway.Balance)
+	}
+	if meta.OutletID != layaway.OutletID {
+		return nil, nil, fmt.Errorf("layaway is held at outlet id %d", layaway.OutletID)
+	}
+
+	// The sale is built from the layaway's own lines, so it keeps their
+	// prices whatever the products sell for today.
+	sale := &pricedSale{reorderQty: make(map[int]models.Quantity), total: layaway.TotalAmount}
+	for _, item := range layaway.Items {
+		var reorderQty models.Quantity
+		if err := tx.QueryRow("SELECT reorder_qty FROM products WHERE id = $1", item.ProductID).Scan(&reorderQty); err != nil {
+			return nil, nil, err
+		}
+		sale.reorderQty[item.ProductID] = reorderQty
+		sale.details = append(sale.details, models.TransactionDetail{
+			ProductID:   item.ProductID,
+			ProductName: item.ProductName,
+			Quantity:    item.Quantity,
+			Subtotal:    item.Subtotal,
+			PriceListID: item.PriceListID,
+			Modifiers:   make([]models.TransactionDetailModifier, 0),
+		})
+		sale.modifiers = append(sale.modifiers, nil)
+		sale.composite = append(sale.composite, false)
+		sale.bundleOf = append(sale.bundleOf, -1)
+	}
+
+	// Installments are grouped by method into the sale's payments.
+	payments := make([]models.Payment, 0)
+	byMethod := make(map[string]int)
+	for _, installment := range layaway.Payments {
+		i, ok := byMethod[installment.Method]
+		if !ok {
+			i = len(payments)
+			byMethod[installment.Method] = i
+			payments = append(payments, models.Payment{Method: installment.Method})
+		}
+		payments[i].Amount += installment.Amount
+	}
+
+	pointsEarned := 0
+	loyalty, err := getLoyaltySettings(tx)
+	if err != nil {
+		return nil, nil, err
+	}
+	if layaway.CustomerID != nil {
+		if err := lockCustomer(tx, *layaway.CustomerID); err != nil {
+			return nil, nil, err
+		}
+		if err := expirePoints(tx, *layaway.CustomerID, meta.ActorName()); err != nil {
+			return nil, nil, err
+		}
+		if loyalty.Enabled {
+			pointsEarned, err = earnPoints(tx, sale.details, sale.total, 0, loyalty)
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	if err := releaseStock(tx, layaway.OutletID, layaway.Items); err != nil {
+		return nil, nil, err
+	}
+
+	var transactionID int
+	query := `
+		INSERT INTO transactions (total_amount, outlet_id, terminal_id, customer_id, points_earned)
+		VALUES ($1, $2, NULLIF($3, 0), $4, $5)
+		RETURNING id`
+	err = tx.QueryRow(query, sale.total, layaway.OutletID, meta.TerminalID, layaway.CustomerID, pointsEarned).Scan(&transactionID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	events, err := takeSaleStock(tx, sale, transactionID, meta)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := insertSaleDetails(tx, sale, transactionID, meta.ActorName()); err != nil {
+		return nil, nil, err
+	}
+	if err := insertSalePayments(tx, transactionID, payments, meta.ActorName()); err != nil {
+		return nil, nil, err
+	}
+	if pointsEarned > 0 {
+		err := addPoints(tx, &models.PointsEntry{
+			CustomerID:    *layaway.CustomerID,
+			Points:        pointsEarned,
+			Reason:        models.PointsReasonEarn,
+			TransactionID: &transactionID,
+			CreatedBy:     meta.ActorName(),
+		}, loyalty.ExpiryDays)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	_, err = tx.Exec("UPDATE layaways SET status = $1, transaction_id = $2, completed_at = NOW() WHERE id = $3", models.LayawayCompleted, transactionID, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, err
+	}
+	layaway, err = getLayaway(repo.db, id, "")
+	if err != nil {
+		return nil, nil, err
+	}
+	return layaway, events, nil
+}
+
+// Cancel cancels an open layaway and releases its stock. The customer
+// forfeits part of what they paid under the forfeiture rules, more once
+// the layaway is past due; the rest is paid back in cash or as store
+// credit.
+func (repo *LayawayRepository) Cancel(id int, req models.LayawayCancelRequest, meta models.RequestMeta) (*models.Layaway, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	layaway, err := getLayaway(tx, id, "FOR UPDATE")
+	if err != nil {
+		return nil, err
+	}
+	if layaway.Status != models.LayawayOpen {
+		return nil, fmt.Errorf("layaway is %s", layaway.Status)
+	}
+	if meta.OutletID != layaway.OutletID {
+		return nil, fmt.Errorf("layaway is held at outlet id %d", layaway.OutletID)
+	}
+
+	settings, err := getLayawaySettings(tx)
+	if err != nil {
+		return nil, err
+	}
+	forfeited := settings.Forfeit(layaway.PaidAmount, time.Now().After(layaway.DueAt))
+	refunded := layaway.PaidAmount - forfeited
+
+	refundMethod := ""
+	if refunded > 0 {
+		refundMethod = models.PaymentCash
+		if req.StoreCredit {
+			if layaway.CustomerID == nil {
+				return nil, errors.New("A customer is required to refund as store credit")
+			}
+			if err := lockCustomer(tx, *layaway.CustomerID); err != nil {
+				return nil, err
+			}
+			account, err := storeCreditAccount(tx, *layaway.CustomerID)
+			if err != nil {
+				return nil, err
+			}
+			err = moveStoredValue(tx, &models.StoredValueEntry{
+				AccountID: account.ID,
+				Amount:    refunded,
+				Reason:    models.StoredValueReasonRefund,
+				Note:      fmt.Sprintf("layaway %d", id),
+				CreatedBy: meta.ActorName(),
+			})
+			if err != nil {
+				return nil, err
+			}
+			refundMethod = models.PaymentStoreCredit
+		}
+	}
+
+	if err := releaseStock(tx, layaway.OutletID, layaway.Items); err != nil {
+		return nil, err
+	}
+
+	query := `
+		UPDATE layaways
+		SET status = $1, forfeited_amount = $2, refunded_amount = $3, refund_method = $4, cancel_reason = $5, cancelled_at = NOW()
+		WHERE id = $6`
+	_, err = tx.Exec(query, models.LayawayCancelled, forfeited, refunded, refundMethod, strings.TrimSpace(req.Reason), id)
+	if err != nil {
+		return nil, err
+	}
+
+	after, err := getLayaway(tx, id, "")
+	if err != nil {
+		return nil, err
+	}
+	if err := insertAuditLog(tx, meta, models.AuditActionCancel, models.AuditEntityLayaway, id, layaway, after); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return getLayaway(repo.db, id, "")
+}
+
+// GetReport sums up layaways. Deposits held and the balance due are for
+// the layaways open now; the rest is over the period.
+func (repo *LayawayRepository) GetReport(filter models.LayawayReportFilter) (*models.LayawayReport, error) {
+	var report models.LayawayReport
+
+	outlet := func(args []interface{}) ([]interface{}, string) {
+		if filter.OutletID == 0 {
+			return args, ""
+		}
+		args = append(args, filter.OutletID)
+		return args, fmt.Sprintf(" AND l.outlet_id = $%d", len(args))
+	}
+	period := func(column string) ([]interface{}, string) {
+		args, where := outlet([]interface{}{})
+		if filter.From != nil {
+			args = append(args, *filter.From)
+			where += fmt.Sprintf(" AND %s >= $%d", column, len(args))
+		}
+		if filter.To != nil {
+			args = append(args, *filter.To)
+			where += fmt.Sprintf(" AND %s < $%d", column, len(args))
+		}
+		return args, where
+	}
+
+	args, where := outlet([]interface{}{models.LayawayOpen})
+	err := repo.db.QueryRow("SELECT COUNT(*), COALESCE(SUM(l.paid_amount), 0), COALESCE(SUM(l.total_amount - l.paid_amount), 0) FROM layaways l WHERE l.status = $1"+where, args...).Scan(
+		&report.OpenLayaways, &report.DepositsHeld, &report.BalanceDue)
+	if err != nil {
+		return nil, err
+	}
+
+	args, where = period("lp.created_at")
+	err = repo.db.QueryRow("SELECT COALESCE(SUM(lp.amount), 0) FROM layaway_payments lp JOIN layaways l ON l.id = lp.layaway_id WHERE 1 = 1"+where, args...).Scan(&report.DepositsReceived)
+	if err != nil {
+		return nil, err
+	}
+
+	args, where = period("l.completed_at")
+	args = append(args, models.LayawayCompleted)
+	err = repo.db.QueryRow(fmt.Sprintf("SELECT COUNT(*), COALESCE(SUM(l.total_amount), 0) FROM layaways l WHERE l.status = $%d", len(args))+where, args...).Scan(
+		&report.Completed, &report.RevenueRecognized)
+	if err != nil {
+		return nil, err
+	}
+
+	args, where = period("l.cancelled_at")
+	args = append(args, models.LayawayCancelled)
+	err = repo.db.QueryRow(fmt.Sprintf("SELECT COUNT(*), COALESCE(SUM(l.forfeited_amount), 0), COALESCE(SUM(l.refunded_amount), 0) FROM layaways l WHERE l.status = $%d", len(args))+where, args...).Scan(
+		&report.Cancelled, &report.ForfeitedIncome, &report.DepositsRefunded)
+	if err != nil {
+		return nil, err
+	}
+
+	return &report, nil
+}
+
+// reserveStock sets a layaway's items aside at an outlet, in product order
+// like a checkout so the two cannot deadlock. Stock already reserved for
+// other layaways cannot be reserved again.
+func reserveStock(tx *sql.Tx, outletID int, items []models.LayawayItem) error {
+	quantities, productIDs := layawayQuantities(items)
+	for _, productID := range productIDs {
+		quantity := quantities[productID]
+		var available models.Quantity
+		err := tx.QueryRow("UPDATE outlet_stocks SET reserved = reserved + $3 WHERE outlet_id = $1 AND product_id = $2 RETURNING stock - reserved", outletID, productID, quantity).Scan(&available)
+		if err != nil && err != sql.ErrNoRows {
+			return err
+		}
+		if err == sql.ErrNoRows || available < 0 {
+			return fmt.Errorf("insufficient stock for product id %d: available %s, requested %s", productID, max(available+quantity, 0), quantity)
+		}
+	}
+	return nil
+}
+
+// releaseStock gives back what reserveStock set aside for a layaway.
+func releaseStock(tx *sql.Tx, outletID int, items []models.LayawayItem) error {
+	quantities, productIDs := layawayQuantities(items)
+	for _, productID := range productIDs {
+		_, err := tx.Exec("UPDATE outlet_stocks SET reserved = reserved - $3 WHERE outlet_id = $1 AND product_id = $2", outletID, productID, quantities[productID])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func layawayQuantities(items []models.LayawayItem) (map[int]models.Quantity, []int) {
+	quantities := make(map[int]models.Quantity)
+	productIDs := make([]int, 0, len(items))
+	for _, item := range items {
+		if _, ok := quantities[item.ProductID]; !ok {
+			productIDs = append(productIDs, item.ProductID)
+		}
+		quantities[item.ProductID] += item.Quantity
+	}
+	sort.Ints(productIDs)
+	return quantities, productIDs
+}
+
+// validateInstallment checks a payment towards a layaway. Installments are
+// paid with money, not points or stored value.
+func validateInstallment(payment *models.Payment) error {
+	switch payment.Method {
+	case models.PaymentCash, models.PaymentCard, models.PaymentQRIS, models.PaymentTransfer:
+	default:
+		return fmt.Errorf("invalid installment method %q", payment.Method)
+	}
+	if payment.Amount <= 0 {
+		return fmt.Errorf("invalid amount for %s payment", payment.Method)
+	}
+	return nil
+}
+
+func validateLayawaySettings(settings *models.LayawaySettings) error {
+	if settings.MinDepositPercent < 0 || settings.MinDepositPercent > 100 {
+		return errors.New("min_deposit_percent must be between 0 and 100")
+	}
+	if settings.TermDays <= 0 {
+		return errors.New("term_days must be positive")
+	}
+	if settings.ForfeitPercent < 0 || settings.ForfeitPercent > 100 {
+		return errors.New("forfeit_percent must be between 0 and 100")
+	}
+	if settings.ForfeitMin < 0 {
+		return errors.New("forfeit_min must not be negative")
+	}
+	if settings.ExpiredForfeitPercent < 0 || settings.ExpiredForfeitPercent > 100 {
+		return errors.New("expired_forfeit_percent must be between 0 and 100")
+	}
+	return nil
+}
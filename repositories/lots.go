@@ -0,0 +1,243 @@
+package repositories
+
+import (
+	"cashier-api/models"
+	"database/sql"
+	"fmt"
+)
+
+// moveLots keeps the lots of a lot-tracked product in step with its outlet
+// stock. Incoming units go into the lots in m.Lots, or into the product's
+// unassigned lot when none are given. Outgoing units come out of the lots in
+// m.Lots when given, otherwise first-expiry-first-out; sales never draw from
+// an expired lot. m.Lots is left holding the lots actually moved.
+func moveLots(tx *sql.Tx, m *models.StockMovement) error {
+	quantity := max(m.Quantity, -m.Quantity)
+
+	if m.Quantity < 0 && len(m.Lots) == 0 {
+		lots, err := pickLots(tx, m, quantity)
+		if err != nil {
+			return err
+		}
+		m.Lots = lots
+		return nil
+	}
+	if len(m.Lots) == 0 {
+		m.Lots = []models.LotAllocation{{Quantity: quantity}}
+	}
+
+	var total models.Quantity
+	for i := range m.Lots {
+		lot := &m.Lots[i]
+		if lot.Quantity <= 0 {
+			return fmt.Errorf("invalid lot quantity for product id %d", m.ProductID)
+		}
+		total += lot.Quantity
+
+		if m.Quantity < 0 {
+			if lot.LotID == 0 {
+				return fmt.Errorf("lot id is required to take stock of product id %d from a lot", m.ProductID)
+			}
+			query := "UPDATE lots SET remaining = remaining - $1 WHERE id = $2 AND outlet_id = $3 AND product_id = $4 AND remaining >= $1 RETURNING batch_number, TO_CHAR(expiry_date, 'YYYY-MM-DD')"
+			err := tx.QueryRow(query, lot.Quantity, lot.LotID, m.OutletID, m.ProductID).Scan(&lot.BatchNumber, &lot.ExpiryDate)
+			if err == sql.ErrNoRows {
+				return fmt.Errorf("lot id %d not found or holds less than %s", lot.LotID, lot.Quantity)
+			}
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		if lot.LotID != 0 {
+			query := "UPDATE lots SET remaining = remaining + $1 WHERE id = $2 AND outlet_id = $3 AND product_id = $4 RETURNING batch_number, TO_CHAR(expiry_date, 'YYYY-MM-DD')"
+			err := tx.QueryRow(query, lot.Quantity, lot.LotID, m.OutletID, m.ProductID).Scan(&lot.BatchNumber, &lot.ExpiryDate)
+			if err == sql.ErrNoRows {
+				return fmt.Errorf("lot id %d not found", lot.LotID)
+			}
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		// A batch received again keeps the expiry date it was first
+		// received with.
+		query := `
+			INSERT INTO lots (outlet_id, product_id, batch_number, expiry_date, remaining) VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (outlet_id, product_id, batch_number) DO UPDATE SET remaining = lots.remaining + EXCLUDED.remaining
+			RETURNING id, TO_CHAR(expiry_date, 'YYYY-MM-DD')
+		`
+		err := tx.QueryRow(query, m.OutletID, m.ProductID, lot.BatchNumber, lot.ExpiryDate, lot.Quantity).Scan(&lot.LotID, &lot.ExpiryDate)
+		if err != nil {
+			return err
+		}
+	}
+
+	if total != quantity {
+		return fmt.Errorf("lot quantities for product id %d add up to %s, not %s", m.ProductID, total, quantity)
+	}
+
+	return nil
+}
+
+// pickLots takes quantity units out of the outlet's lots, earliest expiry
+// first. Lots without an expiry date go last.
+func pickLots(tx *sql.Tx, m *models.StockMovement, quantity models.Quantity) ([]models.LotAllocation, error) {
+	query := "SELECT id, batch_number, TO_CHAR(expiry_date, 'YYYY-MM-DD'), remaining FROM lots WHERE outlet_id = $1 AND product_id = $2 AND remaining > 0"
+	if m.Reason == models.StockReasonSale {
+		query += " AND (expiry_date IS NULL OR expiry_date >= CURRENT_DATE)"
+	}
+	query += " ORDER BY expiry_date NULLS LAST, id FOR UPDATE"
+
+	rows, err := tx.Query(query, m.OutletID, m.ProductID)
+	if err != nil {
+		return nil, err
+	}
+
+	lots := make([]models.LotAllocation, 0)
+	left := quantity
+	for rows.Next() && left > 0 {
+		var lot models.LotAllocation
+		var remaining models.Quantity
+		if err := rows.Scan(&lot.LotID, &lot.BatchNumber, &lot.ExpiryDate, &remaining); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		lot.Quantity = min(left, remaining)
+		left -= lot.Quantity
+		lots = append(lots, lot)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if left > 0 {
+		if m.Reason == models.StockReasonSale {
+			return nil, fmt.Errorf("insufficient unexpired stock for product id %d: available %s, requested %s", m.ProductID, quantity-left, quantity)
+		}
+		return nil, fmt.Errorf("lots of product id %d hold less than its stock", m.ProductID)
+	}
+
+	for _, lot := range lots {
+		_, err := tx.Exec("UPDATE lots SET remaining = remaining - $1 WHERE id = $2", lot.Quantity, lot.LotID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return lots, nil
+}
+
+// setLotTracking turns lot tracking on or off for a product. Stock already on
+// hand starts out in each outlet's unassigned lot; turning tracking off
+// empties the lots but keeps them for the sales that refer to them.
+func setLotTracking(tx *sql.Tx, productID int, on bool) error {
+	_, err := tx.Exec("UPDATE lots SET remaining = 0 WHERE product_id = $1", productID)
+	if err != nil {
+		return err
+	}
+
+	if on {
+		query := `
+			INSERT INTO lots (outlet_id, product_id, batch_number, remaining)
+			SELECT outlet_id, product_id, '', stock FROM outlet_stocks WHERE product_id = $1 AND stock > 0
+			ON CONFLICT (outlet_id, product_id, batch_number) DO UPDATE SET remaining = EXCLUDED.remaining
+		`
+		if _, err := tx.Exec(query, productID); err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.Exec("UPDATE products SET track_lots = $1 WHERE id = $2", on, productID)
+	return err
+}
+
+// returnedLots lists the lots a sold line is returned into: the lots it was
+// sold from, in the order they were drawn, marked as returned. Units sold
+// before the product was lot-tracked go into the unassigned lot. Lots are
+// matched by batch number so a return at another outlet lands in the same
+// batch there.
+func returnedLots(tx *sql.Tx, transactionDetailID int, quantity models.Quantity) ([]models.LotAllocation, error) {
+	rows, err := tx.Query(`
+		SELECT tdl.id, l.batch_number, TO_CHAR(l.expiry_date, 'YYYY-MM-DD'), tdl.quantity - tdl.returned_quantity
+		FROM transaction_detail_lots tdl
+		JOIN lots l ON l.id = tdl.lot_id
+		WHERE tdl.transaction_detail_id = $1 AND tdl.quantity > tdl.returned_quantity
+		ORDER BY tdl.id
+		FOR UPDATE OF tdl
+	`, transactionDetailID)
+	if err != nil {
+		return nil, err
+	}
+
+	type drawn struct {
+		id  int
+		lot models.LotAllocation
+	}
+	draws := make([]drawn, 0)
+	left := quantity
+	for rows.Next() && left > 0 {
+		var d drawn
+		var open models.Quantity
+		if err := rows.Scan(&d.id, &d.lot.BatchNumber, &d.lot.ExpiryDate, &open); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		d.lot.Quantity = min(left, open)
+		left -= d.lot.Quantity
+		draws = append(draws, d)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	lots := make([]models.LotAllocation, 0, len(draws)+1)
+	for _, d := range draws {
+		_, err := tx.Exec("UPDATE transaction_detail_lots SET returned_quantity = returned_quantity + $1 WHERE id = $2", d.lot.Quantity, d.id)
+		if err != nil {
+			return nil, err
+		}
+		lots = append(lots, d.lot)
+	}
+	if left > 0 {
+		lots = append(lots, models.LotAllocation{Quantity: left})
+	}
+
+	return lots, nil
+}
+
+// transferLots lists the lots quantity units of a transfer line arrive in:
+// the lots they were dispatched from, earliest expiry first, with anything
+// beyond the dispatched quantity in the unassigned lot.
+func transferLots(tx *sql.Tx, stockTransferLineID int, quantity models.Quantity) ([]models.LotAllocation, error) {
+	rows, err := tx.Query(`
+		SELECT batch_number, TO_CHAR(expiry_date, 'YYYY-MM-DD'), quantity
+		FROM stock_transfer_line_lots
+		WHERE stock_transfer_line_id = $1
+		ORDER BY expiry_date NULLS LAST, id
+	`, stockTransferLineID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lots := make([]models.LotAllocation, 0)
+	left := quantity
+	for rows.Next() && left > 0 {
+		var lot models.LotAllocation
+		if err := rows.Scan(&lot.BatchNumber, &lot.ExpiryDate, &lot.Quantity); err != nil {
+			return nil, err
+		}
+		lot.Quantity = min(left, lot.Quantity)
+		left -= lot.Quantity
+		lots = append(lots, lot)
+	}
+	if left > 0 && quantity > 0 {
+		lots = append(lots, models.LotAllocation{Quantity: left})
+	}
+
+	return lots, rows.Err()
+}
@@ -0,0 +1,272 @@
+package repositories
+
+import (
+	"cashier-api/models"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+func getLoyaltySettings(db queryer) (*models.LoyaltySettings, error) {
+	var settings models.LoyaltySettings
+	err := db.QueryRow("SELECT enabled, spend_per_point, point_value, min_redeem, expiry_days, updated_at FROM loyalty_settings WHERE id = 1").Scan(
+		&settings.Enabled, &settings.SpendPerPoint, &settings.PointValue, &settings.MinRedeem, &settings.ExpiryDays, &settings.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+func loadLoyaltyRules(db queryer, settings *models.LoyaltySettings) error {
+	rows, err := db.Query(`
+		SELECT lr.category_id, lr.product_id, COALESCE(p.name, c.name, ''), lr.multiplier
+		FROM loyalty_rules lr
+		LEFT JOIN categories c ON c.id = lr.category_id
+		LEFT JOIN products p ON p.id = lr.product_id
+		ORDER BY lr.product_id NULLS FIRST, lr.category_id, lr.id
+	`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	settings.Rules = make([]models.LoyaltyRule, 0)
+	for rows.Next() {
+		var rule models.LoyaltyRule
+		if err := rows.Scan(&rule.CategoryID, &rule.ProductID, &rule.Name, &rule.Multiplier); err != nil {
+			return err
+		}
+		settings.Rules = append(settings.Rules, rule)
+	}
+	return rows.Err()
+}
+
+// lockCustomer locks a customer's row, which serialises every change to
+// their points balance.
+func lockCustomer(tx *sql.Tx, customerID int) error {
+	err := tx.QueryRow("SELECT id FROM customers WHERE id = $1 FOR UPDATE", customerID).Scan(&customerID)
+	if err == sql.ErrNoRows {
+		return errors.New("Customer not found")
+	}
+	return err
+}
+
+// expirePoints writes off what is left of a customer's lots that have
+// expired. Expiry is applied lazily, whenever the balance is about to be
+// read or used, so it needs no scheduled job. The customer must be locked.
+func expirePoints(tx *sql.Tx, customerID int, actor string) error {
+	rows, err := tx.Query(`
+		UPDATE loyalty_ledger SET remaining = 0
+		FROM (
+			SELECT id, remaining FROM loyalty_ledger
+			WHERE customer_id = $1 AND remaining > 0 AND expires_at <= NOW()
+		) expired
+		WHERE loyalty_ledger.id = expired.id
+		RETURNING expired.remaining, loyalty_ledger.expires_at
+	`, customerID)
+	if err != nil {
+		return err
+	}
+
+	type lot struct {
+		points    int
+		expiresAt sql.NullTime
+	}
+	lots := make([]lot, 0)
+	for rows.Next() {
+		var l lot
+		if err := rows.Scan(&l.points, &l.expiresAt); err != nil {
+			rows.Close()
+			return err
+		}
+		lots = append(lots, l)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, l := range lots {
+		_, err := tx.Exec("INSERT INTO loyalty_ledger (customer_id, points, reason, note, created_by) VALUES ($1, $2, $3, $4, $5)",
+			customerID, -l.points, models.PointsReasonExpire, "expired "+l.expiresAt.Time.In(time.Local).Format(models.DateTimeLayout), actor)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func pointsBalance(tx *sql.Tx, customerID int) (int, error) {
+	var balance int
+	err := tx.QueryRow("SELECT COALESCE(SUM(points), 0) FROM loyalty_ledger WHERE customer_id = $1", customerID).Scan(&balance)
+	return balance, err
+}
+
+// addPoints writes a ledger entry that adds points, as a lot that expires
+// expiryDays from now, or never when expiryDays is 0.
+func addPoints(tx *sql.Tx, entry *models.PointsEntry, expiryDays int) error {
+	query := `
+		INSERT INTO loyalty_ledger (customer_id, points, reason, transaction_id, refund_id, note, expires_at, remaining, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, CASE WHEN $7::INT > 0 THEN NOW() + MAKE_INTERVAL(days => $7::INT) END, $2, $8)
+		RETURNING id, expires_at, created_at`
+	return tx.QueryRow(query, entry.CustomerID, entry.Points, entry.Reason, entry.TransactionID, entry.RefundID, entry.Note, expiryDays, entry.CreatedBy).Scan(&entry.ID, &entry.ExpiresAt, &entry.CreatedAt)
+}
+
+// spendPoints writes a ledger entry that takes points off, using up the
+// customer's lots soonest to expire first. The customer must be locked and
+// hold at least the points taken.
+func spendPoints(tx *sql.Tx, entry *models.PointsEntry) error {
+	rows, err := tx.Query("SELECT id, remaining FROM loyalty_ledger WHERE customer_id = $1 AND remaining > 0 ORDER BY expires_at NULLS LAST, id", entry.CustomerID)
+	if err != nil {
+		return err
+	}
+
+	type lot struct {
+		id, remaining int
+	}
+	lots := make([]lot, 0)
+	for rows.Next() {
+		var l lot
+		if err := rows.Scan(&l.id, &l.remaining); err != nil {
+			rows.Close()
+			return err
+		}
+		lots = append(lots, l)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	left := -entry.Points
+	for _, l := range lots {
+		if left == 0 {
+			break
+		}
+		used := min(l.remaining, left)
+		if _, err := tx.Exec("UPDATE loyalty_ledger SET remaining = remaining - $1 WHERE id = $2", used, l.id); err != nil {
+			return err
+		}
+		left -= used
+	}
+	if left > 0 {
+		return fmt.Errorf("customer has only %d points", -entry.Points-left)
+	}
+
+	query := `
+		INSERT INTO loyalty_ledger (customer_id, points, reason, transaction_id, refund_id, note, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at`
+	return tx.QueryRow(query, entry.CustomerID, entry.Points, entry.Reason, entry.TransactionID, entry.RefundID, entry.Note, entry.CreatedBy).Scan(&entry.ID, &entry.CreatedAt)
+}
+
+// checkRedemption makes sure a customer can redeem points in a sale: the
+// programme is on, the points reach its minimum and the customer holds
+// them. The customer must be locked and their expired points written off.
+func checkRedemption(tx *sql.Tx, customerID *int, points int, settings *models.LoyaltySettings) error {
+	if customerID == nil {
+		return errors.New("A customer is required to redeem points")
+	}
+	if !settings.Enabled {
+		return errors.New("Loyalty programme is not enabled")
+	}
+	if points < settings.MinRedeem {
+		return fmt.Errorf("at least %d points must be redeemed at a time", settings.MinRedeem)
+	}
+	balance, err := pointsBalance(tx, *customerID)
+	if err != nil {
+		return err
+	}
+	if points > balance {
+		return fmt.Errorf("customer has only %d points", balance)
+	}
+	return nil
+}
+
+// redeemAsDiscount takes points worth of discount off a sale's lines, in
+// proportion to their subtotals, and returns the discount. Gift cards are
+// not discounted: they load the full amount they were sold for.
+func redeemAsDiscount(details []models.TransactionDetail, points int, settings *models.LoyaltySettings) (int, error) {
+	if !settings.Enabled {
+		return 0, errors.New("Loyalty programme is not enabled")
+	}
+	total := 0
+	weights := make([]int, len(details))
+	for i, detail := range details {
+		if len(detail.GiftCards) > 0 {
+			continue
+		}
+		weights[i] = detail.Subtotal
+		total += detail.Subtotal
+	}
+	discount := points * settings.PointValue
+	if discount > total {
+		return 0, fmt.Errorf("%d points are worth %d, more than the %d they can be redeemed against", points, discount, total)
+	}
+
+	for i, share := range models.AllocateBundlePrice(discount, weights) {
+		details[i].DiscountAmount = share
+		details[i].Subtotal -= share
+	}
+	return discount, nil
+}
+
+// earnPoints works out the points a sale earns and shares them out over its
+// lines. Each line's subtotal is weighted by its loyalty rule, and the part
+// of the sale paid with points earns nothing. Gift cards earn nothing when
+// bought; what is bought with them earns.
+func earnPoints(tx *sql.Tx, details []models.TransactionDetail, total, paidWithPoints int, settings *models.LoyaltySettings) (int, error) {
+	productIDs := make([]int, 0, len(details))
+	for _, detail := range details {
+		productIDs = append(productIDs, detail.ProductID)
+	}
+	rows, err := tx.Query(`
+		SELECT p.id, COALESCE(pr.multiplier, cr.multiplier, 1)
+		FROM products p
+		LEFT JOIN loyalty_rules pr ON pr.product_id = p.id
+		LEFT JOIN loyalty_rules cr ON cr.category_id = p.category_id
+		WHERE p.id = ANY ($1)
+	`, pq.Array(productIDs))
+	if err != nil {
+		return 0, err
+	}
+	multipliers := make(map[int]models.Quantity)
+	for rows.Next() {
+		var productID int
+		var multiplier models.Quantity
+		if err := rows.Scan(&productID, &multiplier); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		multipliers[productID] = multiplier
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	spend := 0
+	weights := make([]int, len(details))
+	for i, detail := range details {
+		if len(detail.GiftCards) > 0 {
+			continue
+		}
+		weights[i] = multipliers[detail.ProductID].Times(detail.Subtotal)
+		spend += weights[i]
+	}
+	if total > 0 {
+		spend = int(int64(spend) * int64(total-paidWithPoints) / int64(total))
+	}
+
+	earned := spend / settings.SpendPerPoint
+	if earned <= 0 {
+		return 0, nil
+	}
+	for i, share := range models.AllocateBundlePrice(earned, weights) {
+		details[i].PointsEarned = share
+	}
+	return earned, nil
+}
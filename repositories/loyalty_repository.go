@@ -0,0 +1,289 @@
+package repositories
+
+import (
+	"cashier-api/models"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+type LoyaltyRepository struct {
+	db *sql.DB
+}
+
+func NewLoyaltyRepository(db *sql.DB) *LoyaltyRepository {
+	return &LoyaltyRepository{db: db}
+}
+
+func (repo *LoyaltyRepository) GetSettings() (*models.LoyaltySettings, error) {
+	settings, err := getLoyaltySettings(repo.db)
+	if err != nil {
+		return nil, err
+	}
+	if err := loadLoyaltyRules(repo.db, settings); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// UpdateSettings replaces the programme's settings and its earning rules.
+// Changes apply to sales from now on; points already earned keep their
+// expiry.
+func (repo *LoyaltyRepository) UpdateSettings(settings *models.LoyaltySettings, meta models.RequestMeta) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	before, err := getLoyaltySettings(tx)
+	if err != nil {
+		return err
+	}
+	if err := loadLoyaltyRules(tx, before); err != nil {
+		return err
+	}
+
+	if err := validateLoyaltySettings(tx, settings); err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE loyalty_settings
+		SET enabled = $1, spend_per_point = $2, point_value = $3, min_redeem = $4, expiry_days = $5, updated_at = NOW()
+		WHERE id = 1
+		RETURNING updated_at`
+	err = tx.QueryRow(query, settings.Enabled, settings.SpendPerPoint, settings.PointValue, settings.MinRedeem, settings.ExpiryDays).Scan(&settings.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM loyalty_rules"); err != nil {
+		return err
+	}
+	for _, rule := range settings.Rules {
+		_, err := tx.Exec("INSERT INTO loyalty_rules (category_id, product_id, multiplier) VALUES ($1, $2, $3)", rule.CategoryID, rule.ProductID, rule.Multiplier)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = insertAuditLog(tx, meta, models.AuditActionUpdate, models.AuditEntityLoyaltySettings, 1, before, settings)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetAccount returns a customer's points balance, with the latest limit
+// ledger entries. Points that have expired are written off first.
+func (repo *LoyaltyRepository) GetAccount(customerID, limit int, meta models.RequestMeta) (*models.PointsAccount, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	if limit > 500 {
+		limit = 500
+	}
+
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := lockCustomer(tx, customerID); err != nil {
+		return nil, err
+	}
+	if err := expirePoints(tx, customerID, meta.ActorName()); err != nil {
+		return nil, err
+	}
+
+	account, err := pointsAccount(tx, customerID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+// Adjust adds points to a customer's balance or removes them, such as for
+// a goodwill gesture or to correct a mistake. Removing more points than the
+// customer holds is refused.
+func (repo *LoyaltyRepository) Adjust(customerID int, adjustment models.PointsAdjustment, meta models.RequestMeta) (*models.PointsEntry, error) {
+	if adjustment.Points == 0 {
+		return nil, errors.New("Points must not be zero")
+	}
+
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := lockCustomer(tx, customerID); err != nil {
+		return nil, err
+	}
+	if err := expirePoints(tx, customerID, meta.ActorName()); err != nil {
+		return nil, err
+	}
+
+	entry := models.PointsEntry{
+		CustomerID: customerID,
+		Points:     adjustment.Points,
+		Reason:     models.PointsReasonAdjust,
+		Note:       adjustment.Note,
+		CreatedBy:  meta.ActorName(),
+	}
+	if entry.Points > 0 {
+		settings, err := getLoyaltySettings(tx)
+		if err != nil {
+			return nil, err
+		}
+		if err := addPoints(tx, &entry, settings.ExpiryDays); err != nil {
+			return nil, err
+		}
+	} else {
+		balance, err := pointsBalance(tx, customerID)
+		if err != nil {
+			return nil, err
+		}
+		if -entry.Points > balance {
+			return nil, fmt.Errorf("customer has only %d points", balance)
+		}
+		if err := spendPoints(tx, &entry); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func pointsAccount(tx *sql.Tx, customerID, limit int) (*models.PointsAccount, error) {
+	account := models.PointsAccount{
+		CustomerID: customerID,
+		Expiring:   make([]models.PointsExpiry, 0),
+		Ledger:     make([]models.PointsEntry, 0),
+	}
+
+	balance, err := pointsBalance(tx, customerID)
+	if err != nil {
+		return nil, err
+	}
+	settings, err := getLoyaltySettings(tx)
+	if err != nil {
+		return nil, err
+	}
+	account.Balance = balance
+	account.Value = balance * settings.PointValue
+
+	rows, err := tx.Query(`
+		SELECT expires_at, SUM(remaining)
+		FROM loyalty_ledger
+		WHERE customer_id = $1 AND remaining > 0 AND expires_at IS NOT NULL
+		GROUP BY expires_at
+		ORDER BY expires_at
+	`, customerID)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var expiry models.PointsExpiry
+		if err := rows.Scan(&expiry.ExpiresAt, &expiry.Points); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		account.Expiring = append(account.Expiring, expiry)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = tx.Query(`
+		SELECT id, customer_id, points, reason, transaction_id, refund_id, note, expires_at, created_by, created_at
+		FROM loyalty_ledger
+		WHERE customer_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2
+	`, customerID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var entry models.PointsEntry
+		if err := rows.Scan(&entry.ID, &entry.CustomerID, &entry.Points, &entry.Reason, &entry.TransactionID, &entry.RefundID, &entry.Note, &entry.ExpiresAt, &entry.CreatedBy, &entry.CreatedAt); err != nil {
+			return nil, err
+		}
+		account.Ledger = append(account.Ledger, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// validateLoyaltySettings checks the programme's settings and that each rule
+// names exactly one category or product, at most once.
+func validateLoyaltySettings(tx *sql.Tx, settings *models.LoyaltySettings) error {
+	if settings.SpendPerPoint <= 0 {
+		return errors.New("spend_per_point must be positive")
+	}
+	if settings.PointValue <= 0 {
+		return errors.New("point_value must be positive")
+	}
+	if settings.MinRedeem < 0 {
+		return errors.New("min_redeem must not be negative")
+	}
+	if settings.ExpiryDays < 0 {
+		return errors.New("expiry_days must not be negative")
+	}
+
+	if settings.Rules == nil {
+		settings.Rules = make([]models.LoyaltyRule, 0)
+	}
+	categories := make(map[int]bool)
+	products := make(map[int]bool)
+	for i := range settings.Rules {
+		rule := &settings.Rules[i]
+		if rule.Multiplier < 0 {
+			return errors.New("multiplier must not be negative")
+		}
+		switch {
+		case rule.CategoryID != nil && rule.ProductID == nil:
+			if categories[*rule.CategoryID] {
+				return fmt.Errorf("category id %d has more than one rule", *rule.CategoryID)
+			}
+			categories[*rule.CategoryID] = true
+			err := tx.QueryRow("SELECT name FROM categories WHERE id = $1", *rule.CategoryID).Scan(&rule.Name)
+			if err == sql.ErrNoRows {
+				return fmt.Errorf("category id %d not found", *rule.CategoryID)
+			}
+			if err != nil {
+				return err
+			}
+		case rule.ProductID != nil && rule.CategoryID == nil:
+			if products[*rule.ProductID] {
+				return fmt.Errorf("product id %d has more than one rule", *rule.ProductID)
+			}
+			products[*rule.ProductID] = true
+			err := tx.QueryRow("SELECT name FROM products WHERE id = $1", *rule.ProductID).Scan(&rule.Name)
+			if err == sql.ErrNoRows {
+				return fmt.Errorf("product id %d not found", *rule.ProductID)
+			}
+			if err != nil {
+				return err
+			}
+		default:
+			return errors.New("each rule needs either a category_id or a product_id")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,371 @@
+package repositories
+
+import (
+	"cashier-api/models"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+type ModifierRepository struct {
+	db *sql.DB
+}
+
+func NewModifierRepository(db *sql.DB) *ModifierRepository {
+	return &ModifierRepository{db: db}
+}
+
+func (repo *ModifierRepository) GetAll() ([]models.ModifierGroup, error) {
+	rows, err := repo.db.Query("SELECT id, name, min_select, max_select FROM modifier_groups ORDER BY name, id")
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make([]models.ModifierGroup, 0)
+	for rows.Next() {
+		var group models.ModifierGroup
+		if err := rows.Scan(&group.ID, &group.Name, &group.MinSelect, &group.MaxSelect); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		groups = append(groups, group)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range groups {
+		groups[i].Modifiers, err = getModifiers(repo.db, groups[i].ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return groups, nil
+}
+
+func (repo *ModifierRepository) GetByID(id int) (*models.ModifierGroup, error) {
+	return getModifierGroup(repo.db, id, "")
+}
+
+func (repo *ModifierRepository) Create(group *models.ModifierGroup, meta models.RequestMeta) error {
+	if err := validateModifierGroup(group); err != nil {
+		return err
+	}
+
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := "INSERT INTO modifier_groups (name, min_select, max_select) VALUES ($1, $2, $3) RETURNING id"
+	err = tx.QueryRow(query, group.Name, group.MinSelect, group.MaxSelect).Scan(&group.ID)
+	if err != nil {
+		return err
+	}
+
+	if err := saveModifiers(tx, group); err != nil {
+		return err
+	}
+
+	err = insertAuditLog(tx, meta, models.AuditActionCreate, models.AuditEntityModifierGroup, group.ID, nil, group)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Update replaces a group's settings and modifiers. Modifiers given with an
+// ID are updated in place, those without are added and the rest removed.
+func (repo *ModifierRepository) Update(group *models.ModifierGroup, meta models.RequestMeta) error {
+	if err := validateModifierGroup(group); err != nil {
+		return err
+	}
+
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	before, err := getModifierGroup(tx, group.ID, "FOR UPDATE")
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("UPDATE modifier_groups SET name = $1, min_select = $2, max_select = $3 WHERE id = $4", group.Name, group.MinSelect, group.MaxSelect, group.ID)
+	if err != nil {
+		return err
+	}
+
+	known := make(map[int]bool, len(before.Modifiers))
+	for _, modifier := range before.Modifiers {
+		known[modifier.ID] = true
+	}
+	keep := make([]int64, 0, len(group.Modifiers))
+	for _, modifier := range group.Modifiers {
+		if modifier.ID != 0 && !known[modifier.ID] {
+			return fmt.Errorf("modifier id %d not found in modifier group %d", modifier.ID, group.ID)
+		}
+		if modifier.ID != 0 {
+			keep = append(keep, int64(modifier.ID))
+		}
+	}
+	_, err = tx.Exec("DELETE FROM modifiers WHERE modifier_group_id = $1 AND NOT (id = ANY ($2))", group.ID, pq.Array(keep))
+	if err != nil {
+		return err
+	}
+
+	if err := saveModifiers(tx, group); err != nil {
+		return err
+	}
+
+	err = insertAuditLog(tx, meta, models.AuditActionUpdate, models.AuditEntityModifierGroup, group.ID, before, group)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (repo *ModifierRepository) Delete(id int, meta models.RequestMeta) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	before, err := getModifierGroup(tx, id, "FOR UPDATE")
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("DELETE FROM modifier_groups WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	err = insertAuditLog(tx, meta, models.AuditActionDelete, models.AuditEntityModifierGroup, id, before, nil)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetProductGroups lists the modifier groups offered with a product, in the
+// order they are shown.
+func (repo *ModifierRepository) GetProductGroups(productID int) ([]models.ModifierGroup, error) {
+	return getProductModifierGroups(repo.db, productID)
+}
+
+// SetProductGroups replaces the modifier groups offered with a product.
+func (repo *ModifierRepository) SetProductGroups(productID int, groupIDs []int) ([]models.ModifierGroup, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := getProductForUpdate(tx, productID); err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec("DELETE FROM product_modifier_groups WHERE product_id = $1", productID)
+	if err != nil {
+		return nil, err
+	}
+
+	for position, groupID := range groupIDs {
+		if _, err := getModifierGroup(tx, groupID, ""); err != nil {
+			return nil, err
+		}
+		_, err = tx.Exec("INSERT INTO product_modifier_groups (product_id, modifier_group_id, position) VALUES ($1, $2, $3)", productID, groupID, position)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	groups, err := getProductModifierGroups(tx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return groups, nil
+}
+
+func validateModifierGroup(group *models.ModifierGroup) error {
+	if group.Name == "" {
+		return errors.New("Name is required")
+	}
+	if group.MaxSelect == 0 {
+		group.MaxSelect = 1
+	}
+	if group.MinSelect < 0 || group.MinSelect > group.MaxSelect {
+		return errors.New("min_select must be between 0 and max_select")
+	}
+	if len(group.Modifiers) == 0 {
+		return errors.New("Modifier group has no modifiers")
+	}
+	for _, modifier := range group.Modifiers {
+		if modifier.Name == "" {
+			return errors.New("Modifier name is required")
+		}
+		if modifier.Quantity < 0 || (modifier.ProductID != nil) != (modifier.Quantity > 0) {
+			return fmt.Errorf("modifier %q needs both product_id and a positive quantity, or neither", modifier.Name)
+		}
+	}
+	return nil
+}
+
+// saveModifiers inserts new modifiers and updates existing ones, filling in
+// their IDs.
+func saveModifiers(tx *sql.Tx, group *models.ModifierGroup) error {
+	for i := range group.Modifiers {
+		modifier := &group.Modifiers[i]
+		modifier.ModifierGroupID = group.ID
+
+		if modifier.ID != 0 {
+			query := "UPDATE modifiers SET name = $1, price = $2, product_id = $3, quantity = $4 WHERE id = $5"
+			_, err := tx.Exec(query, modifier.Name, modifier.Price, modifier.ProductID, modifier.Quantity, modifier.ID)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		query := "INSERT INTO modifiers (modifier_group_id, name, price, product_id, quantity) VALUES ($1, $2, $3, $4, $5) RETURNING id"
+		err := tx.QueryRow(query, group.ID, modifier.Name, modifier.Price, modifier.ProductID, modifier.Quantity).Scan(&modifier.ID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getModifierGroup loads a group with its modifiers. lock is an optional row
+// locking clause for the group.
+func getModifierGroup(db queryer, id int, lock string) (*models.ModifierGroup, error) {
+	var group models.ModifierGroup
+	err := db.QueryRow("SELECT id, name, min_select, max_select FROM modifier_groups WHERE id = $1 "+lock, id).Scan(&group.ID, &group.Name, &group.MinSelect, &group.MaxSelect)
+	if err == sql.ErrNoRows {
+		return nil, errors.New("Modifier group not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	group.Modifiers, err = getModifiers(db, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &group, nil
+}
+
+func getModifiers(db queryer, groupID int) ([]models.Modifier, error) {
+	rows, err := db.Query("SELECT id, modifier_group_id, name, price, product_id, quantity FROM modifiers WHERE modifier_group_id = $1 ORDER BY id", groupID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	modifiers := make([]models.Modifier, 0)
+	for rows.Next() {
+		var modifier models.Modifier
+		if err := rows.Scan(&modifier.ID, &modifier.ModifierGroupID, &modifier.Name, &modifier.Price, &modifier.ProductID, &modifier.Quantity); err != nil {
+			return nil, err
+		}
+		modifiers = append(modifiers, modifier)
+	}
+
+	return modifiers, rows.Err()
+}
+
+func getProductModifierGroups(db queryer, productID int) ([]models.ModifierGroup, error) {
+	rows, err := db.Query(`
+		SELECT g.id
+		FROM product_modifier_groups pmg
+		JOIN modifier_groups g ON g.id = pmg.modifier_group_id
+		WHERE pmg.product_id = $1
+		ORDER BY pmg.position, g.id
+	`, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int, 0)
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	groups := make([]models.ModifierGroup, 0, len(ids))
+	for _, id := range ids {
+		group, err := getModifierGroup(db, id, "")
+		if err != nil {
+			return nil, err
+		}
+		groups = append(groups, *group)
+	}
+
+	return groups, nil
+}
+
+// chooseModifiers checks the modifiers picked for a checkout item against the
+// groups offered with its product and returns them in the order given. Every
+// group must end up with between its minimum and maximum number of choices.
+func chooseModifiers(tx *sql.Tx, item models.CheckoutItem) ([]models.Modifier, error) {
+	groups, err := getProductModifierGroups(tx, item.ProductID)
+	if err != nil {
+		return nil, err
+	}
+
+	offered := make(map[int]models.Modifier)
+	for _, group := range groups {
+		for _, modifier := range group.Modifiers {
+			offered[modifier.ID] = modifier
+		}
+	}
+
+	modifiers := make([]models.Modifier, 0, len(item.ModifierIDs))
+	picked := make(map[int]int)
+	seen := make(map[int]bool)
+	for _, id := range item.ModifierIDs {
+		modifier, ok := offered[id]
+		if !ok {
+			return nil, fmt.Errorf("modifier id %d is not offered with product id %d", id, item.ProductID)
+		}
+		if seen[id] {
+			return nil, fmt.Errorf("modifier id %d chosen more than once for product id %d", id, item.ProductID)
+		}
+		seen[id] = true
+		picked[modifier.ModifierGroupID]++
+		modifiers = append(modifiers, modifier)
+	}
+
+	for _, group := range groups {
+		if n := picked[group.ID]; n < group.MinSelect || n > group.MaxSelect {
+			return nil, fmt.Errorf("choose between %d and %d of %s for product id %d", group.MinSelect, group.MaxSelect, group.Name, item.ProductID)
+		}
+	}
+
+	return modifiers, nil
+}
@@ -0,0 +1,750 @@
+package repositories
+
+import (
+	"cashier-api/models"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+type OrderRepository struct {
+	db *sql.DB
+}
+
+func NewOrderRepository(db *sql.DB) *OrderRepository {
+	return &OrderRepository{db: db}
+}
+
+const orderColumns = `o.id, o.outlet_id, o.table_name, o.customer_id, COALESCE(c.name, ''), o.status, o.split_mode, o.total_amount,
+	(SELECT COALESCE(SUM(os.amount), 0) FROM order_settlements os WHERE os.order_id = o.id AND os.status = 'paid'), o.note,
+	o.transaction_id, o.cancel_reason, o.created_by, o.created_at, o.split_at, o.settled_at, o.cancelled_at`
+
+func scanOrder(row rowScanner, order *models.Order) error {
+	err := row.Scan(&order.ID, &order.OutletID, &order.TableName, &order.CustomerID, &order.CustomerName, &order.Status, &order.SplitMode, &order.TotalAmount, &order.PaidAmount, &order.Note,
+		&order.TransactionID, &order.CancelReason, &order.CreatedBy, &order.CreatedAt, &order.SplitAt, &order.SettledAt, &order.CancelledAt)
+	order.Balance = order.TotalAmount - order.PaidAmount
+	return err
+}
+
+// GetAll lists orders, newest first. Orders still open are listed without
+// their bill, which is only priced when a single order is loaded.
+func (repo *OrderRepository) GetAll(filter models.OrderFilter) ([]models.Order, error) {
+	query := "SELECT " + orderColumns + " FROM orders o LEFT JOIN customers c ON c.id = o.customer_id WHERE 1 = 1"
+	args := []interface{}{}
+
+	if filter.Status != "" {
+		switch filter.Status {
+		case models.OrderOpen, models.OrderSplit, models.OrderSettled, models.OrderCancelled:
+		default:
+			return nil, fmt.Errorf("invalid status %q", filter.Status)
+		}
+		args = append(args, filter.Status)
+		query += fmt.Sprintf(" AND o.status = $%d", len(args))
+	}
+	if filter.OutletID != 0 {
+		args = append(args, filter.OutletID)
+		query += fmt.Sprintf(" AND o.outlet_id = $%d", len(args))
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+	if limit > 500 {
+		limit = 500
+	}
+	args = append(args, limit)
+	query += fmt.Sprintf(" ORDER BY o.created_at DESC, o.id DESC LIMIT $%d", len(args))
+
+	rows, err := repo.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	orders := make([]models.Order, 0)
+	for rows.Next() {
+		var order models.Order
+		if err := scanOrder(rows, &order); err != nil {
+			return nil, err
+		}
+		orders = append(orders, order)
+	}
+	return orders, rows.Err()
+}
+
+// GetByID loads an order. The bill of an open order is priced at today's
+// prices, as it would be if it were split now.
+func (repo *OrderRepository) GetByID(id int) (*models.Order, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	order, err := getOrder(tx, id, "")
+	if err != nil {
+		return nil, err
+	}
+	if order.Status == models.OrderOpen && len(order.Items) > 0 {
+		if _, err := priceOrder(tx, order); err != nil {
+			return nil, err
+		}
+		order.Balance = order.TotalAmount
+	}
+	return order, nil
+}
+
+// getOrder loads an order with its items and settlements. lock is an
+// optional row locking clause such as "FOR UPDATE".
+func getOrder(db queryer, id int, lock string) (*models.Order, error) {
+	var order models.Order
+	if lock != "" {
+		lock += " OF o"
+	}
+	err := scanOrder(db.QueryRow("SELECT "+orderColumns+" FROM orders o LEFT JOIN customers c ON c.id = o.customer_id WHERE o.id = $1 "+lock, id), &order)
+	if err == sql.ErrNoRows {
+		return nil, errors.New("Order not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query(`
+		SELECT id, COALESCE(product_id, 0), barcode, COALESCE(bundle_id, 0), quantity, unit, modifier_ids, name, subtotal, settlement_id, created_by, created_at
+		FROM order_items
+		WHERE order_id = $1
+		ORDER BY id
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	order.Items = make([]models.OrderItem, 0)
+	for rows.Next() {
+		var item models.OrderItem
+		var modifierIDs pq.Int64Array
+		err := rows.Scan(&item.ID, &item.ProductID, &item.Barcode, &item.BundleID, &item.Quantity, &item.Unit, &modifierIDs, &item.Name, &item.Subtotal, &item.SettlementID, &item.CreatedBy, &item.CreatedAt)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		for _, modifierID := range modifierIDs {
+			item.ModifierIDs = append(item.ModifierIDs, int(modifierID))
+		}
+		order.Items = append(order.Items, item)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = db.Query("SELECT id, amount, change_amount, status, paid_by, paid_at FROM order_settlements WHERE order_id = $1 ORDER BY id", id)
+	if err != nil {
+		return nil, err
+	}
+	order.Settlements = make([]models.OrderSettlement, 0)
+	shares := make(map[int]int)
+	for rows.Next() {
+		var settlement models.OrderSettlement
+		if err := rows.Scan(&settlement.ID, &settlement.Amount, &settlement.Change, &settlement.Status, &settlement.PaidBy, &settlement.PaidAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		settlement.Payments = make([]models.Payment, 0)
+		shares[settlement.ID] = len(order.Settlements)
+		order.Settlements = append(order.Settlements, settlement)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for _, item := range order.Items {
+		if item.SettlementID != nil {
+			settlement := &order.Settlements[shares[*item.SettlementID]]
+			settlement.ItemIDs = append(settlement.ItemIDs, item.ID)
+		}
+	}
+
+	rows, err = db.Query(`
+		SELECT tp.order_settlement_id, tp.id, tp.method, tp.amount, tp.points, tp.account_id, COALESCE(sva.code, '')
+		FROM transaction_payments tp
+		JOIN order_settlements os ON os.id = tp.order_settlement_id
+		LEFT JOIN stored_value_accounts sva ON sva.id = tp.account_id
+		WHERE os.order_id = $1
+		ORDER BY tp.id
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var settlementID int
+		var payment models.Payment
+		if err := rows.Scan(&settlementID, &payment.ID, &payment.Method, &payment.Amount, &payment.Points, &payment.AccountID, &payment.Code); err != nil {
+			return nil, err
+		}
+		settlement := &order.Settlements[shares[settlementID]]
+		settlement.Payments = append(settlement.Payments, payment)
+	}
+	return &order, rows.Err()
+}
+
+// Create opens an order for a table at the outlet. Its items are priced to
+// check they can be sold, but the bill is only fixed when it is split.
+func (repo *OrderRepository) Create(req models.OrderRequest, meta models.RequestMeta) (*models.Order, error) {
+	if len(req.Items) == 0 {
+		return nil, errors.New("Items are required")
+	}
+
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if req.CustomerID != nil {
+		if _, err := getCustomer(tx, *req.CustomerID, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	order := models.Order{
+		OutletID:   meta.OutletID,
+		TableName:  strings.TrimSpace(req.TableName),
+		CustomerID: req.CustomerID,
+		Status:     models.OrderOpen,
+		Note:       strings.TrimSpace(req.Note),
+		CreatedBy:  meta.ActorName(),
+	}
+	for _, item := range req.Items {
+		order.Items = append(order.Items, orderItem(item))
+	}
+	if _, err := priceOrder(tx, &order); err != nil {
+		return nil, err
+	}
+
+	query := `
+		INSERT INTO orders (outlet_id, table_name, customer_id, status, note, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id`
+	err = tx.QueryRow(query, order.OutletID, order.TableName, order.CustomerID, order.Status, order.Note, order.CreatedBy).Scan(&order.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := insertOrderItems(tx, order.ID, order.Items, meta.ActorName()); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return repo.GetByID(order.ID)
+}
+
+// AddItems adds items to an open order.
+func (repo *OrderRepository) AddItems(id int, req models.OrderItemsRequest, meta models.RequestMeta) (*models.Order, error) {
+	if len(req.Items) == 0 {
+		return nil, errors.New("Items are required")
+	}
+
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	order, err := getOrder(tx, id, "FOR UPDATE")
+	if err != nil {
+		return nil, err
+	}
+	if order.Status != models.OrderOpen {
+		return nil, fmt.Errorf("order is %s", order.Status)
+	}
+	if meta.OutletID != order.OutletID {
+		return nil, fmt.Errorf("order is at outlet id %d", order.OutletID)
+	}
+
+	items := make([]models.OrderItem, 0, len(req.Items))
+	for _, item := range req.Items {
+		items = append(items, orderItem(item))
+	}
+	order.Items = append(order.Items, items...)
+	if _, err := priceOrder(tx, order); err != nil {
+		return nil, err
+	}
+	if err := insertOrderItems(tx, id, items, meta.ActorName()); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return repo.GetByID(id)
+}
+
+// RemoveItem takes an item off an open order.
+func (repo *OrderRepository) RemoveItem(id, itemID int, meta models.RequestMeta) (*models.Order, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	order, err := getOrder(tx, id, "FOR UPDATE")
+	if err != nil {
+		return nil, err
+	}
+	if order.Status != models.OrderOpen {
+		return nil, fmt.Errorf("order is %s", order.Status)
+	}
+	if meta.OutletID != order.OutletID {
+		return nil, fmt.Errorf("order is at outlet id %d", order.OutletID)
+	}
+
+	result, err := tx.Exec("DELETE FROM order_items WHERE id = $1 AND order_id = $2", itemID, id)
+	if err != nil {
+		return nil, err
+	}
+	if affected, err := result.RowsAffected(); err != nil {
+		return nil, err
+	} else if affected == 0 {
+		return nil, errors.New("Order item not found")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return repo.GetByID(id)
+}
+
+// Split fixes an open order's bill at today's prices and divides it into
+// settlements. The order is recorded as a single sale, which takes its stock
+// and earns the customer's points once however many ways the bill is
+// split; the settlements then pay for it. Besides the order it returns the
+// sale's low stock events, for the caller to deliver once committed.
+func (repo *OrderRepository) Split(id int, req models.SplitRequest, meta models.RequestMeta) (*models.Order, []models.LowStockEvent, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback()
+
+	order, err := getOrder(tx, id, "FOR UPDATE")
+	if err != nil {
+		return nil, nil, err
+	}
+	if order.Status != models.OrderOpen {
+		return nil, nil, fmt.Errorf("order is %s", order.Status)
+	}
+	if meta.OutletID != order.OutletID {
+		return nil, nil, fmt.Errorf("order is at outlet id %d", order.OutletID)
+	}
+	if len(order.Items) == 0 {
+		return nil, nil, errors.New("The order has no items")
+	}
+
+	sale, err := priceOrder(tx, order)
+	if err != nil {
+		return nil, nil, err
+	}
+	if sale.total <= 0 {
+		return nil, nil, errors.New("The order has nothing to pay")
+	}
+	settlements, err := splitBill(order, req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pointsEarned := 0
+	loyalty, err := getLoyaltySettings(tx)
+	if err != nil {
+		return nil, nil, err
+	}
+	if order.CustomerID != nil {
+		if err := lockCustomer(tx, *order.CustomerID); err != nil {
+			return nil, nil, err
+		}
+		if err := expirePoints(tx, *order.CustomerID, meta.ActorName()); err != nil {
+			return nil, nil, err
+		}
+		if loyalty.Enabled {
+			pointsEarned, err = earnPoints(tx, sale.details, sale.total, 0, loyalty)
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	var transactionID int
+	query := `
+		INSERT INTO transactions (total_amount, outlet_id, terminal_id, customer_id, points_earned)
+		VALUES ($1, $2, NULLIF($3, 0), $4, $5)
+		RETURNING id`
+	err = tx.QueryRow(query, sale.total, order.OutletID, meta.TerminalID, order.CustomerID, pointsEarned).Scan(&transactionID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	events, err := takeSaleStock(tx, sale, transactionID, meta)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := insertSaleDetails(tx, sale, transactionID, meta.ActorName()); err != nil {
+		return nil, nil, err
+	}
+	if pointsEarned > 0 {
+		err := addPoints(tx, &models.PointsEntry{
+			CustomerID:    *order.CustomerID,
+			Points:        pointsEarned,
+			Reason:        models.PointsReasonEarn,
+			TransactionID: &transactionID,
+			CreatedBy:     meta.ActorName(),
+		}, loyalty.ExpiryDays)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// A share split by items pays for the sale's lines of those items.
+	itemIndex := make(map[int]int, len(order.Items))
+	for i, item := range order.Items {
+		itemIndex[item.ID] = i
+	}
+	for _, settlement := range settlements {
+		var settlementID int
+		err := tx.QueryRow("INSERT INTO order_settlements (order_id, amount, status) VALUES ($1, $2, $3) RETURNING id", id, settlement.Amount, models.SettlementUnpaid).Scan(&settlementID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(settlement.ItemIDs) == 0 {
+			continue
+		}
+
+		paidFor := make(map[int]bool, len(settlement.ItemIDs))
+		for _, itemID := range settlement.ItemIDs {
+			paidFor[itemIndex[itemID]] = true
+		}
+		detailIDs := make([]int, 0)
+		for d, detail := range sale.details {
+			if paidFor[sale.itemOf[d]] {
+				detailIDs = append(detailIDs, detail.ID)
+			}
+		}
+		if _, err := tx.Exec("UPDATE order_items SET settlement_id = $1 WHERE id = ANY ($2)", settlementID, pq.Array(settlement.ItemIDs)); err != nil {
+			return nil, nil, err
+		}
+		if _, err := tx.Exec("UPDATE transaction_details SET order_settlement_id = $1 WHERE id = ANY ($2)", settlementID, pq.Array(detailIDs)); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	for _, item := range order.Items {
+		if _, err := tx.Exec("UPDATE order_items SET name = $1, subtotal = $2 WHERE id = $3", item.Name, item.Subtotal, item.ID); err != nil {
+			return nil, nil, err
+		}
+	}
+	query = `
+		UPDATE orders
+		SET status = $1, split_mode = $2, total_amount = $3, transaction_id = $4, split_at = NOW()
+		WHERE id = $5`
+	_, err = tx.Exec(query, models.OrderSplit, req.Mode, sale.total, transactionID, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, err
+	}
+	order, err = getOrder(repo.db, id, "")
+	if err != nil {
+		return nil, nil, err
+	}
+	return order, events, nil
+}
+
+// PaySettlement pays one share of a split bill. A share is paid like a
+// checkout of its amount, except with points or on account: the points
+// the sale earned assumed it was paid in full. The order is settled with
+// its last share.
+func (repo *OrderRepository) PaySettlement(id, settlementID int, req models.SettlementRequest, meta models.RequestMeta) (*models.Order, error) {
+	for _, payment := range req.Payments {
+		if payment.Method == models.PaymentPoints || payment.Method == models.PaymentOnAccount {
+			return nil, fmt.Errorf("a settlement cannot be paid with %s", payment.Method)
+		}
+	}
+
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	order, err := getOrder(tx, id, "FOR UPDATE")
+	if err != nil {
+		return nil, err
+	}
+	if order.Status != models.OrderSplit {
+		return nil, fmt.Errorf("order is %s", order.Status)
+	}
+	if meta.OutletID != order.OutletID {
+		return nil, fmt.Errorf("order is at outlet id %d", order.OutletID)
+	}
+	if order.TransactionID == nil {
+		return nil, errors.New("Order has no sale to settle")
+	}
+	var settlement *models.OrderSettlement
+	unpaid := 0
+	for i := range order.Settlements {
+		if order.Settlements[i].ID == settlementID {
+			settlement = &order.Settlements[i]
+		}
+		if order.Settlements[i].Status == models.SettlementUnpaid {
+			unpaid++
+		}
+	}
+	if settlement == nil {
+		return nil, errors.New("Settlement not found")
+	}
+	if settlement.Status != models.SettlementUnpaid {
+		return nil, errors.New("Settlement is already paid")
+	}
+
+	loyalty, err := getLoyaltySettings(tx)
+	if err != nil {
+		return nil, err
+	}
+	payments, change, err := settlePayments(req.Payments, settlement.Amount, loyalty)
+	if err != nil {
+		return nil, err
+	}
+
+	// The sale is locked before the customer, as in a refund.
+	if _, err := tx.Exec("UPDATE transactions SET change_amount = change_amount + $1 WHERE id = $2", change, *order.TransactionID); err != nil {
+		return nil, err
+	}
+	if order.CustomerID != nil {
+		if err := lockCustomer(tx, *order.CustomerID); err != nil {
+			return nil, err
+		}
+	}
+	if err := lockStoredValue(tx, payments, order.CustomerID); err != nil {
+		return nil, err
+	}
+	if err := insertSalePayments(tx, *order.TransactionID, payments, meta.ActorName()); err != nil {
+		return nil, err
+	}
+	paymentIDs := make([]int, 0, len(payments))
+	for _, payment := range payments {
+		paymentIDs = append(paymentIDs, payment.ID)
+	}
+	if _, err := tx.Exec("UPDATE transaction_payments SET order_settlement_id = $1 WHERE id = ANY ($2)", settlementID, pq.Array(paymentIDs)); err != nil {
+		return nil, err
+	}
+
+	query := "UPDATE order_settlements SET status = $1, change_amount = $2, paid_by = $3, paid_at = NOW() WHERE id = $4"
+	if _, err := tx.Exec(query, models.SettlementPaid, change, meta.ActorName(), settlementID); err != nil {
+		return nil, err
+	}
+	if unpaid == 1 {
+		if _, err := tx.Exec("UPDATE orders SET status = $1, settled_at = NOW() WHERE id = $2", models.OrderSettled, id); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return getOrder(repo.db, id, "")
+}
+
+// Cancel cancels an open order, or a split one before any share of it is
+// paid, at the order's outlet. The sale a split order was recorded as is
+// then refunded in full, putting its stock back and taking back the points it
+// earned, with no payments to give back.
+func (repo *OrderRepository) Cancel(id int, req models.OrderCancelRequest, meta models.RequestMeta) (*models.Order, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	order, err := getOrder(tx, id, "FOR UPDATE")
+	if err != nil {
+		return nil, err
+	}
+	if order.Status != models.OrderOpen && order.Status != models.OrderSplit {
+		return nil, fmt.Errorf("order is %s", order.Status)
+	}
+	if meta.OutletID != order.OutletID {
+		return nil, fmt.Errorf("order is at outlet id %d", order.OutletID)
+	}
+	reason := strings.TrimSpace(req.Reason)
+	if order.Status == models.OrderSplit {
+		if order.PaidAmount > 0 {
+			return nil, errors.New("Order has paid settlements")
+		}
+		if order.TransactionID == nil {
+			return nil, errors.New("Order has no sale to cancel")
+		}
+		note := reason
+		if note == "" {
+			note = fmt.Sprintf("Order #%d cancelled", id)
+		}
+		if _, err := refundSale(tx, *order.TransactionID, models.RefundRequest{Reason: note}, meta, false); err != nil {
+			return nil, err
+		}
+	}
+
+	_, err = tx.Exec("UPDATE orders SET status = $1, cancel_reason = $2, cancelled_at = NOW() WHERE id = $3", models.OrderCancelled, reason, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return getOrder(repo.db, id, "")
+}
+
+// GetReceipt loads a split order with the sale it was recorded as, for
+// printing the receipt of one of its settlements.
+func (repo *OrderRepository) GetReceipt(id int) (*models.Order, *models.Transaction, error) {
+	order, err := getOrder(repo.db, id, "")
+	if err != nil {
+		return nil, nil, err
+	}
+	if order.TransactionID == nil {
+		return nil, nil, fmt.Errorf("order is %s", order.Status)
+	}
+	transaction, err := getTransaction(repo.db, *order.TransactionID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return order, transaction, nil
+}
+
+func orderItem(item models.CheckoutItem) models.OrderItem {
+	return models.OrderItem{
+		ProductID:   item.ProductID,
+		Barcode:     item.Barcode,
+		BundleID:    item.BundleID,
+		Quantity:    item.Quantity,
+		Unit:        item.Unit,
+		ModifierIDs: item.ModifierIDs,
+	}
+}
+
+func insertOrderItems(tx *sql.Tx, orderID int, items []models.OrderItem, actor string) error {
+	for _, item := range items {
+		query := `
+			INSERT INTO order_items (order_id, product_id, barcode, bundle_id, quantity, unit, modifier_ids, created_by)
+			VALUES ($1, NULLIF($2, 0), $3, NULLIF($4, 0), $5, $6, COALESCE($7::INT[], '{}'), $8)`
+		_, err := tx.Exec(query, orderID, item.ProductID, item.Barcode, item.BundleID, item.Quantity, item.Unit, pq.Array(item.ModifierIDs), actor)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// priceOrder prices an order's items as a checkout at its outlet would, for
+// its customer, and sets each item's name and subtotal and the order's
+// total. Gift cards cannot be sold on an order.
+func priceOrder(tx *sql.Tx, order *models.Order) (*pricedSale, error) {
+	items := make([]models.CheckoutItem, 0, len(order.Items))
+	for _, item := range order.Items {
+		items = append(items, item.CheckoutItem())
+	}
+	sale, err := priceSale(tx, models.CheckoutRequest{CustomerID: order.CustomerID, Items: items}, order.OutletID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range order.Items {
+		order.Items[i].Subtotal = 0
+	}
+	for d, detail := range sale.details {
+		if len(detail.GiftCards) > 0 {
+			return nil, fmt.Errorf("product id %d cannot be ordered", detail.ProductID)
+		}
+		item := &order.Items[sale.itemOf[d]]
+		item.Name = detail.ProductName
+		if bundle := sale.bundleOf[d]; bundle >= 0 {
+			item.Name = sale.bundles[bundle].Name
+		}
+		item.Subtotal += detail.Subtotal
+	}
+	order.TotalAmount = sale.total
+	return sale, nil
+}
+
+// splitBill divides an order's priced bill into the settlements req asks
+// for.
+func splitBill(order *models.Order, req models.SplitRequest) ([]models.OrderSettlement, error) {
+	settlements := make([]models.OrderSettlement, 0)
+	switch req.Mode {
+	case models.SplitItems:
+		if len(req.Items) == 0 {
+			return nil, errors.New("Items must list the items of each share")
+		}
+		subtotals := make(map[int]int, len(order.Items))
+		for _, item := range order.Items {
+			subtotals[item.ID] = item.Subtotal
+		}
+		shared := make(map[int]bool, len(order.Items))
+		for i, itemIDs := range req.Items {
+			settlement := models.OrderSettlement{ItemIDs: itemIDs}
+			for _, itemID := range itemIDs {
+				subtotal, ok := subtotals[itemID]
+				if !ok {
+					return nil, fmt.Errorf("order item id %d is not on the order", itemID)
+				}
+				if shared[itemID] {
+					return nil, fmt.Errorf("order item id %d is in more than one share", itemID)
+				}
+				shared[itemID] = true
+				settlement.Amount += subtotal
+			}
+			if settlement.Amount <= 0 {
+				return nil, fmt.Errorf("share %d has nothing to pay", i+1)
+			}
+			settlements = append(settlements, settlement)
+		}
+		for _, item := range order.Items {
+			if !shared[item.ID] {
+				return nil, fmt.Errorf("order item id %d is not in any share", item.ID)
+			}
+		}
+	case models.SplitEqual:
+		if req.Shares < 1 {
+			return nil, errors.New("shares must be at least 1")
+		}
+		if req.Shares > order.TotalAmount {
+			return nil, fmt.Errorf("a total of %d cannot be split into %d shares", order.TotalAmount, req.Shares)
+		}
+		for _, amount := range models.SplitEqually(order.TotalAmount, req.Shares) {
+			settlements = append(settlements, models.OrderSettlement{Amount: amount})
+		}
+	case models.SplitAmounts:
+		if len(req.Amounts) == 0 {
+			return nil, errors.New("Amounts are required")
+		}
+		total := 0
+		for i, amount := range req.Amounts {
+			if amount <= 0 {
+				return nil, fmt.Errorf("invalid amount for share %d", i+1)
+			}
+			total += amount
+			settlements = append(settlements, models.OrderSettlement{Amount: amount})
+		}
+		if total != order.TotalAmount {
+			return nil, fmt.Errorf("shares of %d do not add up to the total of %d", total, order.TotalAmount)
+		}
+	default:
+		return nil, fmt.Errorf("invalid split mode %q", req.Mode)
+	}
+	return settlements, nil
+}
@@ -0,0 +1,290 @@
+package repositories
+
+import (
+	"cashier-api/models"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+)
+
+type OutletRepository struct {
+	db *sql.DB
+}
+
+func NewOutletRepository(db *sql.DB) *OutletRepository {
+	return &OutletRepository{db: db}
+}
+
+func (repo *OutletRepository) GetAll() ([]models.Outlet, error) {
+	rows, err := repo.db.Query("SELECT id, name, address, created_at FROM outlets ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	outlets := make([]models.Outlet, 0)
+	for rows.Next() {
+		var outlet models.Outlet
+		err := rows.Scan(&outlet.ID, &outlet.Name, &outlet.Address, &outlet.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		outlets = append(outlets, outlet)
+	}
+
+	return outlets, rows.Err()
+}
+
+func (repo *OutletRepository) Create(outlet *models.Outlet, meta models.RequestMeta) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := "INSERT INTO outlets (name, address) VALUES ($1, $2) RETURNING id, created_at"
+	err = tx.QueryRow(query, outlet.Name, outlet.Address).Scan(&outlet.ID, &outlet.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	err = insertAuditLog(tx, meta, models.AuditActionCreate, models.AuditEntityOutlet, outlet.ID, nil, outlet)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (repo *OutletRepository) GetByID(id int) (*models.Outlet, error) {
+	return getOutlet(repo.db, id, "")
+}
+
+func (repo *OutletRepository) Update(outlet *models.Outlet, meta models.RequestMeta) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	before, err := getOutlet(tx, outlet.ID, "FOR UPDATE")
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("UPDATE outlets SET name = $1, address = $2 WHERE id = $3", outlet.Name, outlet.Address, outlet.ID)
+	if err != nil {
+		return err
+	}
+	outlet.CreatedAt = before.CreatedAt
+
+	err = insertAuditLog(tx, meta, models.AuditActionUpdate, models.AuditEntityOutlet, outlet.ID, before, outlet)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (repo *OutletRepository) GetTerminals(outletID int) ([]models.Terminal, error) {
+	if _, err := getOutlet(repo.db, outletID, ""); err != nil {
+		return nil, err
+	}
+
+	rows, err := repo.db.Query("SELECT id, outlet_id, name, created_at FROM terminals WHERE outlet_id = $1 ORDER BY id", outletID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	terminals := make([]models.Terminal, 0)
+	for rows.Next() {
+		var terminal models.Terminal
+		err := rows.Scan(&terminal.ID, &terminal.OutletID, &terminal.Name, &terminal.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		terminals = append(terminals, terminal)
+	}
+
+	return terminals, rows.Err()
+}
+
+// CreateTerminal registers a terminal and generates its API key. The key is
+// only available on the returned value; the database keeps its hash.
+func (repo *OutletRepository) CreateTerminal(terminal *models.Terminal) error {
+	if _, err := getOutlet(repo.db, terminal.OutletID, ""); err != nil {
+		return err
+	}
+
+	key := make([]byte, 24)
+	if _, err := rand.Read(key); err != nil {
+		return err
+	}
+	terminal.APIKey = hex.EncodeToString(key)
+
+	query := "INSERT INTO terminals (outlet_id, name, api_key_hash) VALUES ($1, $2, $3) RETURNING id, created_at"
+	return repo.db.QueryRow(query, terminal.OutletID, terminal.Name, hashAPIKey(terminal.APIKey)).Scan(&terminal.ID, &terminal.CreatedAt)
+}
+
+func (repo *OutletRepository) DeleteTerminal(outletID, terminalID int) error {
+	result, err := repo.db.Exec("DELETE FROM terminals WHERE id = $1 AND outlet_id = $2", terminalID, outletID)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errors.New("Terminal not found")
+	}
+
+	return nil
+}
+
+func (repo *OutletRepository) GetTerminalByAPIKey(key string) (*models.Terminal, error) {
+	var terminal models.Terminal
+	query := "SELECT id, outlet_id, name, created_at FROM terminals WHERE api_key_hash = $1"
+	err := repo.db.QueryRow(query, hashAPIKey(key)).Scan(&terminal.ID, &terminal.OutletID, &terminal.Name, &terminal.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, errors.New("Invalid terminal key")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &terminal, nil
+}
+
+// SetPrice overrides a product's price at one outlet. A nil price removes
+// the override so the outlet sells at the product price again.
+func (repo *OutletRepository) SetPrice(price *models.OutletPrice, meta models.RequestMeta) error {
+	if price.Price != nil && *price.Price < 0 {
+		return errors.New("Price must not be negative")
+	}
+
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := getOutlet(tx, price.OutletID, ""); err != nil {
+		return err
+	}
+	if _, err := getProductForUpdate(tx, price.ProductID); err != nil {
+		return err
+	}
+
+	before := models.OutletPrice{OutletID: price.OutletID, ProductID: price.ProductID}
+	err = tx.QueryRow("SELECT price FROM outlet_stocks WHERE outlet_id = $1 AND product_id = $2", price.OutletID, price.ProductID).Scan(&before.Price)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+
+	query := `
+		INSERT INTO outlet_stocks (outlet_id, product_id, price) VALUES ($1, $2, $3)
+		ON CONFLICT (outlet_id, product_id) DO UPDATE SET price = EXCLUDED.price
+	`
+	_, err = tx.Exec(query, price.OutletID, price.ProductID, price.Price)
+	if err != nil {
+		return err
+	}
+
+	err = insertAuditLog(tx, meta, models.AuditActionUpdate, models.AuditEntityOutletPrice, price.ProductID, before, price)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetReport compares sales, refunds and gross profit across outlets over an
+// optional date range. Refunds count against the outlet that took them.
+func (repo *OutletRepository) GetReport(from, to *time.Time) (*models.OutletReport, error) {
+	args := []interface{}{}
+	saleWhere, refundWhere := "", ""
+	if from != nil {
+		args = append(args, *from)
+		saleWhere += fmt.Sprintf(" AND t.created_at >= $%d", len(args))
+		refundWhere += fmt.Sprintf(" AND r.created_at >= $%d", len(args))
+	}
+	if to != nil {
+		args = append(args, *to)
+		saleWhere += fmt.Sprintf(" AND t.created_at < $%d", len(args))
+		refundWhere += fmt.Sprintf(" AND r.created_at < $%d", len(args))
+	}
+
+	query := `
+		SELECT o.id, o.name,
+			COALESCE(s.transactions, 0), COALESCE(s.revenue, 0), COALESCE(rf.amount, 0),
+			COALESCE(s.cost, 0) - COALESCE(rf.cost, 0)
+		FROM outlets o
+		LEFT JOIN (
+			SELECT t.outlet_id, COUNT(DISTINCT t.id) AS transactions, SUM(td.subtotal) AS revenue, SUM(td.cost_amount) AS cost
+			FROM transactions t
+			JOIN transaction_details td ON td.transaction_id = t.id
+			WHERE 1 = 1` + saleWhere + `
+			GROUP BY t.outlet_id
+		) s ON s.outlet_id = o.id
+		LEFT JOIN (
+			SELECT r.outlet_id, SUM(ri.amount) AS amount, SUM(ri.cost_amount) AS cost
+			FROM refunds r
+			JOIN refund_items ri ON ri.refund_id = r.id
+			WHERE 1 = 1` + refundWhere + `
+			GROUP BY r.outlet_id
+		) rf ON rf.outlet_id = o.id
+		ORDER BY o.id
+	`
+	rows, err := repo.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	report := models.OutletReport{
+		Total:   models.OutletSummary{OutletName: "Total"},
+		Outlets: make([]models.OutletSummary, 0),
+	}
+	for rows.Next() {
+		var row models.OutletSummary
+		if err := rows.Scan(&row.OutletID, &row.OutletName, &row.TotalTransaction, &row.TotalRevenue, &row.TotalRefund, &row.TotalCost); err != nil {
+			return nil, err
+		}
+		row.GrossProfit = row.TotalRevenue - row.TotalRefund - row.TotalCost
+		row.MarginPercent = models.MarginPercent(row.GrossProfit, row.TotalRevenue-row.TotalRefund)
+		report.Outlets = append(report.Outlets, row)
+
+		report.Total.TotalTransaction += row.TotalTransaction
+		report.Total.TotalRevenue += row.TotalRevenue
+		report.Total.TotalRefund += row.TotalRefund
+		report.Total.TotalCost += row.TotalCost
+	}
+	report.Total.GrossProfit = report.Total.TotalRevenue - report.Total.TotalRefund - report.Total.TotalCost
+	report.Total.MarginPercent = models.MarginPercent(report.Total.GrossProfit, report.Total.TotalRevenue-report.Total.TotalRefund)
+
+	return &report, rows.Err()
+}
+
+func getOutlet(db queryer, id int, lock string) (*models.Outlet, error) {
+	var outlet models.Outlet
+	err := db.QueryRow("SELECT id, name, address, created_at FROM outlets WHERE id = $1 "+lock, id).Scan(&outlet.ID, &outlet.Name, &outlet.Address, &outlet.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, errors.New("Outlet not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &outlet, nil
+}
+
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
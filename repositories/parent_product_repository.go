@@ -0,0 +1,257 @@
+package repositories
+
+import (
+	"cashier-api/models"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+type ParentProductRepository struct {
+	db *sql.DB
+}
+
+func NewParentProductRepository(db *sql.DB) *ParentProductRepository {
+	return &ParentProductRepository{db: db}
+}
+
+func (repo *ParentProductRepository) GetAll() ([]models.ParentProduct, error) {
+	rows, err := repo.db.Query("SELECT id, name, category_id, axes FROM parent_products ORDER BY name, id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	parents := make([]models.ParentProduct, 0)
+	for rows.Next() {
+		var parent models.ParentProduct
+		if err := scanParentProduct(rows, &parent); err != nil {
+			return nil, err
+		}
+		parents = append(parents, parent)
+	}
+
+	return parents, rows.Err()
+}
+
+// GetByID returns a parent with its variants, showing the outlet's stock and
+// price when outletID is set.
+func (repo *ParentProductRepository) GetByID(id, outletID int) (*models.ParentProduct, error) {
+	parent, err := getParentProduct(repo.db, id, "")
+	if err != nil {
+		return nil, err
+	}
+
+	query, args := productQuery(outletID)
+	query += " AND p.parent_id = $2 ORDER BY p.id"
+	rows, err := repo.db.Query(query, append(args, id)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	parent.Variants = make([]models.Product, 0)
+	for rows.Next() {
+		var product models.Product
+		if err := scanProduct(rows, &product); err != nil {
+			return nil, err
+		}
+		parent.Variants = append(parent.Variants, product)
+	}
+
+	return parent, rows.Err()
+}
+
+func (repo *ParentProductRepository) Create(parent *models.ParentProduct, meta models.RequestMeta) error {
+	if parent.Name == "" {
+		return errors.New("Name is required")
+	}
+	if err := models.ValidateAxes(parent.Axes); err != nil {
+		return err
+	}
+	axes, err := json.Marshal(parent.Axes)
+	if err != nil {
+		return err
+	}
+
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := "INSERT INTO parent_products (name, category_id, axes) VALUES ($1, $2, $3) RETURNING id"
+	err = tx.QueryRow(query, parent.Name, parent.CategoryID, string(axes)).Scan(&parent.ID)
+	if err != nil {
+		return err
+	}
+
+	err = insertAuditLog(tx, meta, models.AuditActionCreate, models.AuditEntityParentProduct, parent.ID, nil, parent)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Update renames a parent or changes its axes. Axes can only change in ways
+// that keep every existing variant valid.
+func (repo *ParentProductRepository) Update(parent *models.ParentProduct, meta models.RequestMeta) error {
+	if parent.Name == "" {
+		return errors.New("Name is required")
+	}
+	if err := models.ValidateAxes(parent.Axes); err != nil {
+		return err
+	}
+	axes, err := json.Marshal(parent.Axes)
+	if err != nil {
+		return err
+	}
+
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	before, err := getParentProduct(tx, parent.ID, "FOR UPDATE")
+	if err != nil {
+		return err
+	}
+
+	rows, err := tx.Query("SELECT id, variant_options FROM products WHERE parent_id = $1", parent.ID)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var productID int
+		var data []byte
+		if err := rows.Scan(&productID, &data); err != nil {
+			rows.Close()
+			return err
+		}
+		var options map[string]string
+		if err := json.Unmarshal(data, &options); err != nil {
+			rows.Close()
+			return err
+		}
+		if err := parent.ValidateOptions(options); err != nil {
+			rows.Close()
+			return fmt.Errorf("axes no longer fit variant product id %d: %v", productID, err)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("UPDATE parent_products SET name = $1, category_id = $2, axes = $3 WHERE id = $4", parent.Name, parent.CategoryID, string(axes), parent.ID)
+	if err != nil {
+		return err
+	}
+
+	err = insertAuditLog(tx, meta, models.AuditActionUpdate, models.AuditEntityParentProduct, parent.ID, before, parent)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (repo *ParentProductRepository) Delete(id int, meta models.RequestMeta) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	before, err := getParentProduct(tx, id, "FOR UPDATE")
+	if err != nil {
+		return err
+	}
+
+	var variants int
+	err = tx.QueryRow("SELECT COUNT(*) FROM products WHERE parent_id = $1", id).Scan(&variants)
+	if err != nil {
+		return err
+	}
+	if variants > 0 {
+		return errors.New("Parent product still has variants")
+	}
+
+	_, err = tx.Exec("DELETE FROM parent_products WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	err = insertAuditLog(tx, meta, models.AuditActionDelete, models.AuditEntityParentProduct, id, before, nil)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetCatalog lists every product, with variants grouped under their parent.
+func (repo *ParentProductRepository) GetCatalog(outletID int) (*models.Catalog, error) {
+	parents, err := repo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	index := make(map[int]int, len(parents))
+	for i := range parents {
+		parents[i].Variants = make([]models.Product, 0)
+		index[parents[i].ID] = i
+	}
+
+	query, args := productQuery(outletID)
+	query += " ORDER BY p.name, p.id"
+	rows, err := repo.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	catalog := models.Catalog{Parents: parents, Products: make([]models.Product, 0)}
+	for rows.Next() {
+		var product models.Product
+		if err := scanProduct(rows, &product); err != nil {
+			return nil, err
+		}
+		i, ok := -1, false
+		if product.ParentID != nil {
+			i, ok = index[*product.ParentID]
+		}
+		if !ok {
+			catalog.Products = append(catalog.Products, product)
+			continue
+		}
+		catalog.Parents[i].Variants = append(catalog.Parents[i].Variants, product)
+	}
+
+	return &catalog, rows.Err()
+}
+
+func scanParentProduct(row rowScanner, parent *models.ParentProduct) error {
+	var axes []byte
+	if err := row.Scan(&parent.ID, &parent.Name, &parent.CategoryID, &axes); err != nil {
+		return err
+	}
+	return json.Unmarshal(axes, &parent.Axes)
+}
+
+// getParentProduct loads a parent without its variants. lock is an optional
+// row locking clause.
+func getParentProduct(db queryer, id int, lock string) (*models.ParentProduct, error) {
+	var parent models.ParentProduct
+	err := scanParentProduct(db.QueryRow("SELECT id, name, category_id, axes FROM parent_products WHERE id = $1 "+lock, id), &parent)
+	if err == sql.ErrNoRows {
+		return nil, errors.New("Parent product not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &parent, nil
+}
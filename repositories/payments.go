@@ -0,0 +1,153 @@
+package repositories
+
+import (
+	"cashier-api/models"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// settlePayments checks the payments tendered for a sale of total and
+// returns them as recorded, with the change due. Stored value payments are
+// checked against their accounts separately, by lockStoredValue, and
+// payments on account against the customer's credit limit, by checkCredit.
+// Without payments the sale is paid in cash. Only cash may be tendered over the total; the excess is
+// handed back as change and taken off the cash payments, last first.
+func settlePayments(payments []models.Payment, total int, settings *models.LoyaltySettings) ([]models.Payment, int, error) {
+	if len(payments) == 0 {
+		if total == 0 {
+			return make([]models.Payment, 0), 0, nil
+		}
+		return []models.Payment{{Method: models.PaymentCash, Amount: total}}, 0, nil
+	}
+
+	paid, cash, onAccount := 0, 0, 0
+	for i := range payments {
+		payment := &payments[i]
+		payment.AccountID = nil
+		if payment.Amount <= 0 {
+			return nil, 0, fmt.Errorf("invalid amount for %s payment", payment.Method)
+		}
+		switch payment.Method {
+		case models.PaymentCash:
+			cash += payment.Amount
+		case models.PaymentCard, models.PaymentQRIS, models.PaymentTransfer:
+		case models.PaymentPoints:
+			if !settings.Enabled {
+				return nil, 0, errors.New("Loyalty programme is not enabled")
+			}
+			if payment.Amount%settings.PointValue != 0 {
+				return nil, 0, fmt.Errorf("points pay in multiples of %d", settings.PointValue)
+			}
+			payment.Points = payment.Amount / settings.PointValue
+		case models.PaymentGiftCard:
+			code, err := models.NormalizeGiftCardCode(payment.Code)
+			if err != nil {
+				return nil, 0, err
+			}
+			payment.Code = code
+		case models.PaymentStoreCredit:
+		case models.PaymentOnAccount:
+			onAccount++
+			if onAccount > 1 {
+				return nil, 0, errors.New("A sale takes a single on_account payment")
+			}
+		default:
+			return nil, 0, fmt.Errorf("invalid payment method %q", payment.Method)
+		}
+		paid += payment.Amount
+	}
+	if paid-cash > total {
+		return nil, 0, fmt.Errorf("non-cash payments of %d exceed the total of %d", paid-cash, total)
+	}
+	if paid < total {
+		return nil, 0, fmt.Errorf("payments of %d do not cover the total of %d", paid, total)
+	}
+
+	change := paid - total
+	if change > 0 && change == cash {
+		return nil, 0, fmt.Errorf("other payments already cover the total of %d without cash", total)
+	}
+	left := change
+	for i := len(payments) - 1; i >= 0 && left > 0; i-- {
+		if payments[i].Method == models.PaymentCash {
+			taken := min(payments[i].Amount, left)
+			payments[i].Amount -= taken
+			left -= taken
+		}
+	}
+	settled := make([]models.Payment, 0, len(payments))
+	for _, payment := range payments {
+		if payment.Amount > 0 {
+			settled = append(settled, payment)
+		}
+	}
+	return settled, change, nil
+}
+
+// refundPayments splits a refund of amount across the payments of a sale, in
+// proportion to what each has left to refund, so the refund that clears the
+// sale pays every payment back in full. A points payment gives back its
+// share of the points it spent, and gift card and store credit payments go
+// back onto the account they were paid from. Each payment's ID is the sale
+// payment it pays back.
+func refundPayments(tx *sql.Tx, transactionID, amount int) ([]models.Payment, error) {
+	rows, err := tx.Query(`
+		SELECT tp.id, tp.method, tp.amount, tp.points, tp.account_id, COALESCE(sva.code, ''), COALESCE(SUM(rp.amount), 0), COALESCE(SUM(rp.points), 0)
+		FROM transaction_payments tp
+		LEFT JOIN stored_value_accounts sva ON sva.id = tp.account_id
+		LEFT JOIN refund_payments rp ON rp.transaction_payment_id = tp.id
+		WHERE tp.transaction_id = $1
+		GROUP BY tp.id, tp.method, tp.amount, tp.points, tp.account_id, sva.code
+		ORDER BY tp.id
+	`, transactionID)
+	if err != nil {
+		return nil, err
+	}
+
+	type paidLine struct {
+		payment        models.Payment
+		refunded       int
+		refundedPoints int
+	}
+	paidLines := make([]paidLine, 0)
+	remaining := make([]int, 0)
+	left := 0
+	for rows.Next() {
+		var line paidLine
+		if err := rows.Scan(&line.payment.ID, &line.payment.Method, &line.payment.Amount, &line.payment.Points, &line.payment.AccountID, &line.payment.Code, &line.refunded, &line.refundedPoints); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		paidLines = append(paidLines, line)
+		remaining = append(remaining, line.payment.Amount-line.refunded)
+		left += line.payment.Amount - line.refunded
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if amount > left {
+		return nil, fmt.Errorf("transaction %d has only %d left to refund", transactionID, left)
+	}
+
+	refunded := make([]models.Payment, 0, len(paidLines))
+	if amount == 0 {
+		return refunded, nil
+	}
+	for i, share := range models.AllocateBundlePrice(amount, remaining) {
+		if share == 0 {
+			continue
+		}
+		line := paidLines[i]
+		payment := models.Payment{ID: line.payment.ID, Method: line.payment.Method, Amount: share, Code: line.payment.Code, AccountID: line.payment.AccountID}
+		if line.payment.Points > 0 {
+			payment.Points = line.payment.Points * share / line.payment.Amount
+			if share == remaining[i] {
+				payment.Points = line.payment.Points - line.refundedPoints
+			}
+		}
+		refunded = append(refunded, payment)
+	}
+	return refunded, nil
+}
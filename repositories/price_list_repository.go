@@ -0,0 +1,344 @@
+package repositories
+
+import (
+	"cashier-api/models"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/lib/pq"
+)
+
+type PriceListRepository struct {
+	db *sql.DB
+}
+
+func NewPriceListRepository(db *sql.DB) *PriceListRepository {
+	return &PriceListRepository{db: db}
+}
+
+func (repo *PriceListRepository) GetAll() ([]models.PriceList, error) {
+	rows, err := repo.db.Query("SELECT id, name, is_default, created_at FROM price_lists ORDER BY name, id")
+	if err != nil {
+		return nil, err
+	}
+
+	lists := make([]models.PriceList, 0)
+	for rows.Next() {
+		var list models.PriceList
+		if err := rows.Scan(&list.ID, &list.Name, &list.IsDefault, &list.CreatedAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		lists = append(lists, list)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range lists {
+		if err := loadPriceListItems(repo.db, &lists[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	return lists, nil
+}
+
+func (repo *PriceListRepository) GetByID(id int) (*models.PriceList, error) {
+	list, err := getPriceList(repo.db, id, "")
+	if err != nil {
+		return nil, err
+	}
+	if err := loadPriceListItems(repo.db, list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (repo *PriceListRepository) Create(list *models.PriceList, meta models.RequestMeta) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := validatePriceList(tx, list); err != nil {
+		return err
+	}
+	if list.IsDefault {
+		if _, err := tx.Exec("UPDATE price_lists SET is_default = FALSE WHERE is_default"); err != nil {
+			return err
+		}
+	}
+
+	err = tx.QueryRow("INSERT INTO price_lists (name, is_default) VALUES ($1, $2) RETURNING id, created_at", list.Name, list.IsDefault).Scan(&list.ID, &list.CreatedAt)
+	if err != nil {
+		return err
+	}
+	if err := insertPriceListItems(tx, list); err != nil {
+		return err
+	}
+
+	err = insertAuditLog(tx, meta, models.AuditActionCreate, models.AuditEntityPriceList, list.ID, nil, list)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (repo *PriceListRepository) Update(list *models.PriceList, meta models.RequestMeta) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	before, err := getPriceList(tx, list.ID, "FOR UPDATE")
+	if err != nil {
+		return err
+	}
+	if err := loadPriceListItems(tx, before); err != nil {
+		return err
+	}
+
+	if err := validatePriceList(tx, list); err != nil {
+		return err
+	}
+	if list.IsDefault {
+		if _, err := tx.Exec("UPDATE price_lists SET is_default = FALSE WHERE is_default AND id <> $1", list.ID); err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.Exec("UPDATE price_lists SET name = $1, is_default = $2 WHERE id = $3", list.Name, list.IsDefault, list.ID)
+	if err != nil {
+		return err
+	}
+	list.CreatedAt = before.CreatedAt
+
+	_, err = tx.Exec("DELETE FROM price_list_items WHERE price_list_id = $1", list.ID)
+	if err != nil {
+		return err
+	}
+	if err := insertPriceListItems(tx, list); err != nil {
+		return err
+	}
+
+	err = insertAuditLog(tx, meta, models.AuditActionUpdate, models.AuditEntityPriceList, list.ID, before, list)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Delete removes a price list. Groups that sold at it fall back to the
+// default list; past sales keep their lines but lose the link.
+func (repo *PriceListRepository) Delete(id int, meta models.RequestMeta) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	before, err := getPriceList(tx, id, "FOR UPDATE")
+	if err != nil {
+		return err
+	}
+	if err := loadPriceListItems(tx, before); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("DELETE FROM price_lists WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	err = insertAuditLog(tx, meta, models.AuditActionDelete, models.AuditEntityPriceList, id, before, nil)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// getPriceList loads a price list without its items. lock is an optional
+// row locking clause.
+func getPriceList(db queryer, id int, lock string) (*models.PriceList, error) {
+	var list models.PriceList
+	err := db.QueryRow("SELECT id, name, is_default, created_at FROM price_lists WHERE id = $1 "+lock, id).Scan(&list.ID, &list.Name, &list.IsDefault, &list.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, errors.New("Price list not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+func loadPriceListItems(db queryer, list *models.PriceList) error {
+	rows, err := db.Query(`
+		SELECT pli.product_id, p.name, pli.min_quantity, pli.price
+		FROM price_list_items pli
+		JOIN products p ON p.id = pli.product_id
+		WHERE pli.price_list_id = $1
+		ORDER BY p.name, pli.product_id, pli.min_quantity
+	`, list.ID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	list.Items = make([]models.PriceListItem, 0)
+	for rows.Next() {
+		var item models.PriceListItem
+		if err := rows.Scan(&item.ProductID, &item.ProductName, &item.MinQuantity, &item.Price); err != nil {
+			return err
+		}
+		list.Items = append(list.Items, item)
+	}
+	return rows.Err()
+}
+
+// validatePriceList checks a list's items and sorts them by product and
+// quantity. A missing min_quantity means from one unit.
+func validatePriceList(tx *sql.Tx, list *models.PriceList) error {
+	if list.Name == "" {
+		return errors.New("Name is required")
+	}
+	var taken bool
+	err := tx.QueryRow("SELECT EXISTS (SELECT 1 FROM price_lists WHERE name = $1 AND id <> $2)", list.Name, list.ID).Scan(&taken)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return fmt.Errorf("price list %q already exists", list.Name)
+	}
+
+	if list.Items == nil {
+		list.Items = make([]models.PriceListItem, 0)
+	}
+	type tier struct {
+		productID   int
+		minQuantity models.Quantity
+	}
+	seen := make(map[tier]bool)
+	for i := range list.Items {
+		item := &list.Items[i]
+		if item.MinQuantity == 0 {
+			item.MinQuantity = models.Units(1)
+		}
+		if item.MinQuantity < 0 {
+			return fmt.Errorf("invalid min_quantity for product id %d", item.ProductID)
+		}
+		if item.Price < 0 {
+			return fmt.Errorf("price for product id %d must not be negative", item.ProductID)
+		}
+		key := tier{item.ProductID, item.MinQuantity}
+		if seen[key] {
+			return fmt.Errorf("product id %d is listed twice from %s", item.ProductID, item.MinQuantity)
+		}
+		seen[key] = true
+
+		err := tx.QueryRow("SELECT name FROM products WHERE id = $1", item.ProductID).Scan(&item.ProductName)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("product id %d not found", item.ProductID)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	sort.SliceStable(list.Items, func(a, b int) bool {
+		if list.Items[a].ProductID != list.Items[b].ProductID {
+			return list.Items[a].ProductID < list.Items[b].ProductID
+		}
+		return list.Items[a].MinQuantity < list.Items[b].MinQuantity
+	})
+	return nil
+}
+
+func insertPriceListItems(tx *sql.Tx, list *models.PriceList) error {
+	for _, item := range list.Items {
+		_, err := tx.Exec("INSERT INTO price_list_items (price_list_id, product_id, min_quantity, price) VALUES ($1, $2, $3, $4)", list.ID, item.ProductID, item.MinQuantity, item.Price)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// listPrice is the price a price list sets for a product at the quantity
+// sold.
+type listPrice struct {
+	priceListID int
+	name        string
+	price       int
+}
+
+// customerPriceLists returns the price lists that apply to a sale, most
+// specific first: the customer's group list, then the default list.
+func customerPriceLists(tx *sql.Tx, customerID *int) ([]int, error) {
+	lists := make([]int, 0, 2)
+	if customerID != nil {
+		var groupList *int
+		query := `
+			SELECT cg.price_list_id
+			FROM customers c
+			LEFT JOIN customer_groups cg ON cg.id = c.customer_group_id
+			WHERE c.id = $1`
+		err := tx.QueryRow(query, *customerID).Scan(&groupList)
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("customer id %d not found", *customerID)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if groupList != nil {
+			lists = append(lists, *groupList)
+		}
+	}
+
+	var defaultList int
+	err := tx.QueryRow("SELECT id FROM price_lists WHERE is_default").Scan(&defaultList)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	if err == nil && (len(lists) == 0 || lists[0] != defaultList) {
+		lists = append(lists, defaultList)
+	}
+	return lists, nil
+}
+
+// resolveListPrices picks each product's price from the first list in lists
+// with a quantity break at or below the quantity sold. Products the lists do
+// not price at that quantity are left out and sell at their regular price.
+func resolveListPrices(tx *sql.Tx, lists []int, quantities map[int]models.Quantity) (map[int]listPrice, error) {
+	prices := make(map[int]listPrice)
+	if len(lists) == 0 {
+		return prices, nil
+	}
+
+	query := `
+		SELECT pl.id, pl.name, pli.price
+		FROM price_list_items pli
+		JOIN price_lists pl ON pl.id = pli.price_list_id
+		WHERE pli.product_id = $1 AND pli.min_quantity <= $2 AND pl.id = ANY ($3)
+		ORDER BY ARRAY_POSITION($3, pl.id), pli.min_quantity DESC
+		LIMIT 1`
+	for productID, quantity := range quantities {
+		var price listPrice
+		err := tx.QueryRow(query, productID, quantity, pq.Array(lists)).Scan(&price.priceListID, &price.name, &price.price)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		prices[productID] = price
+	}
+	return prices, nil
+}
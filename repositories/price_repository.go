@@ -0,0 +1,174 @@
+package repositories
+
+import (
+	"cashier-api/models"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+type PriceRepository struct {
+	db *sql.DB
+}
+
+func NewPriceRepository(db *sql.DB) *PriceRepository {
+	return &PriceRepository{db: db}
+}
+
+// GetHistory lists a product's past, current and scheduled prices, oldest
+// first.
+func (repo *PriceRepository) GetHistory(productID int) (*models.ProductPriceHistory, error) {
+	history := models.ProductPriceHistory{ProductID: productID}
+	err := repo.db.QueryRow("SELECT p.name, "+priceColumn+" FROM products p WHERE p.id = $1", productID).Scan(&history.ProductName, &history.Price)
+	if err == sql.ErrNoRows {
+		return nil, errors.New("Product not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT id, product_id, price, effective_at, effective_at > NOW(), created_by, created_at
+		FROM product_prices
+		WHERE product_id = $1
+		ORDER BY effective_at, id`
+	rows, err := repo.db.Query(query, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history.Prices = make([]models.ProductPrice, 0)
+	current := -1
+	for rows.Next() {
+		var price models.ProductPrice
+		var scheduled bool
+		if err := rows.Scan(&price.ID, &price.ProductID, &price.Price, &price.EffectiveAt, &scheduled, &price.CreatedBy, &price.CreatedAt); err != nil {
+			return nil, err
+		}
+		price.Status = models.PriceStatusPast
+		if scheduled {
+			price.Status = models.PriceStatusScheduled
+		} else {
+			current = len(history.Prices)
+		}
+		history.Prices = append(history.Prices, price)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if current >= 0 {
+		history.Prices[current].Status = models.PriceStatusCurrent
+	}
+
+	return &history, nil
+}
+
+// Schedule changes a product's base price at a future time, or at once when
+// no time is given.
+func (repo *PriceRepository) Schedule(productID int, req models.ProductPriceRequest, meta models.RequestMeta) (*models.ProductPrice, error) {
+	if req.Price < 0 {
+		return nil, errors.New("Price must not be negative")
+	}
+	var effectiveAt *time.Time
+	if req.EffectiveAt != "" {
+		t, err := models.ParseStoreTime(req.EffectiveAt)
+		if err != nil {
+			return nil, err
+		}
+		if !t.After(time.Now()) {
+			return nil, errors.New("effective_at must be in the future; leave it out to change the price now")
+		}
+		effectiveAt = &t
+	}
+
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := getProductForUpdate(tx, productID); err != nil {
+		return nil, err
+	}
+
+	price, err := insertProductPrice(tx, productID, req.Price, effectiveAt, meta)
+	if err != nil {
+		return nil, err
+	}
+	if effectiveAt == nil {
+		if _, err := tx.Exec("UPDATE products SET price = $1 WHERE id = $2", req.Price, productID); err != nil {
+			return nil, err
+		}
+	}
+
+	err = insertAuditLog(tx, meta, models.AuditActionCreate, models.AuditEntityProductPrice, productID, nil, price)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return price, nil
+}
+
+// Cancel removes a price change that has not taken effect yet.
+func (repo *PriceRepository) Cancel(productID, priceID int, meta models.RequestMeta) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var price models.ProductPrice
+	var scheduled bool
+	query := `
+		SELECT id, product_id, price, effective_at, effective_at > NOW(), created_by, created_at
+		FROM product_prices
+		WHERE id = $1 AND product_id = $2
+		FOR UPDATE`
+	err = tx.QueryRow(query, priceID, productID).Scan(&price.ID, &price.ProductID, &price.Price, &price.EffectiveAt, &scheduled, &price.CreatedBy, &price.CreatedAt)
+	if err == sql.ErrNoRows {
+		return errors.New("Price not found")
+	}
+	if err != nil {
+		return err
+	}
+	if !scheduled {
+		return errors.New("Only scheduled prices can be cancelled")
+	}
+	price.Status = models.PriceStatusScheduled
+
+	if _, err := tx.Exec("DELETE FROM product_prices WHERE id = $1", priceID); err != nil {
+		return err
+	}
+
+	err = insertAuditLog(tx, meta, models.AuditActionDelete, models.AuditEntityProductPrice, productID, price, nil)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// insertProductPrice adds an entry to a product's price history, taking
+// effect at the start of the database transaction when effectiveAt is nil.
+func insertProductPrice(tx *sql.Tx, productID, amount int, effectiveAt *time.Time, meta models.RequestMeta) (*models.ProductPrice, error) {
+	price := models.ProductPrice{ProductID: productID, Price: amount, Status: models.PriceStatusCurrent, CreatedBy: meta.ActorName()}
+	if effectiveAt != nil {
+		price.Status = models.PriceStatusScheduled
+	}
+
+	query := `
+		INSERT INTO product_prices (product_id, price, effective_at, created_by)
+		VALUES ($1, $2, COALESCE($3, NOW()), $4)
+		RETURNING id, effective_at, created_at`
+	err := tx.QueryRow(query, productID, amount, effectiveAt, price.CreatedBy).Scan(&price.ID, &price.EffectiveAt, &price.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &price, nil
+}
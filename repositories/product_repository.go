@@ -3,7 +3,9 @@ package repositories
 import (
 	"cashier-api/models"
 	"database/sql"
+	"encoding/json"
 	"errors"
+	"fmt"
 )
 
 type ProductRepository struct {
@@ -14,14 +16,77 @@ func NewProductRepository(db *sql.DB) *ProductRepository {
 	return &ProductRepository{db: db}
 }
 
-func (repo *ProductRepository) GetAll(nameFilter string) ([]models.Product, error) {
-	query := "SELECT id, name, price, stock FROM products"
+const productColumns = "p.id, p.name, " + priceColumn + ", p.stock, p.category_id, p.cost, p.costing_method, p.min_stock, p.reorder_qty, p.supplier_id, p.track_lots, p.is_composite, p.is_gift_card, " + variantColumns + ", " + unitColumns + ", " + barcodeColumns
+
+const variantColumns = "p.parent_id, p.variant_options, COALESCE(p.sku, ''), COALESCE(p.barcode, '')"
+
+const unitColumns = `p.unit, p.purchase_unit, COALESCE((
+	SELECT JSON_AGG(JSON_BUILD_OBJECT('name', pu.name, 'factor', pu.factor) ORDER BY pu.factor, pu.name)
+	FROM product_units pu WHERE pu.product_id = p.id
+), '[]')`
+
+const barcodeColumns = `COALESCE((
+	SELECT JSON_AGG(JSON_BUILD_OBJECT('barcode', pb.barcode, 'kind', pb.kind) ORDER BY pb.barcode)
+	FROM product_barcodes pb WHERE pb.product_id = p.id
+), '[]')`
+
+// priceColumn is a product's base price from its price history as in effect
+// at the start of the database transaction, so a checkout prices every line
+// as of one moment.
+const priceColumn = `COALESCE((
+	SELECT pp.price FROM product_prices pp
+	WHERE pp.product_id = p.id AND pp.effective_at <= NOW()
+	ORDER BY pp.effective_at DESC, pp.id DESC
+	LIMIT 1
+), p.price)`
+
+// productQuery selects products as seen from an outlet: stock and price are
+// the outlet's own when outletID is set, the consolidated stock and base
+// price otherwise. Further arguments start at $2 in both cases.
+func productQuery(outletID int) (string, []interface{}) {
+	if outletID == 0 {
+		return "SELECT " + productColumns + " FROM products p WHERE $1 = 0", []interface{}{0}
+	}
+
+	query := `
+		SELECT p.id, p.name, COALESCE(os.price, ` + priceColumn + `), COALESCE(os.stock, 0), p.category_id, p.cost, p.costing_method, p.min_stock, p.reorder_qty, p.supplier_id, p.track_lots, p.is_composite, p.is_gift_card, ` + variantColumns + `, ` + unitColumns + `, ` + barcodeColumns + `
+		FROM products p
+		LEFT JOIN outlet_stocks os ON os.product_id = p.id AND os.outlet_id = $1
+		WHERE 1 = 1`
+	return query, []interface{}{outletID}
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanProduct(row rowScanner, product *models.Product) error {
+	var options, units, barcodes []byte
+	err := row.Scan(&product.ID, &product.Name, &product.Price, &product.Stock, &product.CategoryID, &product.Cost, &product.CostingMethod, &product.MinStock, &product.ReorderQty, &product.SupplierID, &product.TrackLots, &product.IsComposite, &product.IsGiftCard,
+		&product.ParentID, &options, &product.SKU, &product.Barcode, &product.Unit, &product.PurchaseUnit, &units, &barcodes)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(units, &product.Units); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(barcodes, &product.Barcodes); err != nil {
+		return err
+	}
+	if options == nil {
+		return nil
+	}
+	return json.Unmarshal(options, &product.Options)
+}
+
+func (repo *ProductRepository) GetAll(nameFilter string, outletID int) ([]models.Product, error) {
+	query, args := productQuery(outletID)
 
-	args := []interface{}{}
 	if nameFilter != "" {
-		query += " WHERE name ILIKE $1"
+		query += " AND p.name ILIKE $2"
 		args = append(args, "%"+nameFilter+"%")
 	}
+	query += " ORDER BY p.id"
 
 	rows, err := repo.db.Query(query, args...)
 	if err != nil {
@@ -32,7 +97,7 @@ func (repo *ProductRepository) GetAll(nameFilter string) ([]models.Product, erro
 	products := make([]models.Product, 0)
 	for rows.Next() {
 		var product models.Product
-		err := rows.Scan(&product.ID, &product.Name, &product.Price, &product.Stock)
+		err := scanProduct(rows, &product)
 		if err != nil {
 			return nil, err
 		}
@@ -42,17 +107,87 @@ func (repo *ProductRepository) GetAll(nameFilter string) ([]models.Product, erro
 	return products, nil
 }
 
-func (repo *ProductRepository) Create(product *models.Product) error {
-	query := "INSERT INTO products (name, price, stock) VALUES ($1, $2, $3) RETURNING id"
-	err := repo.db.QueryRow(query, product.Name, product.Price, product.Stock).Scan(&product.ID)
-	return err
+func (repo *ProductRepository) Create(product *models.Product, meta models.RequestMeta) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if product.CostingMethod == "" {
+		product.CostingMethod = models.CostingMethodAverage
+	}
+	if err := validateProduct(product); err != nil {
+		return err
+	}
+	options, err := prepareVariant(tx, product)
+	if err != nil {
+		return err
+	}
+
+	// The opening stock comes in at the given cost through the ledger, which
+	// also sets products.cost or opens the first FIFO layer.
+	openingCost := product.Cost
+	query := `
+		INSERT INTO products (name, price, stock, category_id, cost, costing_method, min_stock, reorder_qty, supplier_id, track_lots, is_composite, is_gift_card, parent_id, variant_options, sku, barcode, unit, purchase_unit)
+		VALUES ($1, $2, 0, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, NULLIF($14, ''), NULLIF($15, ''), $16, $17)
+		RETURNING id`
+	err = tx.QueryRow(query, product.Name, product.Price, product.CategoryID, openingCost, product.CostingMethod, product.MinStock, product.ReorderQty, product.SupplierID, product.TrackLots, product.IsComposite, product.IsGiftCard,
+		product.ParentID, options, product.SKU, product.Barcode, product.Unit, product.PurchaseUnit).Scan(&product.ID)
+	if err != nil {
+		return err
+	}
+	if err := saveProductUnits(tx, product); err != nil {
+		return err
+	}
+	if err := saveProductBarcodes(tx, product); err != nil {
+		return err
+	}
+	if _, err := insertProductPrice(tx, product.ID, product.Price, nil, meta); err != nil {
+		return err
+	}
+
+	if product.Stock != 0 && product.IsComposite {
+		return errors.New("Composite products hold no stock")
+	}
+	if product.Stock != 0 && product.IsGiftCard {
+		return errors.New("Gift cards hold no stock")
+	}
+	if product.Stock != 0 && meta.OutletID == 0 {
+		return errors.New("Opening stock requires terminal authentication")
+	}
+
+	movement := models.StockMovement{
+		OutletID:      meta.OutletID,
+		ProductID:     product.ID,
+		Quantity:      product.Stock,
+		UnitCost:      openingCost,
+		Reason:        models.StockReasonAdjustment,
+		ReferenceType: models.StockReferenceProduct,
+		ReferenceID:   product.ID,
+		Note:          "Opening balance",
+		Actor:         meta.ActorName(),
+	}
+	err = applyStockMovement(tx, &movement)
+	if err != nil {
+		return err
+	}
+
+	err = insertAuditLog(tx, meta, models.AuditActionCreate, models.AuditEntityProduct, product.ID, nil, product)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
-func (repo *ProductRepository) GetByID(id int) (*models.Product, error) {
-	query := "SELECT id, name, price, stock FROM products WHERE id = $1"
+func (repo *ProductRepository) GetByID(id int, outletID int) (*models.Product, error) {
+	query, args := productQuery(outletID)
+	query += " AND p.id = $2"
+	args = append(args, id)
 
 	var product models.Product
-	err := repo.db.QueryRow(query, id).Scan(&product.ID, &product.Name, &product.Price, &product.Stock)
+	err := scanProduct(repo.db.QueryRow(query, args...), &product)
 	if err == sql.ErrNoRows {
 		return nil, errors.New("Product not found")
 	}
@@ -63,39 +198,279 @@ func (repo *ProductRepository) GetByID(id int) (*models.Product, error) {
 	return &product, nil
 }
 
-func (repo *ProductRepository) Update(product *models.Product) error {
-	query := "UPDATE products SET name = $1, price = $2, stock = $3 WHERE id = $4"
-	result, err := repo.db.Exec(query, product.Name, product.Price, product.Stock, product.ID)
+// Lookup finds the product a scanned barcode belongs to.
+func (repo *ProductRepository) Lookup(barcode string, outletID int) (*models.BarcodeMatch, error) {
+	return findBarcode(repo.db, barcode, outletID)
+}
+
+func (repo *ProductRepository) Update(product *models.Product, meta models.RequestMeta) error {
+	tx, err := repo.db.Begin()
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
 
-	rows, err := result.RowsAffected()
+	before, err := getProductForUpdate(tx, product.ID)
 	if err != nil {
 		return err
 	}
 
-	if rows == 0 {
-		return errors.New("Product not found")
+	if product.CostingMethod == "" {
+		product.CostingMethod = before.CostingMethod
+	}
+	if err := validateProduct(product); err != nil {
+		return err
+	}
+	options, err := prepareVariant(tx, product)
+	if err != nil {
+		return err
+	}
+	if product.IsComposite && !before.IsComposite && before.Stock != 0 {
+		return errors.New("cannot make a product composite while it holds stock")
+	}
+	if product.IsGiftCard && !before.IsGiftCard && before.Stock != 0 {
+		return errors.New("cannot make a product a gift card while it holds stock")
 	}
 
-	return nil
+	query := `
+		UPDATE products
+		SET name = $1, price = $2, category_id = $3, min_stock = $4, reorder_qty = $5, supplier_id = $6, is_composite = $7, is_gift_card = $8,
+			parent_id = $9, variant_options = $10, sku = NULLIF($11, ''), barcode = NULLIF($12, ''), unit = $13, purchase_unit = $14
+		WHERE id = $15`
+	_, err = tx.Exec(query, product.Name, product.Price, product.CategoryID, product.MinStock, product.ReorderQty, product.SupplierID, product.IsComposite, product.IsGiftCard,
+		product.ParentID, options, product.SKU, product.Barcode, product.Unit, product.PurchaseUnit, product.ID)
+	if err != nil {
+		return err
+	}
+	if err := saveProductUnits(tx, product); err != nil {
+		return err
+	}
+	if err := saveProductBarcodes(tx, product); err != nil {
+		return err
+	}
+	// A price given here takes effect at once; scheduled changes go through
+	// the price history.
+	if product.Price != before.Price {
+		if _, err := insertProductPrice(tx, product.ID, product.Price, nil, meta); err != nil {
+			return err
+		}
+	}
+
+	if product.CostingMethod != before.CostingMethod {
+		if err := switchCostingMethod(tx, before, product.CostingMethod); err != nil {
+			return err
+		}
+	}
+
+	if product.TrackLots != before.TrackLots {
+		if err := setLotTracking(tx, product.ID, product.TrackLots); err != nil {
+			return err
+		}
+	}
+
+	// Stock and cost are read-only here; they only change through stock
+	// movements.
+	err = tx.QueryRow("SELECT stock, cost FROM products WHERE id = $1", product.ID).Scan(&product.Stock, &product.Cost)
+	if err != nil {
+		return err
+	}
+	if meta.OutletID != 0 {
+		product.Stock, err = getOutletStock(tx, meta.OutletID, product.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = insertAuditLog(tx, meta, models.AuditActionUpdate, models.AuditEntityProduct, product.ID, before, product)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
-func (repo *ProductRepository) Delete(id int) error {
-	query := "DELETE FROM products WHERE id = $1"
-	result, err := repo.db.Exec(query, id)
+func (repo *ProductRepository) Delete(id int, meta models.RequestMeta) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	before, err := getProductForUpdate(tx, id)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("DELETE FROM products WHERE id = $1", id)
 	if err != nil {
 		return err
 	}
-	rows, err := result.RowsAffected()
+
+	err = insertAuditLog(tx, meta, models.AuditActionDelete, models.AuditEntityProduct, id, before, nil)
 	if err != nil {
 		return err
 	}
 
-	if rows == 0 {
-		return errors.New("Product not found")
+	return tx.Commit()
+}
+
+// getProductForUpdate loads a product and locks its row until the surrounding
+// transaction ends.
+func getProductForUpdate(tx *sql.Tx, id int) (*models.Product, error) {
+	query := "SELECT " + productColumns + " FROM products p WHERE p.id = $1 FOR UPDATE OF p"
+
+	var product models.Product
+	err := scanProduct(tx.QueryRow(query, id), &product)
+	if err == sql.ErrNoRows {
+		return nil, errors.New("Product not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &product, nil
+}
+
+// prepareVariant checks a variant's options against its parent, names it
+// after the parent when no name is given, and checks that its option
+// combination, SKU and barcode are not used by another product. It returns
+// the options as JSON, or nil for a product without a parent.
+func prepareVariant(tx *sql.Tx, product *models.Product) (any, error) {
+	var options any
+	if product.ParentID == nil {
+		if len(product.Options) > 0 {
+			return nil, errors.New("options require a parent_id")
+		}
+		product.Options = nil
+	} else {
+		parent, err := getParentProduct(tx, *product.ParentID, "FOR SHARE")
+		if err != nil {
+			return nil, err
+		}
+		if err := parent.ValidateOptions(product.Options); err != nil {
+			return nil, err
+		}
+		if product.Name == "" {
+			product.Name = parent.VariantName(product.Options)
+		}
+		if product.CategoryID == nil {
+			product.CategoryID = parent.CategoryID
+		}
+
+		data, err := json.Marshal(product.Options)
+		if err != nil {
+			return nil, err
+		}
+		options = string(data)
+
+		var taken bool
+		err = tx.QueryRow("SELECT EXISTS (SELECT 1 FROM products WHERE parent_id = $1 AND variant_options = $2::JSONB AND id <> $3)", *product.ParentID, options, product.ID).Scan(&taken)
+		if err != nil {
+			return nil, err
+		}
+		if taken {
+			return nil, errors.New("A variant with these options already exists")
+		}
+	}
+
+	if product.Name == "" {
+		return nil, errors.New("Name is required")
+	}
+
+	var skuTaken, barcodeTaken bool
+	query := "SELECT EXISTS (SELECT 1 FROM products WHERE sku = $1 AND id <> $3), EXISTS (SELECT 1 FROM product_barcodes WHERE barcode = $2 AND product_id <> $3)"
+	err := tx.QueryRow(query, product.SKU, product.Barcode, product.ID).Scan(&skuTaken, &barcodeTaken)
+	if err != nil {
+		return nil, err
+	}
+	if product.SKU != "" && skuTaken {
+		return nil, fmt.Errorf("SKU %q is already used", product.SKU)
+	}
+	if product.Barcode != "" && barcodeTaken {
+		return nil, fmt.Errorf("barcode %q is already used", product.Barcode)
+	}
+
+	return options, nil
+}
+
+func validateProduct(product *models.Product) error {
+	if product.Price < 0 {
+		return errors.New("Price must not be negative")
+	}
+	if product.Cost < 0 {
+		return errors.New("Cost must not be negative")
+	}
+	if product.MinStock < 0 || product.ReorderQty < 0 {
+		return errors.New("min_stock and reorder_qty must not be negative")
+	}
+	if product.IsComposite && product.TrackLots {
+		return errors.New("Composite products cannot track lots")
+	}
+	if product.IsGiftCard && (product.IsComposite || product.TrackLots) {
+		return errors.New("Gift cards cannot be composite or track lots")
+	}
+	if product.CostingMethod != models.CostingMethodAverage && product.CostingMethod != models.CostingMethodFIFO {
+		return fmt.Errorf("invalid costing method %q", product.CostingMethod)
 	}
 
-	return err
+	if product.Unit == "" {
+		product.Unit = "pcs"
+	}
+	if product.Units == nil {
+		product.Units = make([]models.ProductUnit, 0)
+	}
+	names := map[string]bool{product.Unit: true}
+	for _, unit := range product.Units {
+		if unit.Name == "" {
+			return errors.New("Unit name is required")
+		}
+		if names[unit.Name] {
+			return fmt.Errorf("unit %q is defined twice", unit.Name)
+		}
+		if unit.Factor <= 0 {
+			return fmt.Errorf("invalid factor for unit %q", unit.Name)
+		}
+		names[unit.Name] = true
+	}
+	if product.PurchaseUnit != "" && !names[product.PurchaseUnit] {
+		return fmt.Errorf("purchase unit %q is not one of the product's units", product.PurchaseUnit)
+	}
+	return nil
+}
+
+// saveProductUnits replaces the units a product is sold or bought in.
+func saveProductUnits(tx *sql.Tx, product *models.Product) error {
+	_, err := tx.Exec("DELETE FROM product_units WHERE product_id = $1", product.ID)
+	if err != nil {
+		return err
+	}
+	for _, unit := range product.Units {
+		_, err = tx.Exec("INSERT INTO product_units (product_id, name, factor) VALUES ($1, $2, $3)", product.ID, unit.Name, unit.Factor)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unitFactor returns how many base units of a product one unit holds. An
+// empty unit is the base unit.
+func unitFactor(db queryer, productID int, unit string) (models.Quantity, error) {
+	query := `
+		SELECT CASE WHEN $2 = '' OR p.unit = $2 THEN 1 ELSE pu.factor END
+		FROM products p
+		LEFT JOIN product_units pu ON pu.product_id = p.id AND pu.name = $2
+		WHERE p.id = $1`
+	var factor *models.Quantity
+	err := db.QueryRow(query, productID, unit).Scan(&factor)
+	if err == sql.ErrNoRows {
+		return 0, fmt.Errorf("product id %d not found", productID)
+	}
+	if err != nil {
+		return 0, err
+	}
+	if factor == nil {
+		return 0, fmt.Errorf("unit %q is not defined for product id %d", unit, productID)
+	}
+	return *factor, nil
 }
@@ -0,0 +1,523 @@
+package repositories
+
+import (
+	"cashier-api/models"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+type PurchaseOrderRepository struct {
+	db *sql.DB
+}
+
+func NewPurchaseOrderRepository(db *sql.DB) *PurchaseOrderRepository {
+	return &PurchaseOrderRepository{db: db}
+}
+
+const purchaseOrderColumns = `
+	po.id, po.outlet_id, po.supplier_id, s.name, po.status, po.note, po.created_by, po.created_at, po.sent_at, po.closed_at,
+	COALESCE((SELECT SUM(ROUND(l.quantity * l.unit_cost))::BIGINT FROM purchase_order_lines l WHERE l.purchase_order_id = po.id), 0)
+`
+
+func (repo *PurchaseOrderRepository) GetAll(filter models.PurchaseOrderFilter) ([]models.PurchaseOrder, error) {
+	query := "SELECT " + purchaseOrderColumns + " FROM purchase_orders po JOIN suppliers s ON s.id = po.supplier_id WHERE 1 = 1"
+
+	args := []interface{}{}
+	if filter.SupplierID != 0 {
+		args = append(args, filter.SupplierID)
+		query += fmt.Sprintf(" AND po.supplier_id = $%d", len(args))
+	}
+	if filter.OutletID != 0 {
+		args = append(args, filter.OutletID)
+		query += fmt.Sprintf(" AND po.outlet_id = $%d", len(args))
+	}
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		query += fmt.Sprintf(" AND po.status = $%d", len(args))
+	}
+	query += " ORDER BY po.id DESC"
+
+	rows, err := repo.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	orders := make([]models.PurchaseOrder, 0)
+	for rows.Next() {
+		var order models.PurchaseOrder
+		err := rows.Scan(&order.ID, &order.OutletID, &order.SupplierID, &order.SupplierName, &order.Status, &order.Note, &order.CreatedBy, &order.CreatedAt, &order.SentAt, &order.ClosedAt, &order.TotalAmount)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, order)
+	}
+
+	return orders, rows.Err()
+}
+
+func (repo *PurchaseOrderRepository) GetByID(id int) (*models.PurchaseOrder, error) {
+	return getPurchaseOrder(repo.db, id, "")
+}
+
+func (repo *PurchaseOrderRepository) Create(req models.PurchaseOrderRequest, meta models.RequestMeta) (*models.PurchaseOrder, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := getSupplier(tx, req.SupplierID, ""); err != nil {
+		return nil, err
+	}
+
+	if req.OutletID == 0 {
+		req.OutletID = meta.OutletID
+	}
+	if req.OutletID == 0 {
+		return nil, errors.New("Outlet is required")
+	}
+	if _, err := getOutlet(tx, req.OutletID, ""); err != nil {
+		return nil, err
+	}
+
+	var id int
+	query := "INSERT INTO purchase_orders (outlet_id, supplier_id, status, note, created_by) VALUES ($1, $2, $3, $4, $5) RETURNING id"
+	err = tx.QueryRow(query, req.OutletID, req.SupplierID, models.PurchaseOrderStatusDraft, req.Note, meta.ActorName()).Scan(&id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := insertPurchaseOrderLines(tx, id, req.Lines); err != nil {
+		return nil, err
+	}
+
+	order, err := getPurchaseOrder(tx, id, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return order, nil
+}
+
+// Update replaces the supplier, note and lines of a draft order, and moves it
+// to another outlet when one is given.
+func (repo *PurchaseOrderRepository) Update(id int, req models.PurchaseOrderRequest) (*models.PurchaseOrder, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	order, err := getPurchaseOrder(tx, id, "FOR UPDATE OF po")
+	if err != nil {
+		return nil, err
+	}
+	if order.Status != models.PurchaseOrderStatusDraft {
+		return nil, errors.New("Only draft purchase orders can be changed")
+	}
+
+	if _, err := getSupplier(tx, req.SupplierID, ""); err != nil {
+		return nil, err
+	}
+
+	if req.OutletID == 0 {
+		req.OutletID = order.OutletID
+	}
+	if _, err := getOutlet(tx, req.OutletID, ""); err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec("UPDATE purchase_orders SET supplier_id = $1, note = $2, outlet_id = $3 WHERE id = $4", req.SupplierID, req.Note, req.OutletID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec("DELETE FROM purchase_order_lines WHERE purchase_order_id = $1", id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := insertPurchaseOrderLines(tx, id, req.Lines); err != nil {
+		return nil, err
+	}
+
+	order, err = getPurchaseOrder(tx, id, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return order, nil
+}
+
+func (repo *PurchaseOrderRepository) Delete(id int) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	order, err := getPurchaseOrder(tx, id, "FOR UPDATE OF po")
+	if err != nil {
+		return err
+	}
+	if order.Status != models.PurchaseOrderStatusDraft {
+		return errors.New("Only draft purchase orders can be deleted")
+	}
+
+	_, err = tx.Exec("DELETE FROM purchase_orders WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (repo *PurchaseOrderRepository) Send(id int) (*models.PurchaseOrder, error) {
+	return repo.transition(id, []string{models.PurchaseOrderStatusDraft}, models.PurchaseOrderStatusSent, "sent_at = NOW()")
+}
+
+// Close ends an order; anything still outstanding will not be received.
+func (repo *PurchaseOrderRepository) Close(id int) (*models.PurchaseOrder, error) {
+	from := []string{
+		models.PurchaseOrderStatusSent,
+		models.PurchaseOrderStatusPartiallyReceived,
+		models.PurchaseOrderStatusReceived,
+	}
+	return repo.transition(id, from, models.PurchaseOrderStatusClosed, "closed_at = NOW()")
+}
+
+func (repo *PurchaseOrderRepository) transition(id int, from []string, to string, set string) (*models.PurchaseOrder, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	order, err := getPurchaseOrder(tx, id, "FOR UPDATE OF po")
+	if err != nil {
+		return nil, err
+	}
+
+	allowed := false
+	for _, status := range from {
+		if order.Status == status {
+			allowed = true
+		}
+	}
+	if !allowed {
+		return nil, fmt.Errorf("cannot change purchase order from %s to %s", order.Status, to)
+	}
+	if to == models.PurchaseOrderStatusSent && len(order.Lines) == 0 {
+		return nil, errors.New("Purchase order has no lines")
+	}
+
+	_, err = tx.Exec("UPDATE purchase_orders SET status = $1, "+set+" WHERE id = $2", to, id)
+	if err != nil {
+		return nil, err
+	}
+
+	order, err = getPurchaseOrder(tx, id, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return order, nil
+}
+
+// Receive books a goods receipt against the order. This is the single path
+// through which purchased stock enters the ledger.
+func (repo *PurchaseOrderRepository) Receive(id int, req models.GoodsReceiptRequest, meta models.RequestMeta) (*models.GoodsReceipt, error) {
+	if len(req.Lines) == 0 {
+		return nil, errors.New("No receipt lines given")
+	}
+
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	order, err := getPurchaseOrder(tx, id, "FOR UPDATE OF po")
+	if err != nil {
+		return nil, err
+	}
+	if order.Status != models.PurchaseOrderStatusSent && order.Status != models.PurchaseOrderStatusPartiallyReceived {
+		return nil, fmt.Errorf("cannot receive a %s purchase order", order.Status)
+	}
+	if order.OutletID != meta.OutletID {
+		return nil, errors.New("cannot receive a purchase order for another outlet")
+	}
+
+	lines := make(map[int]*models.PurchaseOrderLine, len(order.Lines))
+	for i := range order.Lines {
+		lines[order.Lines[i].ID] = &order.Lines[i]
+	}
+
+	receipt := models.GoodsReceipt{
+		PurchaseOrderID: id,
+		Note:            req.Note,
+		ReceivedBy:      meta.ActorName(),
+		Lines:           make([]models.GoodsReceiptLine, 0, len(req.Lines)),
+	}
+	for _, reqLine := range req.Lines {
+		line, ok := lines[reqLine.PurchaseOrderLineID]
+		if !ok {
+			return nil, fmt.Errorf("purchase order line id %d not found in purchase order %d", reqLine.PurchaseOrderLineID, id)
+		}
+		if reqLine.Quantity <= 0 {
+			return nil, fmt.Errorf("invalid quantity for purchase order line id %d", reqLine.PurchaseOrderLineID)
+		}
+		if reqLine.ExpiryDate != nil && !models.ValidDate(*reqLine.ExpiryDate) {
+			return nil, fmt.Errorf("invalid expiry_date for purchase order line id %d, use YYYY-MM-DD", reqLine.PurchaseOrderLineID)
+		}
+		if reqLine.BatchNumber == "" {
+			var trackLots bool
+			if err := tx.QueryRow("SELECT track_lots FROM products WHERE id = $1", line.ProductID).Scan(&trackLots); err != nil && err != sql.ErrNoRows {
+				return nil, err
+			}
+			if trackLots || reqLine.ExpiryDate != nil {
+				return nil, fmt.Errorf("batch_number is required for purchase order line id %d", reqLine.PurchaseOrderLineID)
+			}
+		}
+		if line.ReceivedQuantity+reqLine.Quantity > line.Quantity && !req.AllowOverReceipt {
+			return nil, fmt.Errorf("receiving %s of purchase order line id %d exceeds the outstanding %s; set allow_over_receipt to accept", reqLine.Quantity, line.ID, max(line.Quantity-line.ReceivedQuantity, 0))
+		}
+
+		line.ReceivedQuantity += reqLine.Quantity
+		receipt.Lines = append(receipt.Lines, models.GoodsReceiptLine{
+			PurchaseOrderLineID: line.ID,
+			ProductID:           line.ProductID,
+			Quantity:            reqLine.Quantity,
+			Unit:                line.Unit,
+			UnitCost:            line.UnitCost,
+			BatchNumber:         reqLine.BatchNumber,
+			ExpiryDate:          reqLine.ExpiryDate,
+		})
+	}
+
+	err = tx.QueryRow("INSERT INTO goods_receipts (purchase_order_id, note, received_by) VALUES ($1, $2, $3) RETURNING id, created_at", id, receipt.Note, receipt.ReceivedBy).Scan(&receipt.ID, &receipt.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range receipt.Lines {
+		line := &receipt.Lines[i]
+		query := "INSERT INTO goods_receipt_lines (goods_receipt_id, purchase_order_line_id, product_id, quantity, unit_cost, batch_number, expiry_date) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id"
+		err = tx.QueryRow(query, receipt.ID, line.PurchaseOrderLineID, line.ProductID, line.Quantity, line.UnitCost, line.BatchNumber, line.ExpiryDate).Scan(&line.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = tx.Exec("UPDATE purchase_order_lines SET received_quantity = received_quantity + $1 WHERE id = $2", line.Quantity, line.PurchaseOrderLineID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Stock comes in in base units, valued at exactly what the received
+	// quantity cost in the unit it was ordered in.
+	movements := make([]models.StockMovement, 0, len(receipt.Lines))
+	for _, line := range receipt.Lines {
+		quantity := line.Quantity.Mul(lines[line.PurchaseOrderLineID].Factor)
+		var lots []models.LotAllocation
+		if line.BatchNumber != "" {
+			lots = []models.LotAllocation{{BatchNumber: line.BatchNumber, ExpiryDate: line.ExpiryDate, Quantity: quantity}}
+		}
+		movements = append(movements, models.StockMovement{
+			OutletID:      order.OutletID,
+			ProductID:     line.ProductID,
+			Quantity:      quantity,
+			CostAmount:    line.Quantity.Times(line.UnitCost),
+			Reason:        models.StockReasonReceipt,
+			ReferenceType: models.StockReferenceGoodsReceipt,
+			ReferenceID:   receipt.ID,
+			Note:          fmt.Sprintf("PO #%d", id),
+			Actor:         meta.ActorName(),
+			Lots:          lots,
+		})
+	}
+	sort.SliceStable(movements, func(i, j int) bool {
+		return movements[i].ProductID < movements[j].ProductID
+	})
+	for i := range movements {
+		if err := applyStockMovement(tx, &movements[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	status := models.PurchaseOrderStatusReceived
+	for _, line := range lines {
+		if line.ReceivedQuantity < line.Quantity {
+			status = models.PurchaseOrderStatusPartiallyReceived
+			break
+		}
+	}
+	_, err = tx.Exec("UPDATE purchase_orders SET status = $1 WHERE id = $2", status, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &receipt, nil
+}
+
+// GetOpenReport lists sent and partially received orders per supplier with
+// the value still to be delivered.
+func (repo *PurchaseOrderRepository) GetOpenReport(supplierID, outletID int) (*models.OpenPurchaseOrderReport, error) {
+	query := `
+		SELECT
+			po.id, po.status, po.created_at, po.supplier_id, s.name,
+			COALESCE(SUM(ROUND(l.quantity * l.unit_cost))::BIGINT, 0),
+			COALESCE(SUM(ROUND(l.received_quantity * l.unit_cost))::BIGINT, 0),
+			COALESCE(SUM(GREATEST(l.quantity - l.received_quantity, 0) * l.factor), 0),
+			COALESCE(SUM(ROUND(GREATEST(l.quantity - l.received_quantity, 0) * l.unit_cost))::BIGINT, 0)
+		FROM purchase_orders po
+		JOIN suppliers s ON s.id = po.supplier_id
+		LEFT JOIN purchase_order_lines l ON l.purchase_order_id = po.id
+		WHERE po.status IN ($1, $2)
+	`
+	args := []interface{}{models.PurchaseOrderStatusSent, models.PurchaseOrderStatusPartiallyReceived}
+	if supplierID != 0 {
+		args = append(args, supplierID)
+		query += fmt.Sprintf(" AND po.supplier_id = $%d", len(args))
+	}
+	if outletID != 0 {
+		args = append(args, outletID)
+		query += fmt.Sprintf(" AND po.outlet_id = $%d", len(args))
+	}
+	query += " GROUP BY po.id, s.name ORDER BY s.name, po.supplier_id, po.id"
+
+	rows, err := repo.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	report := models.OpenPurchaseOrderReport{Suppliers: make([]models.SupplierOpenOrders, 0)}
+	for rows.Next() {
+		var order models.OpenPurchaseOrder
+		var supplierID int
+		var supplierName string
+		err := rows.Scan(&order.ID, &order.Status, &order.CreatedAt, &supplierID, &supplierName, &order.OrderedValue, &order.ReceivedValue, &order.OutstandingQuantity, &order.OutstandingValue)
+		if err != nil {
+			return nil, err
+		}
+
+		n := len(report.Suppliers)
+		if n == 0 || report.Suppliers[n-1].SupplierID != supplierID {
+			report.Suppliers = append(report.Suppliers, models.SupplierOpenOrders{
+				SupplierID:   supplierID,
+				SupplierName: supplierName,
+				Orders:       make([]models.OpenPurchaseOrder, 0),
+			})
+			n++
+		}
+
+		supplier := &report.Suppliers[n-1]
+		supplier.Orders = append(supplier.Orders, order)
+		supplier.OrderCount++
+		supplier.OutstandingValue += order.OutstandingValue
+		report.TotalOutstandingValue += order.OutstandingValue
+	}
+
+	return &report, rows.Err()
+}
+
+func insertPurchaseOrderLines(tx *sql.Tx, orderID int, lines []models.PurchaseOrderLineRequest) error {
+	for _, line := range lines {
+		if line.Quantity <= 0 {
+			return fmt.Errorf("invalid quantity for product id %d", line.ProductID)
+		}
+		if line.UnitCost < 0 {
+			return fmt.Errorf("invalid unit cost for product id %d", line.ProductID)
+		}
+
+		var baseUnit, purchaseUnit string
+		err := tx.QueryRow("SELECT unit, purchase_unit FROM products WHERE id = $1", line.ProductID).Scan(&baseUnit, &purchaseUnit)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("product id %d not found", line.ProductID)
+		}
+		if err != nil {
+			return err
+		}
+
+		// Lines are ordered in the product's purchase unit unless another
+		// unit is given.
+		unit := line.Unit
+		if unit == "" {
+			unit = purchaseUnit
+		}
+		if unit == "" {
+			unit = baseUnit
+		}
+		factor, err := unitFactor(tx, line.ProductID, unit)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec("INSERT INTO purchase_order_lines (purchase_order_id, product_id, quantity, unit_cost, unit, factor) VALUES ($1, $2, $3, $4, $5, $6)", orderID, line.ProductID, line.Quantity, line.UnitCost, unit, factor)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// getPurchaseOrder loads an order with its lines. lock is an optional row
+// locking clause for the order header.
+func getPurchaseOrder(db queryer, id int, lock string) (*models.PurchaseOrder, error) {
+	query := "SELECT " + purchaseOrderColumns + " FROM purchase_orders po JOIN suppliers s ON s.id = po.supplier_id WHERE po.id = $1 " + lock
+
+	var order models.PurchaseOrder
+	err := db.QueryRow(query, id).Scan(&order.ID, &order.OutletID, &order.SupplierID, &order.SupplierName, &order.Status, &order.Note, &order.CreatedBy, &order.CreatedAt, &order.SentAt, &order.ClosedAt, &order.TotalAmount)
+	if err == sql.ErrNoRows {
+		return nil, errors.New("Purchase order not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query(`
+		SELECT l.id, l.purchase_order_id, l.product_id, COALESCE(p.name, ''), l.quantity, l.unit_cost, l.received_quantity, l.unit, l.factor
+		FROM purchase_order_lines l
+		LEFT JOIN products p ON p.id = l.product_id
+		WHERE l.purchase_order_id = $1
+		ORDER BY l.id
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	order.Lines = make([]models.PurchaseOrderLine, 0)
+	for rows.Next() {
+		var line models.PurchaseOrderLine
+		err := rows.Scan(&line.ID, &line.PurchaseOrderID, &line.ProductID, &line.ProductName, &line.Quantity, &line.UnitCost, &line.ReceivedQuantity, &line.Unit, &line.Factor)
+		if err != nil {
+			return nil, err
+		}
+		line.Subtotal = line.Quantity.Times(line.UnitCost)
+		order.Lines = append(order.Lines, line)
+	}
+
+	return &order, rows.Err()
+}
@@ -0,0 +1,249 @@
+package repositories
+
+import (
+	"cashier-api/models"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type ReceivableRepository struct {
+	db *sql.DB
+}
+
+func NewReceivableRepository(db *sql.DB) *ReceivableRepository {
+	return &ReceivableRepository{db: db}
+}
+
+// GetReport returns every customer who owes something on account, with
+// what they owe aged by invoice.
+func (repo *ReceivableRepository) GetReport() (*models.ReceivablesReport, error) {
+	rows, err := repo.db.Query(`
+		SELECT c.id, c.name, COALESCE(c.phone, ''), c.credit_limit, `+invoiceColumns+`
+		FROM transaction_payments tp
+		JOIN transactions t ON t.id = tp.transaction_id
+		JOIN customers c ON c.id = t.customer_id
+		WHERE tp.method = $1
+		ORDER BY c.id, t.created_at, tp.id
+	`, models.PaymentOnAccount)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	report := models.ReceivablesReport{Customers: make([]models.Receivable, 0)}
+	var customer *models.Receivable
+	for rows.Next() {
+		var receivable models.Receivable
+		var invoice models.Invoice
+		err := rows.Scan(&receivable.CustomerID, &receivable.Name, &receivable.Phone, &receivable.CreditLimit,
+			&invoice.PaymentID, &invoice.TransactionID, &invoice.Amount, &invoice.Refunded, &invoice.Repaid, &invoice.DaysOutstanding, &invoice.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		invoice.Outstanding = invoice.Amount - invoice.Refunded - invoice.Repaid
+		if invoice.Outstanding <= 0 {
+			continue
+		}
+		if customer == nil || customer.CustomerID != receivable.CustomerID {
+			report.Customers = append(report.Customers, receivable)
+			customer = &report.Customers[len(report.Customers)-1]
+		}
+		customer.Invoices++
+		customer.Aging.Add(invoice)
+		report.Total.Add(invoice)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(report.Customers, func(i, j int) bool {
+		return report.Customers[i].Aging.Total > report.Customers[j].Aging.Total
+	})
+	return &report, nil
+}
+
+// GetStatement returns a customer's account between from and to: the
+// balance they owed at the start, every invoice, refund and repayment in
+// between with the balance after each, and the invoices still open now.
+func (repo *ReceivableRepository) GetStatement(customerID int, filter models.StatementFilter) (*models.Statement, error) {
+	customer, err := getCustomer(repo.db, customerID, "")
+	if err != nil {
+		return nil, err
+	}
+	statement := models.Statement{Customer: *customer}
+
+	entries := `
+		WITH entries AS (
+			SELECT 'invoice' AS kind, tp.transaction_id, NULL::INT AS refund_id, NULL::INT AS repayment_id,
+				tp.amount AS debit, 0 AS credit, '' AS note, t.created_at, tp.id AS seq
+			FROM transaction_payments tp
+			JOIN transactions t ON t.id = tp.transaction_id
+			WHERE t.customer_id = $1 AND tp.method = 'on_account'
+			UNION ALL
+			SELECT 'refund', tp.transaction_id, r.id, NULL, 0, rp.amount, r.reason, r.created_at, rp.id
+			FROM refund_payments rp
+			JOIN refunds r ON r.id = rp.refund_id
+			JOIN transaction_payments tp ON tp.id = rp.transaction_payment_id
+			JOIN transactions t ON t.id = tp.transaction_id
+			WHERE t.customer_id = $1 AND rp.method = 'on_account'
+			UNION ALL
+			SELECT 'repayment', NULL, NULL, cr.id, 0, cr.amount, cr.note, cr.created_at, cr.id
+			FROM customer_repayments cr
+			WHERE cr.customer_id = $1
+		)`
+
+	if filter.From != nil {
+		err = repo.db.QueryRow(entries+" SELECT COALESCE(SUM(debit - credit), 0) FROM entries WHERE created_at < $2", customerID, *filter.From).Scan(&statement.OpeningBalance)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	args := []interface{}{customerID}
+	where := ""
+	if filter.From != nil {
+		args = append(args, *filter.From)
+		where += fmt.Sprintf(" AND created_at >= $%d", len(args))
+	}
+	if filter.To != nil {
+		args = append(args, *filter.To)
+		where += fmt.Sprintf(" AND created_at < $%d", len(args))
+	}
+	rows, err := repo.db.Query(entries+`
+		SELECT kind, transaction_id, refund_id, repayment_id, debit, credit, note, created_at
+		FROM entries
+		WHERE 1 = 1`+where+`
+		ORDER BY created_at, seq
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	balance := statement.OpeningBalance
+	statement.Entries = make([]models.StatementEntry, 0)
+	for rows.Next() {
+		var entry models.StatementEntry
+		if err := rows.Scan(&entry.Kind, &entry.TransactionID, &entry.RefundID, &entry.RepaymentID, &entry.Debit, &entry.Credit, &entry.Note, &entry.CreatedAt); err != nil {
+			return nil, err
+		}
+		balance += entry.Debit - entry.Credit
+		entry.Balance = balance
+		statement.Entries = append(statement.Entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	statement.ClosingBalance = balance
+
+	statement.OpenInvoices, err = openInvoices(repo.db, customerID)
+	if err != nil {
+		return nil, err
+	}
+	for _, invoice := range statement.OpenInvoices {
+		statement.Aging.Add(invoice)
+	}
+	statement.AvailableCredit = max(customer.CreditLimit-statement.Aging.Total, 0)
+	return &statement, nil
+}
+
+// CreateRepayment records money a customer paid towards their account.
+// Allocations name the invoices it settles; without them it settles the
+// oldest invoices first. A customer cannot repay more than they owe.
+func (repo *ReceivableRepository) CreateRepayment(customerID int, repayment *models.Repayment, meta models.RequestMeta) error {
+	if repayment.Amount <= 0 {
+		return errors.New("Amount must be greater than zero")
+	}
+	switch repayment.Method {
+	case models.PaymentCash, models.PaymentCard, models.PaymentQRIS, models.PaymentTransfer:
+	default:
+		return fmt.Errorf("invalid repayment method %q", repayment.Method)
+	}
+	repayment.Note = strings.TrimSpace(repayment.Note)
+
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := lockCustomer(tx, customerID); err != nil {
+		return err
+	}
+	invoices, err := openInvoices(tx, customerID)
+	if err != nil {
+		return err
+	}
+	owed := 0
+	for _, invoice := range invoices {
+		owed += invoice.Outstanding
+	}
+	if repayment.Amount > owed {
+		return fmt.Errorf("customer owes only %d", owed)
+	}
+
+	paymentIDs := make([]int, 0, len(invoices))
+	if len(repayment.Allocations) == 0 {
+		left := repayment.Amount
+		for _, invoice := range invoices {
+			if left == 0 {
+				break
+			}
+			amount := min(invoice.Outstanding, left)
+			repayment.Allocations = append(repayment.Allocations, models.RepaymentAllocation{TransactionID: invoice.TransactionID, Amount: amount})
+			paymentIDs = append(paymentIDs, invoice.PaymentID)
+			left -= amount
+		}
+	} else {
+		open := make(map[int]models.Invoice, len(invoices))
+		for _, invoice := range invoices {
+			open[invoice.TransactionID] = invoice
+		}
+		allocated := 0
+		seen := make(map[int]bool)
+		for _, allocation := range repayment.Allocations {
+			invoice, ok := open[allocation.TransactionID]
+			if !ok {
+				return fmt.Errorf("transaction %d has nothing owed on account", allocation.TransactionID)
+			}
+			if seen[allocation.TransactionID] {
+				return fmt.Errorf("transaction %d is allocated twice", allocation.TransactionID)
+			}
+			seen[allocation.TransactionID] = true
+			if allocation.Amount <= 0 {
+				return fmt.Errorf("invalid amount for transaction %d", allocation.TransactionID)
+			}
+			if allocation.Amount > invoice.Outstanding {
+				return fmt.Errorf("transaction %d has only %d owed", allocation.TransactionID, invoice.Outstanding)
+			}
+			allocated += allocation.Amount
+			paymentIDs = append(paymentIDs, invoice.PaymentID)
+		}
+		if allocated != repayment.Amount {
+			return fmt.Errorf("allocations of %d do not add up to the amount of %d", allocated, repayment.Amount)
+		}
+	}
+
+	repayment.CustomerID = customerID
+	repayment.CreatedBy = meta.ActorName()
+	query := `
+		INSERT INTO customer_repayments (customer_id, method, amount, note, created_by)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at`
+	err = tx.QueryRow(query, customerID, repayment.Method, repayment.Amount, repayment.Note, repayment.CreatedBy).Scan(&repayment.ID, &repayment.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	for i, allocation := range repayment.Allocations {
+		_, err = tx.Exec("INSERT INTO repayment_allocations (repayment_id, transaction_payment_id, amount) VALUES ($1, $2, $3)", repayment.ID, paymentIDs[i], allocation.Amount)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
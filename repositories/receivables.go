@@ -0,0 +1,127 @@
+package repositories
+
+import (
+	"cashier-api/models"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// invoiceColumns reads the invoices of on_account payments, joined as tp
+// with their transaction t. Refunded only counts what refunds credited back
+// to the account: money paid back for a repaid invoice is not owed.
+const invoiceColumns = `tp.id, tp.transaction_id, tp.amount,
+	COALESCE((SELECT SUM(rp.amount) FROM refund_payments rp WHERE rp.transaction_payment_id = tp.id AND rp.method = 'on_account'), 0),
+	COALESCE((SELECT SUM(ra.amount) FROM repayment_allocations ra WHERE ra.transaction_payment_id = tp.id), 0),
+	EXTRACT(DAY FROM NOW() - t.created_at)::INT, t.created_at`
+
+func scanInvoice(row rowScanner, invoice *models.Invoice) error {
+	err := row.Scan(&invoice.PaymentID, &invoice.TransactionID, &invoice.Amount, &invoice.Refunded, &invoice.Repaid, &invoice.DaysOutstanding, &invoice.CreatedAt)
+	invoice.Outstanding = invoice.Amount - invoice.Refunded - invoice.Repaid
+	return err
+}
+
+// openInvoices returns a customer's invoices with something still owed on
+// them, oldest first.
+func openInvoices(db queryer, customerID int) ([]models.Invoice, error) {
+	rows, err := db.Query(`
+		SELECT `+invoiceColumns+`
+		FROM transaction_payments tp
+		JOIN transactions t ON t.id = tp.transaction_id
+		WHERE t.customer_id = $1 AND tp.method = $2
+		ORDER BY t.created_at, tp.id
+	`, customerID, models.PaymentOnAccount)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	invoices := make([]models.Invoice, 0)
+	for rows.Next() {
+		var invoice models.Invoice
+		if err := scanInvoice(rows, &invoice); err != nil {
+			return nil, err
+		}
+		if invoice.Outstanding > 0 {
+			invoices = append(invoices, invoice)
+		}
+	}
+	return invoices, rows.Err()
+}
+
+func customerOutstanding(db queryer, customerID int) (int, error) {
+	invoices, err := openInvoices(db, customerID)
+	if err != nil {
+		return 0, err
+	}
+	outstanding := 0
+	for _, invoice := range invoices {
+		outstanding += invoice.Outstanding
+	}
+	return outstanding, nil
+}
+
+// checkCredit makes sure a sale's on_account payment stays within the
+// customer's credit limit, counting what they already owe. The customer
+// must be locked, so two sales cannot both spend the same headroom.
+func checkCredit(tx *sql.Tx, customerID *int, payments []models.Payment) error {
+	onAccount := 0
+	for _, payment := range payments {
+		if payment.Method == models.PaymentOnAccount {
+			onAccount += payment.Amount
+		}
+	}
+	if onAccount == 0 {
+		return nil
+	}
+	if customerID == nil {
+		return errors.New("A customer is required to buy on account")
+	}
+
+	var creditLimit int
+	err := tx.QueryRow("SELECT credit_limit FROM customers WHERE id = $1", *customerID).Scan(&creditLimit)
+	if err != nil {
+		return err
+	}
+	if creditLimit == 0 {
+		return errors.New("Customer cannot buy on account")
+	}
+	outstanding, err := customerOutstanding(tx, *customerID)
+	if err != nil {
+		return err
+	}
+	if outstanding+onAccount > creditLimit {
+		return fmt.Errorf("customer owes %d of a %d credit limit and can put only %d more on account", outstanding, creditLimit, max(creditLimit-outstanding, 0))
+	}
+	return nil
+}
+
+// creditOnAccount keeps what a refund credits to an invoice within what is
+// still owed on it. When the customer has already repaid part of the
+// invoice, the rest of the refund is paid back in cash.
+func creditOnAccount(tx *sql.Tx, payments []models.Payment) ([]models.Payment, error) {
+	credited := make([]models.Payment, 0, len(payments))
+	for _, payment := range payments {
+		if payment.Method != models.PaymentOnAccount {
+			credited = append(credited, payment)
+			continue
+		}
+
+		var invoice models.Invoice
+		row := tx.QueryRow("SELECT "+invoiceColumns+" FROM transaction_payments tp JOIN transactions t ON t.id = tp.transaction_id WHERE tp.id = $1", payment.ID)
+		if err := scanInvoice(row, &invoice); err != nil {
+			return nil, err
+		}
+		cash := payment.Amount - max(invoice.Outstanding, 0)
+		if cash > 0 {
+			payment.Amount -= cash
+		}
+		if payment.Amount > 0 {
+			credited = append(credited, payment)
+		}
+		if cash > 0 {
+			credited = append(credited, models.Payment{ID: payment.ID, Method: models.PaymentCash, Amount: cash})
+		}
+	}
+	return credited, nil
+}
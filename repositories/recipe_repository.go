@@ -0,0 +1,183 @@
+package repositories
+
+import (
+	"cashier-api/models"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+type RecipeRepository struct {
+	db *sql.DB
+}
+
+func NewRecipeRepository(db *sql.DB) *RecipeRepository {
+	return &RecipeRepository{db: db}
+}
+
+func (repo *RecipeRepository) GetRecipe(productID int) ([]models.RecipeItem, error) {
+	var exists bool
+	err := repo.db.QueryRow("SELECT EXISTS (SELECT 1 FROM products WHERE id = $1)", productID).Scan(&exists)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.New("Product not found")
+	}
+
+	return getRecipe(repo.db, productID)
+}
+
+// SetRecipe replaces the recipe of a composite product.
+func (repo *RecipeRepository) SetRecipe(productID int, items []models.RecipeItem, meta models.RequestMeta) ([]models.RecipeItem, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	product, err := getProductForUpdate(tx, productID)
+	if err != nil {
+		return nil, err
+	}
+	if !product.IsComposite {
+		return nil, errors.New("Only composite products have a recipe")
+	}
+
+	before, err := getRecipe(tx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec("DELETE FROM recipe_items WHERE product_id = $1", productID)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[int]bool)
+	for _, item := range items {
+		if item.Quantity <= 0 {
+			return nil, fmt.Errorf("invalid quantity for component id %d", item.ComponentID)
+		}
+		if item.ComponentID == productID || seen[item.ComponentID] {
+			return nil, fmt.Errorf("component id %d cannot be used here", item.ComponentID)
+		}
+		seen[item.ComponentID] = true
+
+		var composite bool
+		err := tx.QueryRow("SELECT is_composite FROM products WHERE id = $1", item.ComponentID).Scan(&composite)
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("component id %d not found", item.ComponentID)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if composite {
+			return nil, fmt.Errorf("component id %d is itself composite", item.ComponentID)
+		}
+
+		_, err = tx.Exec("INSERT INTO recipe_items (product_id, component_id, quantity) VALUES ($1, $2, $3)", productID, item.ComponentID, item.Quantity)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	after, err := getRecipe(tx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = insertAuditLog(tx, meta, models.AuditActionUpdate, models.AuditEntityRecipe, productID, before, after)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return after, nil
+}
+
+// GetIngredientUsage totals the components used by sales of composite
+// products. Usage is not reversed by refunds, as made-to-order goods are not
+// restocked.
+func (repo *RecipeRepository) GetIngredientUsage(filter models.IngredientUsageFilter) (*models.IngredientUsageReport, error) {
+	args := []interface{}{}
+	where := ""
+	if filter.OutletID != 0 {
+		args = append(args, filter.OutletID)
+		where += fmt.Sprintf(" AND t.outlet_id = $%d", len(args))
+	}
+	if filter.From != nil {
+		args = append(args, *filter.From)
+		where += fmt.Sprintf(" AND t.created_at >= $%d", len(args))
+	}
+	if filter.To != nil {
+		args = append(args, *filter.To)
+		where += fmt.Sprintf(" AND t.created_at < $%d", len(args))
+	}
+
+	query := `
+		SELECT iu.product_id, p.name, SUM(iu.quantity), SUM(iu.cost_amount)
+		FROM ingredient_usages iu
+		JOIN transaction_details td ON td.id = iu.transaction_detail_id
+		JOIN transactions t ON t.id = td.transaction_id
+		JOIN products p ON p.id = iu.product_id
+		WHERE 1 = 1` + where + `
+		GROUP BY iu.product_id, p.name
+		ORDER BY p.name
+	`
+	rows, err := repo.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	report := models.IngredientUsageReport{Rows: make([]models.IngredientUsageRow, 0)}
+	for rows.Next() {
+		var row models.IngredientUsageRow
+		if err := rows.Scan(&row.ProductID, &row.Name, &row.Quantity, &row.CostAmount); err != nil {
+			return nil, err
+		}
+		report.TotalCost += row.CostAmount
+		report.Rows = append(report.Rows, row)
+	}
+
+	return &report, rows.Err()
+}
+
+func getRecipe(db queryer, productID int) ([]models.RecipeItem, error) {
+	rows, err := db.Query(`
+		SELECT ri.component_id, p.name, ri.quantity
+		FROM recipe_items ri
+		JOIN products p ON p.id = ri.component_id
+		WHERE ri.product_id = $1
+		ORDER BY ri.component_id
+	`, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]models.RecipeItem, 0)
+	for rows.Next() {
+		var item models.RecipeItem
+		if err := rows.Scan(&item.ComponentID, &item.ComponentName, &item.Quantity); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+// useIngredient takes quantity of a component out of an outlet for a sale
+// and returns the cost of the quantity used.
+func useIngredient(tx *sql.Tx, m *models.StockMovement, quantity models.Quantity) (int, error) {
+	m.Quantity = -quantity
+	if err := applyStockMovement(tx, m); err != nil {
+		return 0, err
+	}
+	return m.CostAmount, nil
+}
@@ -0,0 +1,373 @@
+package repositories
+
+import (
+	"cashier-api/models"
+	"database/sql"
+	"fmt"
+	"sort"
+)
+
+// pricedSale is a checkout's items priced and ready to be sold. Each line
+// of details has its modifiers, whether it is a composite product, the
+// index of the bundle it was sold in, or -1, and the index of the checkout
+// item it came from.
+type pricedSale struct {
+	details    []models.TransactionDetail
+	bundles    []models.TransactionBundle
+	modifiers  [][]models.Modifier
+	composite  []bool
+	bundleOf   []int
+	itemOf     []int
+	reorderQty map[int]models.Quantity
+	total      int
+}
+
+// priceSale prices a checkout's items at an outlet, for the customer when
+// one is given.
+func priceSale(tx *sql.Tx, req models.CheckoutRequest, outletID int) (*pricedSale, error) {
+	lines, bundles, err := expandBundles(tx, req.Items, outletID)
+	if err != nil {
+		return nil, err
+	}
+	if err := scanBarcodes(tx, lines, outletID); err != nil {
+		return nil, err
+	}
+
+	// Prices are per base unit, so items sold in another unit are converted
+	// to base units first. Quantity breaks count all of a product's lines
+	// sold at its own price.
+	quantities := make(map[int]models.Quantity)
+	for i := range lines {
+		item := &lines[i].item
+		if item.Quantity <= 0 {
+			return nil, fmt.Errorf("invalid quantity for product id %d", item.ProductID)
+		}
+		if item.Unit != "" {
+			factor, err := unitFactor(tx, item.ProductID, item.Unit)
+			if err != nil {
+				return nil, err
+			}
+			item.Quantity = item.Quantity.Mul(factor)
+		}
+		if lines[i].bundle < 0 && !lines[i].priced {
+			quantities[item.ProductID] += item.Quantity
+		}
+	}
+	priceLists, err := customerPriceLists(tx, req.CustomerID)
+	if err != nil {
+		return nil, err
+	}
+	listPrices, err := resolveListPrices(tx, priceLists, quantities)
+	if err != nil {
+		return nil, err
+	}
+
+	totalAmount := 0
+	details := make([]models.TransactionDetail, 0)
+	chosen := make([][]models.Modifier, 0)
+	composite := make([]bool, 0)
+	bundleOf := make([]int, 0)
+	itemOf := make([]int, 0)
+	reorderQty := make(map[int]models.Quantity)
+
+	for _, line := range lines {
+		item := line.item
+
+		var productPrice int
+		var productReorderQty models.Quantity
+		var productName string
+		var isComposite, isGiftCard bool
+
+		// An outlet's own price, when set, overrides the base price.
+		err := tx.QueryRow(`
+			SELECT p.name, COALESCE(os.price, `+priceColumn+`), p.reorder_qty, p.is_composite, p.is_gift_card
+			FROM products p
+			LEFT JOIN outlet_stocks os ON os.product_id = p.id AND os.outlet_id = $2
+			WHERE p.id = $1`, item.ProductID, outletID).Scan(&productName, &productPrice, &productReorderQty, &isComposite, &isGiftCard)
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("product id %d not found", item.ProductID)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// A price list, the customer's group list or the default one,
+		// overrides both when it prices the product at the quantity sold.
+		var priceList *listPrice
+		if price, ok := listPrices[item.ProductID]; ok && line.bundle < 0 && !line.priced && !isGiftCard {
+			productPrice = price.price
+			priceList = &price
+		}
+
+		// A gift card sells at the amount it loads, one card per unit.
+		var giftCards []models.StoredValueAccount
+		if isGiftCard {
+			giftCards, err = giftCardsSold(item, line.bundle >= 0, productPrice)
+			if err != nil {
+				return nil, err
+			}
+			productPrice = giftCards[0].IssuedAmount
+		} else if item.GiftCardAmount != 0 || item.GiftCardCode != "" {
+			return nil, fmt.Errorf("product id %d is not a gift card", item.ProductID)
+		}
+
+		modifiers, err := chooseModifiers(tx, item)
+		if err != nil {
+			return nil, err
+		}
+		unitPrice := productPrice
+		soldModifiers := make([]models.TransactionDetailModifier, 0, len(modifiers))
+		for _, modifier := range modifiers {
+			unitPrice += modifier.Price
+			soldModifiers = append(soldModifiers, models.TransactionDetailModifier{
+				ModifierID: &modifier.ID,
+				Name:       modifier.Name,
+				Price:      modifier.Price,
+			})
+		}
+
+		// Subtotals are rounded per line. Components of a bundle sell at
+		// their share of the package price, and price labels at the price
+		// printed.
+		subtotal := item.Quantity.Times(unitPrice)
+		if line.bundle >= 0 || line.priced {
+			subtotal = line.subtotal
+		}
+		totalAmount += subtotal
+		reorderQty[item.ProductID] = productReorderQty
+
+		detail := models.TransactionDetail{
+			ProductID:   item.ProductID,
+			ProductName: productName,
+			Quantity:    item.Quantity,
+			Subtotal:    subtotal,
+			Modifiers:   soldModifiers,
+			GiftCards:   giftCards,
+		}
+		if priceList != nil {
+			detail.PriceListID = &priceList.priceListID
+			detail.PriceListName = priceList.name
+		}
+		if isComposite {
+			recipe, err := getRecipe(tx, item.ProductID)
+			if err != nil {
+				return nil, err
+			}
+			if len(recipe) == 0 {
+				return nil, fmt.Errorf("product id %d has no recipe", item.ProductID)
+			}
+			for _, component := range recipe {
+				detail.Ingredients = append(detail.Ingredients, models.IngredientUsage{
+					ProductID: component.ComponentID,
+					Quantity:  component.Quantity.Mul(item.Quantity),
+				})
+			}
+		}
+
+		details = append(details, detail)
+		chosen = append(chosen, modifiers)
+		composite = append(composite, isComposite)
+		bundleOf = append(bundleOf, line.bundle)
+		itemOf = append(itemOf, line.index)
+	}
+
+	return &pricedSale{
+		details:    details,
+		bundles:    bundles,
+		modifiers:  chosen,
+		composite:  composite,
+		bundleOf:   bundleOf,
+		itemOf:     itemOf,
+		reorderQty: reorderQty,
+		total:      totalAmount,
+	}, nil
+}
+
+// takeSaleStock takes a sale's lines out of stock at the outlet selling
+// them, setting the cost of each line, and returns an event for every
+// product the sale took to or below its minimum stock.
+func takeSaleStock(tx *sql.Tx, priced *pricedSale, transactionID int, meta models.RequestMeta) ([]models.LowStockEvent, error) {
+	details, chosen, composite, reorderQty := priced.details, priced.modifiers, priced.composite, priced.reorderQty
+
+	// Every line takes its product out of stock, or the components of its
+	// recipe for a composite product, and every modifier with an ingredient
+	// takes that out too. modifier and ingredient index the line's modifiers
+	// and recipe components, and are -1 for the line's own product.
+	type saleMovement struct {
+		detail     int
+		modifier   int
+		ingredient int
+		movement   models.StockMovement
+	}
+	movements := make([]saleMovement, 0, len(details))
+	for i := range details {
+		if len(details[i].GiftCards) > 0 {
+			continue
+		}
+		if composite[i] {
+			for k, usage := range details[i].Ingredients {
+				movements = append(movements, saleMovement{detail: i, modifier: -1, ingredient: k, movement: models.StockMovement{
+					ProductID: usage.ProductID,
+				}})
+			}
+		} else {
+			movements = append(movements, saleMovement{detail: i, modifier: -1, ingredient: -1, movement: models.StockMovement{
+				ProductID: details[i].ProductID,
+				Quantity:  -details[i].Quantity,
+			}})
+		}
+		for j, modifier := range chosen[i] {
+			if modifier.ProductID == nil {
+				continue
+			}
+			movements = append(movements, saleMovement{detail: i, modifier: j, ingredient: -1, movement: models.StockMovement{
+				ProductID: *modifier.ProductID,
+				Quantity:  -modifier.Quantity.Mul(details[i].Quantity),
+			}})
+		}
+	}
+
+	// Decrement stock in product order so concurrent checkouts lock rows in
+	// the same sequence and cannot deadlock each other. The cost of goods
+	// sold comes back from the ledger and is snapshotted on each line.
+	sort.SliceStable(movements, func(a, b int) bool {
+		return movements[a].movement.ProductID < movements[b].movement.ProductID
+	})
+	events := make([]models.LowStockEvent, 0)
+	for _, sale := range movements {
+		movement := sale.movement
+		movement.OutletID = meta.OutletID
+		movement.Reason = models.StockReasonSale
+		movement.ReferenceType = models.StockReferenceTransaction
+		movement.ReferenceID = transactionID
+		movement.Actor = meta.ActorName()
+
+		detail := &details[sale.detail]
+		productName := detail.ProductName
+		switch {
+		case sale.ingredient >= 0:
+			usage := &detail.Ingredients[sale.ingredient]
+			cost, err := useIngredient(tx, &movement, usage.Quantity)
+			if err != nil {
+				return nil, err
+			}
+			usage.CostAmount = cost
+			detail.CostAmount += cost
+		case sale.modifier >= 0:
+			if err := applyStockMovement(tx, &movement); err != nil {
+				return nil, err
+			}
+			detail.Modifiers[sale.modifier].CostAmount += movement.CostAmount
+			detail.CostAmount += movement.CostAmount
+		default:
+			if err := applyStockMovement(tx, &movement); err != nil {
+				return nil, err
+			}
+			detail.Lots = movement.Lots
+			detail.CostAmount += movement.CostAmount
+		}
+
+		if movement.CrossedMinStock() {
+			productReorderQty := reorderQty[movement.ProductID]
+			if sale.modifier >= 0 || sale.ingredient >= 0 {
+				err := tx.QueryRow("SELECT name, reorder_qty FROM products WHERE id = $1", movement.ProductID).Scan(&productName, &productReorderQty)
+				if err != nil {
+					return nil, err
+				}
+			}
+			events = append(events, models.LowStockEvent{
+				OutletID:      meta.OutletID,
+				ProductID:     movement.ProductID,
+				ProductName:   productName,
+				Stock:         movement.Balance,
+				MinStock:      movement.MinStock,
+				ReorderQty:    productReorderQty,
+				TransactionID: transactionID,
+				OccurredAt:    movement.CreatedAt,
+			})
+		}
+	}
+	return events, nil
+}
+
+// insertSaleDetails saves a sale's bundles and lines, and issues the gift
+// cards it sold.
+func insertSaleDetails(tx *sql.Tx, sale *pricedSale, transactionID int, actor string) error {
+	details, bundles := sale.details, sale.bundles
+	for i := range bundles {
+		query := "INSERT INTO transaction_bundles (transaction_id, bundle_id, name, price, quantity, subtotal) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id"
+		err := tx.QueryRow(query, transactionID, bundles[i].BundleID, bundles[i].Name, bundles[i].Price, bundles[i].Quantity, bundles[i].Subtotal).Scan(&bundles[i].ID)
+		if err != nil {
+			return err
+		}
+	}
+	for i := range details {
+		if sale.bundleOf[i] >= 0 {
+			details[i].TransactionBundleID = &bundles[sale.bundleOf[i]].ID
+		}
+	}
+
+	for i := range details {
+		var transactionDetailID int
+
+		details[i].TransactionID = transactionID
+
+		query := "INSERT INTO transaction_details (transaction_id, product_id, quantity, subtotal, cost_amount, transaction_bundle_id, price_list_id, discount_amount, points_earned) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id"
+		err := tx.QueryRow(query, transactionID, details[i].ProductID, details[i].Quantity, details[i].Subtotal, details[i].CostAmount, details[i].TransactionBundleID, details[i].PriceListID, details[i].DiscountAmount, details[i].PointsEarned).Scan(&transactionDetailID)
+		if err != nil {
+			return err
+		}
+
+		details[i].ID = transactionDetailID
+
+		for _, modifier := range details[i].Modifiers {
+			_, err = tx.Exec("INSERT INTO transaction_detail_modifiers (transaction_detail_id, modifier_id, name, price, cost_amount) VALUES ($1, $2, $3, $4, $5)", transactionDetailID, modifier.ModifierID, modifier.Name, modifier.Price, modifier.CostAmount)
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, usage := range details[i].Ingredients {
+			_, err = tx.Exec("INSERT INTO ingredient_usages (transaction_detail_id, product_id, quantity, cost_amount) VALUES ($1, $2, $3, $4)", transactionDetailID, usage.ProductID, usage.Quantity, usage.CostAmount)
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, lot := range details[i].Lots {
+			_, err = tx.Exec("INSERT INTO transaction_detail_lots (transaction_detail_id, lot_id, quantity) VALUES ($1, $2, $3)", transactionDetailID, lot.LotID, lot.Quantity)
+			if err != nil {
+				return err
+			}
+		}
+
+		if err := issueGiftCards(tx, &details[i], actor); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// insertSalePayments saves the payments of a sale and spends the stored
+// value accounts they were paid from.
+func insertSalePayments(tx *sql.Tx, transactionID int, payments []models.Payment, actor string) error {
+	for i := range payments {
+		err := tx.QueryRow("INSERT INTO transaction_payments (transaction_id, method, amount, points, account_id) VALUES ($1, $2, $3, $4, $5) RETURNING id", transactionID, payments[i].Method, payments[i].Amount, payments[i].Points, payments[i].AccountID).Scan(&payments[i].ID)
+		if err != nil {
+			return err
+		}
+		if payments[i].AccountID != nil {
+			err = moveStoredValue(tx, &models.StoredValueEntry{
+				AccountID:     *payments[i].AccountID,
+				Amount:        -payments[i].Amount,
+				Reason:        models.StoredValueReasonRedeem,
+				TransactionID: &transactionID,
+				CreatedBy:     actor,
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
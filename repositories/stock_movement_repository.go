@@ -0,0 +1,256 @@
+package repositories
+
+import (
+	"cashier-api/models"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+type StockMovementRepository struct {
+	db *sql.DB
+}
+
+func NewStockMovementRepository(db *sql.DB) *StockMovementRepository {
+	return &StockMovementRepository{db: db}
+}
+
+func (repo *StockMovementRepository) GetStockCard(outletID, productID int, from, to *time.Time) (*models.StockCard, error) {
+	card := models.StockCard{OutletID: outletID, ProductID: productID}
+
+	err := repo.db.QueryRow("SELECT name FROM products WHERE id = $1", productID).Scan(&card.ProductName)
+	if err == sql.ErrNoRows {
+		return nil, errors.New("Product not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if from != nil {
+		query := "SELECT balance FROM stock_movements WHERE outlet_id = $1 AND product_id = $2 AND created_at < $3 ORDER BY created_at DESC, id DESC LIMIT 1"
+		err := repo.db.QueryRow(query, outletID, productID, *from).Scan(&card.OpeningBalance)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, err
+		}
+	}
+
+	query := "SELECT id, outlet_id, product_id, quantity, reason, reference_type, COALESCE(reference_id, 0), balance, cost_amount, note, actor, created_at FROM stock_movements WHERE outlet_id = $1 AND product_id = $2"
+	args := []interface{}{outletID, productID}
+	if from != nil {
+		args = append(args, *from)
+		query += fmt.Sprintf(" AND created_at >= $%d", len(args))
+	}
+	if to != nil {
+		args = append(args, *to)
+		query += fmt.Sprintf(" AND created_at < $%d", len(args))
+	}
+	query += " ORDER BY created_at, id"
+
+	rows, err := repo.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	card.Movements = make([]models.StockMovement, 0)
+	card.ClosingBalance = card.OpeningBalance
+	for rows.Next() {
+		var m models.StockMovement
+		err := rows.Scan(&m.ID, &m.OutletID, &m.ProductID, &m.Quantity, &m.Reason, &m.ReferenceType, &m.ReferenceID, &m.Balance, &m.CostAmount, &m.Note, &m.Actor, &m.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		m.UnitCost = models.PerUnit(m.CostAmount, max(m.Quantity, -m.Quantity))
+
+		if m.Quantity > 0 {
+			card.TotalIn += m.Quantity
+		} else {
+			card.TotalOut -= m.Quantity
+		}
+		card.ClosingBalance = m.Balance
+		card.Movements = append(card.Movements, m)
+	}
+
+	return &card, rows.Err()
+}
+
+func (repo *StockMovementRepository) AdjustStock(productID int, req models.StockAdjustmentRequest, meta models.RequestMeta) (*models.StockMovement, error) {
+	if (req.Delta == nil) == (req.CountedQuantity == nil) {
+		return nil, errors.New("Provide either delta or counted_quantity")
+	}
+	if req.Reason == "" {
+		req.Reason = models.StockReasonAdjustment
+	}
+	if !models.IsAdjustmentReason(req.Reason) {
+		return nil, fmt.Errorf("invalid adjustment reason %q", req.Reason)
+	}
+
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// The row lock makes the counted quantity authoritative: checkouts that
+	// touch this product wait until the adjustment commits.
+	before, err := getProductForUpdate(tx, productID)
+	if err != nil {
+		return nil, err
+	}
+	before.Stock, err = getOutletStock(tx, meta.OutletID, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	movement := models.StockMovement{
+		OutletID:  meta.OutletID,
+		ProductID: productID,
+		Reason:    req.Reason,
+		Note:      req.Note,
+		Actor:     meta.ActorName(),
+		Balance:   before.Stock,
+	}
+	if req.Delta != nil {
+		if *req.Delta == 0 {
+			return nil, errors.New("delta must not be zero")
+		}
+		movement.Quantity = *req.Delta
+	} else {
+		if *req.CountedQuantity < 0 {
+			return nil, errors.New("counted_quantity must not be negative")
+		}
+		// A count that matches the book stock confirms it: there is nothing
+		// to move or audit.
+		movement.Quantity = *req.CountedQuantity - before.Stock
+		if movement.Quantity == 0 {
+			return &movement, nil
+		}
+	}
+	if req.LotID != nil {
+		if !before.TrackLots {
+			return nil, errors.New("Product does not track lots")
+		}
+		movement.Lots = []models.LotAllocation{{LotID: *req.LotID, Quantity: max(movement.Quantity, -movement.Quantity)}}
+	}
+
+	if err := applyStockMovement(tx, &movement); err != nil {
+		return nil, err
+	}
+
+	after := *before
+	after.Stock = movement.Balance
+	err = insertAuditLog(tx, meta, models.AuditActionStockAdjustment, models.AuditEntityProduct, productID, before, &after)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &movement, nil
+}
+
+// applyStockMovement is the only place product stock is changed. It updates
+// the outlet's running balance and the product total, values the movement at
+// cost and appends the ledger row in the caller's transaction, filling in
+// m.Balance, m.UnitCost, m.CostAmount, m.ID and m.CreatedAt. Incoming
+// movements are valued at m.CostAmount when set, such as goods bought in
+// another unit, otherwise at m.UnitCost, or at the current product cost when
+// that is zero. Outgoing movements that would leave the outlet balance below zero
+// are rejected. Lot-tracked products also move their lots, see moveLots.
+//
+// The product row is always locked first, so the product row serialises all
+// stock changes of a product across outlets.
+func applyStockMovement(tx *sql.Tx, m *models.StockMovement) error {
+	if m.Quantity == 0 {
+		return nil
+	}
+	if m.OutletID == 0 {
+		return errors.New("Stock movements require an outlet")
+	}
+
+	var totalStock models.Quantity
+	var cost int
+	var method string
+	var trackLots, composite, giftCard bool
+	err := tx.QueryRow("UPDATE products SET stock = stock + $1 WHERE id = $2 RETURNING stock, cost, costing_method, min_stock, track_lots, is_composite, is_gift_card", m.Quantity, m.ProductID).Scan(&totalStock, &cost, &method, &m.MinStock, &trackLots, &composite, &giftCard)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("product id %d not found", m.ProductID)
+	}
+	if err != nil {
+		return err
+	}
+	if composite {
+		return fmt.Errorf("product id %d is composite and holds no stock", m.ProductID)
+	}
+	if giftCard {
+		return fmt.Errorf("product id %d is a gift card and holds no stock", m.ProductID)
+	}
+
+	query := `
+		INSERT INTO outlet_stocks (outlet_id, product_id, stock) VALUES ($1, $2, $3)
+		ON CONFLICT (outlet_id, product_id) DO UPDATE SET stock = outlet_stocks.stock + EXCLUDED.stock
+		RETURNING stock, reserved
+	`
+	var reserved models.Quantity
+	err = tx.QueryRow(query, m.OutletID, m.ProductID, m.Quantity).Scan(&m.Balance, &reserved)
+	if err != nil {
+		return err
+	}
+
+	if m.Quantity < 0 && m.Balance < 0 {
+		return fmt.Errorf("insufficient stock for product id %d: available %s, requested %s", m.ProductID, m.Balance-m.Quantity, -m.Quantity)
+	}
+	// Stock reserved for layaways cannot be sold or moved, but a count or a
+	// write-off records what is really there, reserved or not.
+	if m.Quantity < 0 && m.Balance < reserved && !models.IsAdjustmentReason(m.Reason) {
+		return fmt.Errorf("insufficient stock for product id %d: available %s, requested %s, %s reserved", m.ProductID, max(m.Balance-m.Quantity-reserved, 0), -m.Quantity, reserved)
+	}
+
+	if trackLots {
+		if err := moveLots(tx, m); err != nil {
+			return err
+		}
+	} else {
+		m.Lots = nil
+	}
+
+	// Costs are kept per product across all outlets.
+	if m.Quantity > 0 {
+		if m.CostAmount == 0 {
+			if m.UnitCost == 0 {
+				m.UnitCost = cost
+			}
+			m.CostAmount = m.Quantity.Times(m.UnitCost)
+		} else {
+			m.UnitCost = models.PerUnit(m.CostAmount, m.Quantity)
+		}
+		err = receiveCost(tx, m.ProductID, totalStock-m.Quantity, m.Quantity, m.CostAmount, method, cost)
+	} else {
+		m.CostAmount, err = issueCost(tx, m.ProductID, -m.Quantity, method, cost)
+		m.UnitCost = models.PerUnit(m.CostAmount, -m.Quantity)
+	}
+	if err != nil {
+		return err
+	}
+
+	query = `
+		INSERT INTO stock_movements (outlet_id, product_id, quantity, reason, reference_type, reference_id, balance, cost_amount, note, actor)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, 0), $7, $8, $9, $10)
+		RETURNING id, created_at
+	`
+	return tx.QueryRow(query, m.OutletID, m.ProductID, m.Quantity, m.Reason, m.ReferenceType, m.ReferenceID, m.Balance, m.CostAmount, m.Note, m.Actor).Scan(&m.ID, &m.CreatedAt)
+}
+
+// getOutletStock returns a product's stock at one outlet, zero when the
+// outlet never held it.
+func getOutletStock(db queryer, outletID, productID int) (models.Quantity, error) {
+	var stock models.Quantity
+	err := db.QueryRow("SELECT stock FROM outlet_stocks WHERE outlet_id = $1 AND product_id = $2", outletID, productID).Scan(&stock)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return stock, err
+}
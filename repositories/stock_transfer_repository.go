@@ -0,0 +1,428 @@
+package repositories
+
+import (
+	"cashier-api/models"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+type StockTransferRepository struct {
+	db *sql.DB
+}
+
+func NewStockTransferRepository(db *sql.DB) *StockTransferRepository {
+	return &StockTransferRepository{db: db}
+}
+
+const stockTransferColumns = `
+	id, source_outlet_id, destination_outlet_id, status, note, requested_by, created_at,
+	dispatched_by, dispatched_at, received_by, received_at, cancelled_at
+`
+
+func scanStockTransfer(row rowScanner, transfer *models.StockTransfer) error {
+	return row.Scan(&transfer.ID, &transfer.SourceOutletID, &transfer.DestinationOutletID, &transfer.Status, &transfer.Note, &transfer.RequestedBy, &transfer.CreatedAt,
+		&transfer.DispatchedBy, &transfer.DispatchedAt, &transfer.ReceivedBy, &transfer.ReceivedAt, &transfer.CancelledAt)
+}
+
+// GetAll lists transfers, optionally only those leaving or arriving at one
+// outlet.
+func (repo *StockTransferRepository) GetAll(filter models.StockTransferFilter) ([]models.StockTransfer, error) {
+	query := "SELECT " + stockTransferColumns + " FROM stock_transfers WHERE 1 = 1"
+
+	args := []interface{}{}
+	if filter.OutletID != 0 {
+		args = append(args, filter.OutletID)
+		query += fmt.Sprintf(" AND (source_outlet_id = $%d OR destination_outlet_id = $%d)", len(args), len(args))
+	}
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		query += fmt.Sprintf(" AND status = $%d", len(args))
+	}
+	query += " ORDER BY id DESC"
+
+	rows, err := repo.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	transfers := make([]models.StockTransfer, 0)
+	for rows.Next() {
+		var transfer models.StockTransfer
+		if err := scanStockTransfer(rows, &transfer); err != nil {
+			return nil, err
+		}
+		transfers = append(transfers, transfer)
+	}
+
+	return transfers, rows.Err()
+}
+
+func (repo *StockTransferRepository) GetByID(id int) (*models.StockTransfer, error) {
+	return getStockTransfer(repo.db, id, "")
+}
+
+func (repo *StockTransferRepository) Create(req models.StockTransferRequest, meta models.RequestMeta) (*models.StockTransfer, error) {
+	if req.DestinationOutletID == 0 {
+		req.DestinationOutletID = meta.OutletID
+	}
+	if req.SourceOutletID == 0 || req.DestinationOutletID == 0 {
+		return nil, errors.New("Source and destination outlets are required")
+	}
+	if req.SourceOutletID == req.DestinationOutletID {
+		return nil, errors.New("Source and destination outlets must differ")
+	}
+	if len(req.Lines) == 0 {
+		return nil, errors.New("Stock transfer has no lines")
+	}
+
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := getOutlet(tx, req.SourceOutletID, ""); err != nil {
+		return nil, err
+	}
+	if _, err := getOutlet(tx, req.DestinationOutletID, ""); err != nil {
+		return nil, err
+	}
+
+	var id int
+	query := "INSERT INTO stock_transfers (source_outlet_id, destination_outlet_id, status, note, requested_by) VALUES ($1, $2, $3, $4, $5) RETURNING id"
+	err = tx.QueryRow(query, req.SourceOutletID, req.DestinationOutletID, models.StockTransferStatusRequested, req.Note, meta.ActorName()).Scan(&id)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[int]bool, len(req.Lines))
+	for _, line := range req.Lines {
+		if line.Quantity <= 0 {
+			return nil, fmt.Errorf("invalid quantity for product id %d", line.ProductID)
+		}
+		if seen[line.ProductID] {
+			return nil, fmt.Errorf("product id %d is listed more than once", line.ProductID)
+		}
+		seen[line.ProductID] = true
+
+		var exists bool
+		err := tx.QueryRow("SELECT EXISTS (SELECT 1 FROM products WHERE id = $1)", line.ProductID).Scan(&exists)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, fmt.Errorf("product id %d not found", line.ProductID)
+		}
+
+		_, err = tx.Exec("INSERT INTO stock_transfer_lines (stock_transfer_id, product_id, quantity) VALUES ($1, $2, $3)", id, line.ProductID, line.Quantity)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	transfer, err := getStockTransfer(tx, id, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return transfer, nil
+}
+
+// Dispatch takes the goods out of the source outlet and puts the transfer in
+// transit. Each line keeps the cost its goods left at, so the same value
+// arrives at the destination.
+func (repo *StockTransferRepository) Dispatch(id int, req models.StockTransferDispatchRequest, meta models.RequestMeta) (*models.StockTransfer, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	transfer, err := getStockTransfer(tx, id, "FOR UPDATE")
+	if err != nil {
+		return nil, err
+	}
+	if transfer.Status != models.StockTransferStatusRequested {
+		return nil, fmt.Errorf("cannot dispatch a %s stock transfer", transfer.Status)
+	}
+	if transfer.SourceOutletID != meta.OutletID {
+		return nil, errors.New("cannot dispatch a stock transfer from another outlet")
+	}
+
+	quantities, err := transferQuantities(transfer, req.Lines)
+	if err != nil {
+		return nil, err
+	}
+
+	var dispatched models.Quantity
+	for _, line := range transfer.Lines {
+		quantity := line.Quantity
+		if q, ok := quantities[line.ID]; ok {
+			quantity = q.Quantity
+		}
+		if quantity == 0 {
+			continue
+		}
+
+		movement := models.StockMovement{
+			OutletID:      transfer.SourceOutletID,
+			ProductID:     line.ProductID,
+			Quantity:      -quantity,
+			Reason:        models.StockReasonTransfer,
+			ReferenceType: models.StockReferenceStockTransfer,
+			ReferenceID:   id,
+			Note:          fmt.Sprintf("Transfer #%d to outlet %d", id, transfer.DestinationOutletID),
+			Actor:         meta.ActorName(),
+		}
+		if err := applyStockMovement(tx, &movement); err != nil {
+			return nil, err
+		}
+
+		_, err = tx.Exec("UPDATE stock_transfer_lines SET dispatched_quantity = $1, unit_cost = $2 WHERE id = $3", quantity, models.PerUnit(movement.CostAmount, quantity), line.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, lot := range movement.Lots {
+			_, err = tx.Exec("INSERT INTO stock_transfer_line_lots (stock_transfer_line_id, batch_number, expiry_date, quantity) VALUES ($1, $2, $3, $4)", line.ID, lot.BatchNumber, lot.ExpiryDate, lot.Quantity)
+			if err != nil {
+				return nil, err
+			}
+		}
+		dispatched += quantity
+	}
+	if dispatched == 0 {
+		return nil, errors.New("Nothing to dispatch")
+	}
+
+	_, err = tx.Exec("UPDATE stock_transfers SET status = $1, dispatched_by = $2, dispatched_at = NOW() WHERE id = $3", models.StockTransferStatusInTransit, meta.ActorName(), id)
+	if err != nil {
+		return nil, err
+	}
+
+	transfer, err = getStockTransfer(tx, id, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return transfer, nil
+}
+
+// Receive puts the goods into the destination outlet at their dispatched cost
+// and closes the transfer. A short delivery is recorded as a discrepancy; the
+// missing goods left the source at dispatch and are not restocked anywhere.
+// More than was dispatched cannot be received: goods that never left an
+// outlet are booked in with a stock adjustment instead.
+func (repo *StockTransferRepository) Receive(id int, req models.StockTransferReceiveRequest, meta models.RequestMeta) (*models.StockTransfer, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	transfer, err := getStockTransfer(tx, id, "FOR UPDATE")
+	if err != nil {
+		return nil, err
+	}
+	if transfer.Status != models.StockTransferStatusInTransit {
+		return nil, fmt.Errorf("cannot receive a %s stock transfer", transfer.Status)
+	}
+	if transfer.DestinationOutletID != meta.OutletID {
+		return nil, errors.New("cannot receive a stock transfer for another outlet")
+	}
+
+	quantities, err := transferQuantities(transfer, req.Lines)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, line := range transfer.Lines {
+		received, note := line.DispatchedQuantity, ""
+		if q, ok := quantities[line.ID]; ok {
+			received, note = q.Quantity, q.Note
+		}
+		if received > line.DispatchedQuantity {
+			return nil, fmt.Errorf("cannot receive %s of product id %d, only %s was dispatched", received, line.ProductID, line.DispatchedQuantity)
+		}
+		lots, err := transferLots(tx, line.ID, received)
+		if err != nil {
+			return nil, err
+		}
+
+		movement := models.StockMovement{
+			OutletID:      transfer.DestinationOutletID,
+			ProductID:     line.ProductID,
+			Quantity:      received,
+			UnitCost:      line.UnitCost,
+			Reason:        models.StockReasonTransfer,
+			ReferenceType: models.StockReferenceStockTransfer,
+			ReferenceID:   id,
+			Note:          fmt.Sprintf("Transfer #%d from outlet %d", id, transfer.SourceOutletID),
+			Actor:         meta.ActorName(),
+			Lots:          lots,
+		}
+		if err := applyStockMovement(tx, &movement); err != nil {
+			return nil, err
+		}
+
+		_, err = tx.Exec("UPDATE stock_transfer_lines SET received_quantity = $1, discrepancy_note = $2 WHERE id = $3", received, note, line.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	_, err = tx.Exec("UPDATE stock_transfers SET status = $1, received_by = $2, received_at = NOW() WHERE id = $3", models.StockTransferStatusReceived, meta.ActorName(), id)
+	if err != nil {
+		return nil, err
+	}
+
+	transfer, err = getStockTransfer(tx, id, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return transfer, nil
+}
+
+// Cancel withdraws a requested transfer, or returns the goods of one in
+// transit to the source outlet at the cost they left with.
+func (repo *StockTransferRepository) Cancel(id int, meta models.RequestMeta) (*models.StockTransfer, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	transfer, err := getStockTransfer(tx, id, "FOR UPDATE")
+	if err != nil {
+		return nil, err
+	}
+
+	switch transfer.Status {
+	case models.StockTransferStatusRequested:
+		if meta.OutletID != transfer.SourceOutletID && meta.OutletID != transfer.DestinationOutletID {
+			return nil, errors.New("cannot cancel a stock transfer between other outlets")
+		}
+	case models.StockTransferStatusInTransit:
+		if meta.OutletID != transfer.SourceOutletID {
+			return nil, errors.New("cannot cancel a stock transfer in transit from another outlet")
+		}
+		for _, line := range transfer.Lines {
+			lots, err := transferLots(tx, line.ID, line.DispatchedQuantity)
+			if err != nil {
+				return nil, err
+			}
+			movement := models.StockMovement{
+				OutletID:      transfer.SourceOutletID,
+				ProductID:     line.ProductID,
+				Quantity:      line.DispatchedQuantity,
+				UnitCost:      line.UnitCost,
+				Reason:        models.StockReasonTransfer,
+				ReferenceType: models.StockReferenceStockTransfer,
+				ReferenceID:   id,
+				Note:          fmt.Sprintf("Transfer #%d cancelled", id),
+				Actor:         meta.ActorName(),
+				Lots:          lots,
+			}
+			if err := applyStockMovement(tx, &movement); err != nil {
+				return nil, err
+			}
+		}
+	default:
+		return nil, fmt.Errorf("cannot cancel a %s stock transfer", transfer.Status)
+	}
+
+	_, err = tx.Exec("UPDATE stock_transfers SET status = $1, cancelled_at = NOW() WHERE id = $2", models.StockTransferStatusCancelled, id)
+	if err != nil {
+		return nil, err
+	}
+
+	transfer, err = getStockTransfer(tx, id, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return transfer, nil
+}
+
+// transferQuantities indexes per-line quantities by line ID and checks they
+// belong to the transfer.
+func transferQuantities(transfer *models.StockTransfer, lines []models.StockTransferQuantityRequest) (map[int]models.StockTransferQuantityRequest, error) {
+	known := make(map[int]bool, len(transfer.Lines))
+	for _, line := range transfer.Lines {
+		known[line.ID] = true
+	}
+
+	quantities := make(map[int]models.StockTransferQuantityRequest, len(lines))
+	for _, line := range lines {
+		if !known[line.StockTransferLineID] {
+			return nil, fmt.Errorf("stock transfer line id %d not found in stock transfer %d", line.StockTransferLineID, transfer.ID)
+		}
+		if line.Quantity < 0 {
+			return nil, fmt.Errorf("invalid quantity for stock transfer line id %d", line.StockTransferLineID)
+		}
+		quantities[line.StockTransferLineID] = line
+	}
+
+	return quantities, nil
+}
+
+// getStockTransfer loads a transfer with its lines in product order, which is
+// also the order stock is moved in. lock is an optional row locking clause
+// for the transfer header.
+func getStockTransfer(db queryer, id int, lock string) (*models.StockTransfer, error) {
+	query := "SELECT " + stockTransferColumns + " FROM stock_transfers WHERE id = $1 " + lock
+
+	var transfer models.StockTransfer
+	err := scanStockTransfer(db.QueryRow(query, id), &transfer)
+	if err == sql.ErrNoRows {
+		return nil, errors.New("Stock transfer not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query(`
+		SELECT l.id, l.stock_transfer_id, l.product_id, COALESCE(p.name, ''), l.quantity, l.dispatched_quantity, l.received_quantity, l.unit_cost, l.discrepancy_note
+		FROM stock_transfer_lines l
+		LEFT JOIN products p ON p.id = l.product_id
+		WHERE l.stock_transfer_id = $1
+		ORDER BY l.product_id
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	transfer.Lines = make([]models.StockTransferLine, 0)
+	for rows.Next() {
+		var line models.StockTransferLine
+		err := rows.Scan(&line.ID, &line.StockTransferID, &line.ProductID, &line.ProductName, &line.Quantity, &line.DispatchedQuantity, &line.ReceivedQuantity, &line.UnitCost, &line.DiscrepancyNote)
+		if err != nil {
+			return nil, err
+		}
+		if transfer.Status == models.StockTransferStatusReceived {
+			line.Discrepancy = line.ReceivedQuantity - line.DispatchedQuantity
+		}
+		transfer.Lines = append(transfer.Lines, line)
+	}
+
+	return &transfer, rows.Err()
+}
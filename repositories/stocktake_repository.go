@@ -0,0 +1,409 @@
+package repositories
+
+import (
+	"cashier-api/models"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+type StocktakeRepository struct {
+	db *sql.DB
+}
+
+func NewStocktakeRepository(db *sql.DB) *StocktakeRepository {
+	return &StocktakeRepository{db: db}
+}
+
+// GetAll lists the sessions of one outlet, or of every outlet when outletID
+// is 0.
+func (repo *StocktakeRepository) GetAll(outletID int) ([]models.Stocktake, error) {
+	query := "SELECT id, outlet_id, status, note, created_by, created_at, finalized_by, finalized_at FROM stocktakes WHERE $1 = 0 OR outlet_id = $1 ORDER BY id DESC"
+	rows, err := repo.db.Query(query, outletID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stocktakes := make([]models.Stocktake, 0)
+	for rows.Next() {
+		var stocktake models.Stocktake
+		err := rows.Scan(&stocktake.ID, &stocktake.OutletID, &stocktake.Status, &stocktake.Note, &stocktake.CreatedBy, &stocktake.CreatedAt, &stocktake.FinalizedBy, &stocktake.FinalizedAt)
+		if err != nil {
+			return nil, err
+		}
+		stocktakes = append(stocktakes, stocktake)
+	}
+
+	return stocktakes, rows.Err()
+}
+
+// Create opens a session at the caller's outlet and snapshots the outlet's
+// book stock of every product.
+func (repo *StocktakeRepository) Create(stocktake *models.Stocktake, meta models.RequestMeta) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var open int
+	err = tx.QueryRow("SELECT COUNT(*) FROM stocktakes WHERE status = $1 AND outlet_id = $2", models.StocktakeStatusOpen, meta.OutletID).Scan(&open)
+	if err != nil {
+		return err
+	}
+	if open > 0 {
+		return errors.New("Another stocktake is still open")
+	}
+
+	stocktake.OutletID = meta.OutletID
+	stocktake.Status = models.StocktakeStatusOpen
+	stocktake.CreatedBy = meta.ActorName()
+	query := "INSERT INTO stocktakes (outlet_id, status, note, created_by) VALUES ($1, $2, $3, $4) RETURNING id, created_at"
+	err = tx.QueryRow(query, stocktake.OutletID, stocktake.Status, stocktake.Note, stocktake.CreatedBy).Scan(&stocktake.ID, &stocktake.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	query = `
+		INSERT INTO stocktake_items (stocktake_id, product_id, snapshot_quantity)
+		SELECT $1, p.id, COALESCE(os.stock, 0)
+		FROM products p
+		LEFT JOIN outlet_stocks os ON os.product_id = p.id AND os.outlet_id = $2`
+	_, err = tx.Exec(query, stocktake.ID, stocktake.OutletID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (repo *StocktakeRepository) GetByID(id int) (*models.Stocktake, error) {
+	stocktake, err := getStocktake(repo.db, id, "")
+	if err != nil {
+		return nil, err
+	}
+
+	stocktake.Items, err = getStocktakeItems(repo.db, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return stocktake, nil
+}
+
+// AddCounts records a batch of count entries from one clerk. Each entry keeps
+// the book stock at the time it was counted.
+func (repo *StocktakeRepository) AddCounts(id int, req models.StocktakeCountRequest, meta models.RequestMeta) ([]models.StocktakeCount, error) {
+	if len(req.Counts) == 0 {
+		return nil, errors.New("No counts given")
+	}
+
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// A shared lock lets clerks count in parallel while blocking finalize.
+	stocktake, err := getStocktake(tx, id, "FOR SHARE")
+	if err != nil {
+		return nil, err
+	}
+	if stocktake.Status != models.StocktakeStatusOpen {
+		return nil, errors.New("Stocktake is not open")
+	}
+	if meta.OutletID != stocktake.OutletID {
+		return nil, fmt.Errorf("stocktake is at outlet id %d", stocktake.OutletID)
+	}
+
+	counts := make([]models.StocktakeCount, 0, len(req.Counts))
+	for _, line := range req.Counts {
+		if line.Quantity < 0 {
+			return nil, fmt.Errorf("invalid quantity for product id %d", line.ProductID)
+		}
+
+		count := models.StocktakeCount{
+			StocktakeID: id,
+			ProductID:   line.ProductID,
+			Quantity:    line.Quantity,
+			Location:    line.Location,
+			CountedBy:   meta.ActorName(),
+		}
+
+		err := tx.QueryRow(`
+			SELECT COALESCE(os.stock, 0)
+			FROM products p
+			LEFT JOIN outlet_stocks os ON os.product_id = p.id AND os.outlet_id = $2
+			WHERE p.id = $1`, line.ProductID, stocktake.OutletID).Scan(&count.SystemQuantity)
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("product id %d not found", line.ProductID)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// Products created after the snapshot join the session with an
+		// expected quantity of zero.
+		_, err = tx.Exec("INSERT INTO stocktake_items (stocktake_id, product_id, snapshot_quantity) VALUES ($1, $2, 0) ON CONFLICT DO NOTHING", id, line.ProductID)
+		if err != nil {
+			return nil, err
+		}
+
+		query := "INSERT INTO stocktake_counts (stocktake_id, product_id, quantity, location, counted_by, system_quantity) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, created_at"
+		err = tx.QueryRow(query, id, count.ProductID, count.Quantity, count.Location, count.CountedBy, count.SystemQuantity).Scan(&count.ID, &count.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		counts = append(counts, count)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return counts, nil
+}
+
+func (repo *StocktakeRepository) DeleteCount(id, countID int, meta models.RequestMeta) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stocktake, err := getStocktake(tx, id, "FOR UPDATE")
+	if err != nil {
+		return err
+	}
+	if stocktake.Status != models.StocktakeStatusOpen {
+		return errors.New("Stocktake is not open")
+	}
+	if meta.OutletID != stocktake.OutletID {
+		return fmt.Errorf("stocktake is at outlet id %d", stocktake.OutletID)
+	}
+
+	result, err := tx.Exec("DELETE FROM stocktake_counts WHERE id = $1 AND stocktake_id = $2", countID, id)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errors.New("Count not found")
+	}
+
+	return tx.Commit()
+}
+
+// Finalize closes the session, freezes the unit values and posts one
+// adjustment per counted product for its variance. Uncounted products are
+// left untouched.
+func (repo *StocktakeRepository) Finalize(id int, meta models.RequestMeta) (*models.StocktakeVarianceReport, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	stocktake, err := getStocktake(tx, id, "FOR UPDATE")
+	if err != nil {
+		return nil, err
+	}
+	if stocktake.Status != models.StocktakeStatusOpen {
+		return nil, errors.New("Stocktake is not open")
+	}
+	if meta.OutletID != stocktake.OutletID {
+		return nil, fmt.Errorf("stocktake is at outlet id %d", stocktake.OutletID)
+	}
+
+	_, err = tx.Exec("UPDATE stocktake_items si SET unit_value = p.cost FROM products p WHERE p.id = si.product_id AND si.stocktake_id = $1", id)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := getStocktakeItems(tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range items {
+		if item.Variance == nil || *item.Variance == 0 {
+			continue
+		}
+
+		movement := models.StockMovement{
+			OutletID:      stocktake.OutletID,
+			ProductID:     item.ProductID,
+			Quantity:      *item.Variance,
+			Reason:        models.StockReasonAdjustment,
+			ReferenceType: models.StockReferenceStocktake,
+			ReferenceID:   id,
+			Note:          "Stocktake variance",
+			Actor:         meta.ActorName(),
+		}
+		if err := applyStockMovement(tx, &movement); err != nil {
+			return nil, err
+		}
+	}
+
+	_, err = tx.Exec("UPDATE stocktakes SET status = $1, finalized_by = $2, finalized_at = NOW() WHERE id = $3", models.StocktakeStatusFinalized, meta.ActorName(), id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return buildVarianceReport(id, models.StocktakeStatusFinalized, items), nil
+}
+
+func (repo *StocktakeRepository) Cancel(id int, meta models.RequestMeta) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stocktake, err := getStocktake(tx, id, "FOR UPDATE")
+	if err != nil {
+		return err
+	}
+	if stocktake.Status != models.StocktakeStatusOpen {
+		return errors.New("Stocktake is not open")
+	}
+	if meta.OutletID != stocktake.OutletID {
+		return fmt.Errorf("stocktake is at outlet id %d", stocktake.OutletID)
+	}
+
+	_, err = tx.Exec("UPDATE stocktakes SET status = $1, finalized_by = $2, finalized_at = NOW() WHERE id = $3", models.StocktakeStatusCancelled, meta.ActorName(), id)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetVarianceReport previews the variance of an open session or returns the
+// final report of a finalized one.
+func (repo *StocktakeRepository) GetVarianceReport(id int) (*models.StocktakeVarianceReport, error) {
+	stocktake, err := repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return buildVarianceReport(id, stocktake.Status, stocktake.Items), nil
+}
+
+// getStocktake loads a session header. lock is an optional row locking
+// clause such as "FOR UPDATE".
+func getStocktake(db queryer, id int, lock string) (*models.Stocktake, error) {
+	query := "SELECT id, outlet_id, status, note, created_by, created_at, finalized_by, finalized_at FROM stocktakes WHERE id = $1 " + lock
+
+	var stocktake models.Stocktake
+	err := db.QueryRow(query, id).Scan(&stocktake.ID, &stocktake.OutletID, &stocktake.Status, &stocktake.Note, &stocktake.CreatedBy, &stocktake.CreatedAt, &stocktake.FinalizedBy, &stocktake.FinalizedAt)
+	if err == sql.ErrNoRows {
+		return nil, errors.New("Stocktake not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &stocktake, nil
+}
+
+// getStocktakeItems reconciles every product line against its counts. The
+// expected quantity of a counted product is the book stock recorded with its
+// first count, a single snapshot that all of its counts are summed against,
+// so sales made during the session before the product was counted are not
+// mistaken for shrinkage.
+func getStocktakeItems(db queryer, id int) ([]models.StocktakeItem, error) {
+	query := `
+		SELECT
+			si.product_id,
+			COALESCE(p.name, ''),
+			si.snapshot_quantity,
+			COALESCE(si.unit_value, p.cost, 0),
+			c.counted,
+			c.entries,
+			c.system_quantity
+		FROM stocktake_items si
+		LEFT JOIN products p ON p.id = si.product_id
+		LEFT JOIN LATERAL (
+			SELECT
+				SUM(sc.quantity) AS counted,
+				COUNT(*) AS entries,
+				(ARRAY_AGG(sc.system_quantity ORDER BY sc.id))[1] AS system_quantity
+			FROM stocktake_counts sc
+			WHERE sc.stocktake_id = si.stocktake_id AND sc.product_id = si.product_id
+		) c ON TRUE
+		WHERE si.stocktake_id = $1
+		ORDER BY si.product_id
+	`
+	rows, err := db.Query(query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]models.StocktakeItem, 0)
+	for rows.Next() {
+		var item models.StocktakeItem
+		var counted, system *models.Quantity
+		err := rows.Scan(&item.ProductID, &item.ProductName, &item.SnapshotQuantity, &item.UnitValue, &counted, &item.CountEntries, &system)
+		if err != nil {
+			return nil, err
+		}
+
+		if counted != nil {
+			var systemQty models.Quantity
+			if system != nil {
+				systemQty = *system
+			}
+			movement := systemQty - item.SnapshotQuantity
+			variance := *counted - systemQty
+
+			item.CountedQuantity = counted
+			item.SystemQuantity = &systemQty
+			item.MovementSince = &movement
+			item.Variance = &variance
+			item.VarianceValue = variance.Times(item.UnitValue)
+		}
+
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+func buildVarianceReport(id int, status string, items []models.StocktakeItem) *models.StocktakeVarianceReport {
+	report := models.StocktakeVarianceReport{
+		StocktakeID: id,
+		Status:      status,
+		Items:       make([]models.StocktakeItem, 0, len(items)),
+	}
+
+	for _, item := range items {
+		if item.Variance == nil {
+			report.UncountedItems++
+			continue
+		}
+
+		report.CountedItems++
+		if *item.Variance < 0 {
+			report.ShortageUnits -= *item.Variance
+			report.ShortageValue -= item.VarianceValue
+		} else {
+			report.SurplusUnits += *item.Variance
+			report.SurplusValue += item.VarianceValue
+		}
+		report.NetValue += item.VarianceValue
+		report.Items = append(report.Items, item)
+	}
+
+	return &report
+}
@@ -0,0 +1,285 @@
+package repositories
+
+import (
+	"cashier-api/models"
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/lib/pq"
+)
+
+const storedValueColumns = "id, kind, COALESCE(code, ''), customer_id, issued_amount, balance, status, created_at"
+
+func scanStoredValueAccount(row rowScanner, account *models.StoredValueAccount) error {
+	return row.Scan(&account.ID, &account.Kind, &account.Code, &account.CustomerID, &account.IssuedAmount, &account.Balance, &account.Status, &account.CreatedAt)
+}
+
+// giftCardAlphabet leaves out letters and digits that are easily mistaken
+// for one another, such as O and 0 or I and 1.
+const giftCardAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// newGiftCardCode returns a random 16-character gift card code.
+func newGiftCardCode() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	for i := range b {
+		b[i] = giftCardAlphabet[int(b[i])%len(giftCardAlphabet)]
+	}
+	return string(b), nil
+}
+
+// giftCardsSold returns the cards a gift card line loads, one per unit,
+// each with the amount given at checkout or else the product's price. The
+// cards are issued once the line is saved.
+func giftCardsSold(item models.CheckoutItem, inBundle bool, price int) ([]models.StoredValueAccount, error) {
+	if inBundle {
+		return nil, fmt.Errorf("gift card product id %d cannot be sold in a bundle", item.ProductID)
+	}
+	if len(item.ModifierIDs) > 0 {
+		return nil, fmt.Errorf("gift card product id %d takes no modifiers", item.ProductID)
+	}
+	if !item.Quantity.IsWhole() {
+		return nil, fmt.Errorf("gift card product id %d is sold in whole units", item.ProductID)
+	}
+	if item.GiftCardAmount < 0 {
+		return nil, fmt.Errorf("invalid gift_card_amount for product id %d", item.ProductID)
+	}
+	if item.GiftCardAmount > 0 {
+		price = item.GiftCardAmount
+	}
+	if price <= 0 {
+		return nil, fmt.Errorf("gift card product id %d needs a gift_card_amount", item.ProductID)
+	}
+
+	code := ""
+	if item.GiftCardCode != "" {
+		if item.Quantity != models.Units(1) {
+			return nil, errors.New("A gift_card_code loads a single card")
+		}
+		var err error
+		code, err = models.NormalizeGiftCardCode(item.GiftCardCode)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	cards := make([]models.StoredValueAccount, item.Quantity.Ceil())
+	for i := range cards {
+		cards[i] = models.StoredValueAccount{Code: code, IssuedAmount: price}
+	}
+	return cards, nil
+}
+
+// issueGiftCards loads a new card for every card a sold line holds, with
+// the code given at checkout or a generated one.
+func issueGiftCards(tx *sql.Tx, detail *models.TransactionDetail, actor string) error {
+	for i := range detail.GiftCards {
+		card := &detail.GiftCards[i]
+		if card.Code == "" {
+			code, err := newGiftCardCode()
+			if err != nil {
+				return err
+			}
+			card.Code = code
+		}
+
+		var taken bool
+		err := tx.QueryRow("SELECT EXISTS (SELECT 1 FROM stored_value_accounts WHERE code = $1)", card.Code).Scan(&taken)
+		if err != nil {
+			return err
+		}
+		if taken {
+			return fmt.Errorf("gift card code %s is already in use", card.Code)
+		}
+
+		card.Kind = models.StoredValueGiftCard
+		card.Status = models.StoredValueStatusActive
+		card.TransactionID = &detail.TransactionID
+		query := `
+			INSERT INTO stored_value_accounts (kind, code, issued_amount, balance, status, transaction_detail_id)
+			VALUES ($1, $2, $3, 0, $4, $5)
+			RETURNING id, created_at`
+		err = tx.QueryRow(query, card.Kind, card.Code, card.IssuedAmount, card.Status, detail.ID).Scan(&card.ID, &card.CreatedAt)
+		if err != nil {
+			return err
+		}
+
+		entry := models.StoredValueEntry{
+			AccountID:     card.ID,
+			Amount:        card.IssuedAmount,
+			Reason:        models.StoredValueReasonIssue,
+			TransactionID: &detail.TransactionID,
+			CreatedBy:     actor,
+		}
+		if err := moveStoredValue(tx, &entry); err != nil {
+			return err
+		}
+		card.Balance = entry.Balance
+	}
+	return nil
+}
+
+// lockStoredValue finds the accounts that gift card and store credit
+// payments spend, locks them and checks they hold enough. Accounts are
+// locked in ID order so that two sales spending the same cards cannot
+// deadlock, and a card cannot be spent twice from two terminals at once.
+func lockStoredValue(tx *sql.Tx, payments []models.Payment, customerID *int) error {
+	spend := make(map[int]int)
+	ids := make([]int, 0)
+	for i := range payments {
+		payment := &payments[i]
+		var id int
+		switch payment.Method {
+		case models.PaymentGiftCard:
+			err := tx.QueryRow("SELECT id FROM stored_value_accounts WHERE kind = $1 AND code = $2", models.StoredValueGiftCard, payment.Code).Scan(&id)
+			if err == sql.ErrNoRows {
+				return fmt.Errorf("gift card %s not found", payment.Code)
+			}
+			if err != nil {
+				return err
+			}
+		case models.PaymentStoreCredit:
+			if customerID == nil {
+				return errors.New("A customer is required to pay with store credit")
+			}
+			err := tx.QueryRow("SELECT id FROM stored_value_accounts WHERE kind = $1 AND customer_id = $2", models.StoredValueStoreCredit, *customerID).Scan(&id)
+			if err == sql.ErrNoRows {
+				return errors.New("Customer has no store credit")
+			}
+			if err != nil {
+				return err
+			}
+		default:
+			continue
+		}
+		payment.AccountID = &id
+		if _, ok := spend[id]; !ok {
+			ids = append(ids, id)
+		}
+		spend[id] += payment.Amount
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	sort.Ints(ids)
+
+	rows, err := tx.Query("SELECT "+storedValueColumns+" FROM stored_value_accounts WHERE id = ANY ($1) ORDER BY id FOR UPDATE", pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var account models.StoredValueAccount
+		if err := scanStoredValueAccount(rows, &account); err != nil {
+			return err
+		}
+		name := "store credit"
+		if account.Kind == models.StoredValueGiftCard {
+			name = "gift card " + account.Code
+		}
+		if account.Status != models.StoredValueStatusActive {
+			return fmt.Errorf("%s is %s", name, account.Status)
+		}
+		if spend[account.ID] > account.Balance {
+			return fmt.Errorf("%s has only %d left", name, account.Balance)
+		}
+	}
+	return rows.Err()
+}
+
+// moveStoredValue changes an account's balance by entry.Amount and writes
+// the change to the ledger, setting entry.Balance to the balance after it.
+// The balance never goes below zero.
+func moveStoredValue(tx *sql.Tx, entry *models.StoredValueEntry) error {
+	err := tx.QueryRow("UPDATE stored_value_accounts SET balance = balance + $1 WHERE id = $2 AND balance + $1 >= 0 RETURNING balance", entry.Amount, entry.AccountID).Scan(&entry.Balance)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("stored value account id %d not found or holds less than %d", entry.AccountID, -entry.Amount)
+	}
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO stored_value_ledger (account_id, amount, balance, reason, transaction_id, refund_id, note, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at`
+	return tx.QueryRow(query, entry.AccountID, entry.Amount, entry.Balance, entry.Reason, entry.TransactionID, entry.RefundID, entry.Note, entry.CreatedBy).Scan(&entry.ID, &entry.CreatedAt)
+}
+
+// storeCreditAccount returns a customer's store credit account, opening one
+// when they have none, and locks it.
+func storeCreditAccount(tx *sql.Tx, customerID int) (*models.StoredValueAccount, error) {
+	_, err := tx.Exec(`
+		INSERT INTO stored_value_accounts (kind, customer_id) VALUES ($1, $2)
+		ON CONFLICT (customer_id) WHERE kind = 'store_credit' DO NOTHING
+	`, models.StoredValueStoreCredit, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	var account models.StoredValueAccount
+	row := tx.QueryRow("SELECT "+storedValueColumns+" FROM stored_value_accounts WHERE kind = $1 AND customer_id = $2 FOR UPDATE", models.StoredValueStoreCredit, customerID)
+	if err := scanStoredValueAccount(row, &account); err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// voidGiftCards voids count cards sold on a line that are still unused,
+// for a refund of the line. Cards that have been spent from cannot be
+// refunded.
+func voidGiftCards(tx *sql.Tx, detailID, count, transactionID, refundID int, actor string) error {
+	rows, err := tx.Query(`
+		SELECT id, balance FROM stored_value_accounts
+		WHERE transaction_detail_id = $1 AND status = $2 AND balance = issued_amount
+		ORDER BY id
+		LIMIT $3
+		FOR UPDATE
+	`, detailID, models.StoredValueStatusActive, count)
+	if err != nil {
+		return err
+	}
+
+	type card struct {
+		id, balance int
+	}
+	cards := make([]card, 0, count)
+	for rows.Next() {
+		var c card
+		if err := rows.Scan(&c.id, &c.balance); err != nil {
+			rows.Close()
+			return err
+		}
+		cards = append(cards, c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(cards) < count {
+		return fmt.Errorf("only %d unused gift cards left to refund on transaction detail id %d", len(cards), detailID)
+	}
+
+	for _, c := range cards {
+		if _, err := tx.Exec("UPDATE stored_value_accounts SET status = $1 WHERE id = $2", models.StoredValueStatusVoid, c.id); err != nil {
+			return err
+		}
+		entry := models.StoredValueEntry{
+			AccountID:     c.id,
+			Amount:        -c.balance,
+			Reason:        models.StoredValueReasonVoid,
+			TransactionID: &transactionID,
+			RefundID:      &refundID,
+			CreatedBy:     actor,
+		}
+		if err := moveStoredValue(tx, &entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
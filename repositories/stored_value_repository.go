@@ -0,0 +1,151 @@
+package repositories
+
+import (
+	"cashier-api/models"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+type StoredValueRepository struct {
+	db *sql.DB
+}
+
+func NewStoredValueRepository(db *sql.DB) *StoredValueRepository {
+	return &StoredValueRepository{db: db}
+}
+
+// GetGiftCard looks up a gift card by its code, with its latest limit
+// ledger entries.
+func (repo *StoredValueRepository) GetGiftCard(code string, limit int) (*models.StoredValueAccount, error) {
+	code, err := models.NormalizeGiftCardCode(code)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT ` + storedValueColumns + `, (SELECT td.transaction_id FROM transaction_details td WHERE td.id = sva.transaction_detail_id)
+		FROM stored_value_accounts sva
+		WHERE kind = $1 AND code = $2`
+	var account models.StoredValueAccount
+	err = repo.db.QueryRow(query, models.StoredValueGiftCard, code).Scan(&account.ID, &account.Kind, &account.Code, &account.CustomerID, &account.IssuedAmount, &account.Balance, &account.Status, &account.CreatedAt, &account.TransactionID)
+	if err == sql.ErrNoRows {
+		return nil, errors.New("Gift card not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	account.Ledger, err = storedValueLedger(repo.db, account.ID, limit)
+	if err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// GetStoreCredit returns a customer's store credit, with its latest limit
+// ledger entries. A customer who never had any has a zero balance.
+func (repo *StoredValueRepository) GetStoreCredit(customerID, limit int) (*models.StoredValueAccount, error) {
+	var exists bool
+	err := repo.db.QueryRow("SELECT EXISTS (SELECT 1 FROM customers WHERE id = $1)", customerID).Scan(&exists)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.New("Customer not found")
+	}
+
+	account := models.StoredValueAccount{
+		Kind:       models.StoredValueStoreCredit,
+		CustomerID: &customerID,
+		Status:     models.StoredValueStatusActive,
+		Ledger:     make([]models.StoredValueEntry, 0),
+	}
+	row := repo.db.QueryRow("SELECT "+storedValueColumns+" FROM stored_value_accounts WHERE kind = $1 AND customer_id = $2", models.StoredValueStoreCredit, customerID)
+	err = scanStoredValueAccount(row, &account)
+	if err == sql.ErrNoRows {
+		return &account, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	account.Ledger, err = storedValueLedger(repo.db, account.ID, limit)
+	if err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// AdjustStoreCredit issues store credit to a customer, such as a goodwill
+// gesture, or takes it off when the amount is negative. Taking off more
+// than the customer holds is refused.
+func (repo *StoredValueRepository) AdjustStoreCredit(customerID int, adjustment models.StoredValueAdjustment, meta models.RequestMeta) (*models.StoredValueEntry, error) {
+	if adjustment.Amount == 0 {
+		return nil, errors.New("Amount must not be zero")
+	}
+
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := lockCustomer(tx, customerID); err != nil {
+		return nil, err
+	}
+	account, err := storeCreditAccount(tx, customerID)
+	if err != nil {
+		return nil, err
+	}
+	if -adjustment.Amount > account.Balance {
+		return nil, fmt.Errorf("customer has only %d store credit", account.Balance)
+	}
+
+	entry := models.StoredValueEntry{
+		AccountID: account.ID,
+		Amount:    adjustment.Amount,
+		Reason:    models.StoredValueReasonAdjust,
+		Note:      adjustment.Note,
+		CreatedBy: meta.ActorName(),
+	}
+	if err := moveStoredValue(tx, &entry); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func storedValueLedger(db queryer, accountID, limit int) ([]models.StoredValueEntry, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	if limit > 500 {
+		limit = 500
+	}
+
+	rows, err := db.Query(`
+		SELECT id, account_id, amount, balance, reason, transaction_id, refund_id, note, created_by, created_at
+		FROM stored_value_ledger
+		WHERE account_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2
+	`, accountID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ledger := make([]models.StoredValueEntry, 0)
+	for rows.Next() {
+		var entry models.StoredValueEntry
+		if err := rows.Scan(&entry.ID, &entry.AccountID, &entry.Amount, &entry.Balance, &entry.Reason, &entry.TransactionID, &entry.RefundID, &entry.Note, &entry.CreatedBy, &entry.CreatedAt); err != nil {
+			return nil, err
+		}
+		ledger = append(ledger, entry)
+	}
+	return ledger, rows.Err()
+}
@@ -0,0 +1,136 @@
+package repositories
+
+import (
+	"cashier-api/models"
+	"database/sql"
+	"errors"
+)
+
+type SupplierRepository struct {
+	db *sql.DB
+}
+
+func NewSupplierRepository(db *sql.DB) *SupplierRepository {
+	return &SupplierRepository{db: db}
+}
+
+func (repo *SupplierRepository) GetAll() ([]models.Supplier, error) {
+	query := "SELECT id, name, phone, email, address FROM suppliers ORDER BY name"
+	rows, err := repo.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	suppliers := make([]models.Supplier, 0)
+	for rows.Next() {
+		var supplier models.Supplier
+		err := rows.Scan(&supplier.ID, &supplier.Name, &supplier.Phone, &supplier.Email, &supplier.Address)
+		if err != nil {
+			return nil, err
+		}
+		suppliers = append(suppliers, supplier)
+	}
+
+	return suppliers, nil
+}
+
+func (repo *SupplierRepository) Create(supplier *models.Supplier, meta models.RequestMeta) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := "INSERT INTO suppliers (name, phone, email, address) VALUES ($1, $2, $3, $4) RETURNING id"
+	err = tx.QueryRow(query, supplier.Name, supplier.Phone, supplier.Email, supplier.Address).Scan(&supplier.ID)
+	if err != nil {
+		return err
+	}
+
+	err = insertAuditLog(tx, meta, models.AuditActionCreate, models.AuditEntitySupplier, supplier.ID, nil, supplier)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (repo *SupplierRepository) GetByID(id int) (*models.Supplier, error) {
+	return getSupplier(repo.db, id, "")
+}
+
+func (repo *SupplierRepository) Update(supplier *models.Supplier, meta models.RequestMeta) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	before, err := getSupplier(tx, supplier.ID, "FOR UPDATE")
+	if err != nil {
+		return err
+	}
+
+	query := "UPDATE suppliers SET name = $1, phone = $2, email = $3, address = $4 WHERE id = $5"
+	_, err = tx.Exec(query, supplier.Name, supplier.Phone, supplier.Email, supplier.Address, supplier.ID)
+	if err != nil {
+		return err
+	}
+
+	err = insertAuditLog(tx, meta, models.AuditActionUpdate, models.AuditEntitySupplier, supplier.ID, before, supplier)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (repo *SupplierRepository) Delete(id int, meta models.RequestMeta) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	before, err := getSupplier(tx, id, "FOR UPDATE")
+	if err != nil {
+		return err
+	}
+
+	var orders int
+	err = tx.QueryRow("SELECT COUNT(*) FROM purchase_orders WHERE supplier_id = $1", id).Scan(&orders)
+	if err != nil {
+		return err
+	}
+	if orders > 0 {
+		return errors.New("Supplier has purchase orders")
+	}
+
+	_, err = tx.Exec("DELETE FROM suppliers WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	err = insertAuditLog(tx, meta, models.AuditActionDelete, models.AuditEntitySupplier, id, before, nil)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func getSupplier(db queryer, id int, lock string) (*models.Supplier, error) {
+	query := "SELECT id, name, phone, email, address FROM suppliers WHERE id = $1 " + lock
+
+	var supplier models.Supplier
+	err := db.QueryRow(query, id).Scan(&supplier.ID, &supplier.Name, &supplier.Phone, &supplier.Email, &supplier.Address)
+	if err == sql.ErrNoRows {
+		return nil, errors.New("Supplier not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &supplier, nil
+}
@@ -16,84 +16,744 @@ func NewTransactionRepository(db *sql.DB) *TransactionRepository {
 	return &TransactionRepository{db: db}
 }
 
-func (repo *TransactionRepository) CreateTransaction(items []models.CheckoutItem) (*models.Transaction, error) {
+// CreateTransaction records a sale and decrements stock. Besides the
+// transaction it returns an event for every product the sale took to or below
+// its minimum stock, for the caller to deliver once the sale is committed.
+func (repo *TransactionRepository) CreateTransaction(req models.CheckoutRequest, meta models.RequestMeta) (*models.Transaction, []models.LowStockEvent, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback()
+
+	sale, err := priceSale(tx, req, meta.OutletID)
+	if err != nil {
+		return nil, nil, err
+	}
+	details, bundles, totalAmount := sale.details, sale.bundles, sale.total
+
+	// Points redeemed as a discount come off the lines before the sale is
+	// paid; points paid with settle part of it like any other tender. The
+	// customer is locked before any stock so their points cannot be spent
+	// twice and refunds, which lock in the same order, cannot deadlock.
+	loyalty, err := getLoyaltySettings(tx)
+	if err != nil {
+		return nil, nil, err
+	}
+	if req.RedeemPoints < 0 {
+		return nil, nil, errors.New("Invalid redeem_points")
+	}
+	discountAmount := 0
+	if req.RedeemPoints > 0 {
+		discountAmount, err = redeemAsDiscount(details, req.RedeemPoints, loyalty)
+		if err != nil {
+			return nil, nil, err
+		}
+		totalAmount -= discountAmount
+	}
+	payments, change, err := settlePayments(req.Payments, totalAmount, loyalty)
+	if err != nil {
+		return nil, nil, err
+	}
+	pointsRedeemed, paidWithPoints := req.RedeemPoints, 0
+	for _, payment := range payments {
+		pointsRedeemed += payment.Points
+		if payment.Method == models.PaymentPoints {
+			paidWithPoints += payment.Amount
+		}
+	}
+
+	pointsEarned := 0
+	if req.CustomerID != nil {
+		if err := lockCustomer(tx, *req.CustomerID); err != nil {
+			return nil, nil, err
+		}
+		if err := expirePoints(tx, *req.CustomerID, meta.ActorName()); err != nil {
+			return nil, nil, err
+		}
+		if loyalty.Enabled {
+			pointsEarned, err = earnPoints(tx, details, totalAmount, paidWithPoints, loyalty)
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+	if pointsRedeemed > 0 {
+		if err := checkRedemption(tx, req.CustomerID, pointsRedeemed, loyalty); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := lockStoredValue(tx, payments, req.CustomerID); err != nil {
+		return nil, nil, err
+	}
+	if err := checkCredit(tx, req.CustomerID, payments); err != nil {
+		return nil, nil, err
+	}
+
+	var transactionID int
+	var createdAt time.Time
+	query := `
+		INSERT INTO transactions (total_amount, outlet_id, terminal_id, customer_id, discount_amount, change_amount, points_redeemed, points_earned)
+		VALUES ($1, $2, NULLIF($3, 0), $4, $5, $6, $7, $8)
+		RETURNING id, created_at`
+	err = tx.QueryRow(query, totalAmount, meta.OutletID, meta.TerminalID, req.CustomerID, discountAmount, change, pointsRedeemed, pointsEarned).Scan(&transactionID, &createdAt)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	events, err := takeSaleStock(tx, sale, transactionID, meta)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := insertSaleDetails(tx, sale, transactionID, meta.ActorName()); err != nil {
+		return nil, nil, err
+	}
+
+	if err := insertSalePayments(tx, transactionID, payments, meta.ActorName()); err != nil {
+		return nil, nil, err
+	}
+
+	// Points are redeemed before the sale's own are earned, so a sale never
+	// spends the points it earns.
+	if pointsRedeemed > 0 {
+		err := spendPoints(tx, &models.PointsEntry{
+			CustomerID:    *req.CustomerID,
+			Points:        -pointsRedeemed,
+			Reason:        models.PointsReasonRedeem,
+			TransactionID: &transactionID,
+			CreatedBy:     meta.ActorName(),
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	if pointsEarned > 0 {
+		err := addPoints(tx, &models.PointsEntry{
+			CustomerID:    *req.CustomerID,
+			Points:        pointsEarned,
+			Reason:        models.PointsReasonEarn,
+			TransactionID: &transactionID,
+			CreatedBy:     meta.ActorName(),
+		}, loyalty.ExpiryDays)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, err
+	}
+
+	return &models.Transaction{
+		ID:             transactionID,
+		OutletID:       meta.OutletID,
+		CustomerID:     req.CustomerID,
+		CreatedAt:      createdAt,
+		TotalAmount:    totalAmount,
+		DiscountAmount: discountAmount,
+		Change:         change,
+		PointsRedeemed: pointsRedeemed,
+		PointsEarned:   pointsEarned,
+		Details:        details,
+		Bundles:        bundles,
+		Payments:       payments,
+	}, events, nil
+}
+
+func (repo *TransactionRepository) RefundTransaction(transactionID int, req models.RefundRequest, meta models.RequestMeta) (*models.Refund, error) {
 	tx, err := repo.db.Begin()
 	if err != nil {
 		return nil, err
 	}
 	defer tx.Rollback()
 
-	totalAmount := 0
-	details := make([]models.TransactionDetail, 0)
+	// A split bill is refunded only once every share of it is paid. A split
+	// bill leaves that state only with its order locked, settled or
+	// cancelled, and either way it is then refundable or already refunded.
+	var unsettled bool
+	err = tx.QueryRow("SELECT EXISTS (SELECT 1 FROM orders WHERE transaction_id = $1 AND status = $2)", transactionID, models.OrderSplit).Scan(&unsettled)
+	if err != nil {
+		return nil, err
+	}
+	if unsettled {
+		return nil, errors.New("Transaction is an order still being settled")
+	}
+
+	refund, err := refundSale(tx, transactionID, req, meta, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return refund, nil
+}
+
+// refundSale refunds req from a sale, restocking what comes back and
+// taking back the points it earned. A sale that was never paid, the sale of
+// a split order cancelled before any share was paid, has no payments to
+// give back.
+func refundSale(tx *sql.Tx, transactionID int, req models.RefundRequest, meta models.RequestMeta, paid bool) (*models.Refund, error) {
+	// Locking the transaction row serialises refunds of the same sale, and
+	// the customer's row any change to their points, before stock is
+	// touched as in a checkout.
+	var customerID *int
+	var saleAmount, pointsRedeemed int
+	err := tx.QueryRow("SELECT id, customer_id, total_amount, points_redeemed FROM transactions WHERE id = $1 FOR UPDATE", transactionID).Scan(&transactionID, &customerID, &saleAmount, &pointsRedeemed)
+	if err == sql.ErrNoRows {
+		return nil, errors.New("Transaction not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if customerID != nil {
+		if err := lockCustomer(tx, *customerID); err != nil {
+			return nil, err
+		}
+	}
+
+	// Ingredients used up by modifiers are not restocked, so only the
+	// product's own share of the line cost goes back into stock. Composite
+	// products were made to order from ingredients and are not restocked at
+	// all.
+	query := `
+		SELECT td.id, td.product_id, td.quantity, td.subtotal,
+			td.cost_amount - COALESCE((SELECT SUM(tdm.cost_amount) FROM transaction_detail_modifiers tdm WHERE tdm.transaction_detail_id = td.id), 0),
+			EXISTS (SELECT 1 FROM ingredient_usages iu WHERE iu.transaction_detail_id = td.id),
+			EXISTS (SELECT 1 FROM stored_value_accounts sva WHERE sva.transaction_detail_id = td.id),
+			COALESCE(SUM(ri.quantity), 0), COALESCE(SUM(ri.amount), 0),
+			td.points_earned, COALESCE(SUM(ri.points_reversed), 0)
+		FROM transaction_details td
+		LEFT JOIN refund_items ri ON ri.transaction_detail_id = td.id
+		WHERE td.transaction_id = $1
+		GROUP BY td.id, td.product_id, td.quantity, td.subtotal, td.cost_amount, td.points_earned
+		ORDER BY td.id
+	`
+	rows, err := tx.Query(query, transactionID)
+	if err != nil {
+		return nil, err
+	}
+
+	type refundableLine struct {
+		productID   int
+		quantity    models.Quantity
+		subtotal    int
+		cost        int
+		madeToOrder bool
+		giftCard    bool
+		refunded    models.Quantity
+		amount      int
+		points      int
+		reversed    int
+	}
+	lines := make(map[int]*refundableLine)
+	lineOrder := make([]int, 0)
+	for rows.Next() {
+		var id int
+		var line refundableLine
+		if err := rows.Scan(&id, &line.productID, &line.quantity, &line.subtotal, &line.cost, &line.madeToOrder, &line.giftCard, &line.refunded, &line.amount, &line.points, &line.reversed); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		lines[id] = &line
+		lineOrder = append(lineOrder, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	requested := req.Items
+	if len(requested) == 0 {
+		for _, id := range lineOrder {
+			if remaining := lines[id].quantity - lines[id].refunded; remaining > 0 {
+				requested = append(requested, models.RefundItemRequest{TransactionDetailID: id, Quantity: remaining})
+			}
+		}
+		if len(requested) == 0 {
+			return nil, errors.New("Transaction already fully refunded")
+		}
+	}
+
+	refund := models.Refund{
+		TransactionID: transactionID,
+		OutletID:      meta.OutletID,
+		Reason:        req.Reason,
+		Items:         make([]models.RefundItem, 0, len(requested)),
+	}
+	for _, item := range requested {
+		line, ok := lines[item.TransactionDetailID]
+		if !ok {
+			return nil, fmt.Errorf("transaction detail id %d not found in transaction %d", item.TransactionDetailID, transactionID)
+		}
+		if item.Quantity <= 0 {
+			return nil, fmt.Errorf("invalid quantity for transaction detail id %d", item.TransactionDetailID)
+		}
+		if item.Quantity > line.quantity-line.refunded {
+			return nil, fmt.Errorf("cannot refund %s of transaction detail id %d: only %s refundable", item.Quantity, item.TransactionDetailID, line.quantity-line.refunded)
+		}
 
-	for _, item := range items {
-		var productPrice, stock int
-		var productName string
+		// Amounts and earned points are prorated from the original line;
+		// the refund that clears the line absorbs rounding so the line
+		// refunds exactly what it charged and takes back what it earned.
+		amount := models.Prorate(line.subtotal, item.Quantity, line.quantity)
+		points := models.Prorate(line.points, item.Quantity, line.quantity)
+		if line.refunded+item.Quantity == line.quantity {
+			amount = line.subtotal - line.amount
+			points = line.points - line.reversed
+		}
+		line.refunded += item.Quantity
+		line.amount += amount
+		line.reversed += points
 
-		err := tx.QueryRow("SELECT name, price, stock FROM products WHERE id = $1", item.ProductID).Scan(&productName, &productPrice, &stock)
-		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("product id %d not found", item.ProductID)
+		refund.TotalAmount += amount
+		refund.PointsReversed += points
+		refund.Items = append(refund.Items, models.RefundItem{
+			TransactionDetailID: item.TransactionDetailID,
+			ProductID:           line.productID,
+			Quantity:            item.Quantity,
+			Amount:              amount,
+			PointsReversed:      points,
+		})
+	}
+
+	refund.Payments = make([]models.Payment, 0)
+	if paid {
+		refund.Payments, err = refundPayments(tx, transactionID, refund.TotalAmount)
+		if err != nil {
+			return nil, err
 		}
+		refund.Payments, err = creditOnAccount(tx, refund.Payments)
 		if err != nil {
 			return nil, err
 		}
+	}
+
+	// Store credit takes the place of money paid back; points, gift cards,
+	// store credit and what was bought on account still go back where they
+	// came from.
+	if req.StoreCredit && paid {
+		creditTo := customerID
+		if creditTo == nil {
+			creditTo = req.CustomerID
+		}
+		if creditTo == nil {
+			return nil, errors.New("A customer is required to refund as store credit")
+		}
+		if creditTo != customerID {
+			if err := lockCustomer(tx, *creditTo); err != nil {
+				return nil, err
+			}
+		}
+		account, err := storeCreditAccount(tx, *creditTo)
+		if err != nil {
+			return nil, err
+		}
+		for i := range refund.Payments {
+			payment := &refund.Payments[i]
+			switch payment.Method {
+			case models.PaymentPoints, models.PaymentGiftCard, models.PaymentStoreCredit, models.PaymentOnAccount:
+				continue
+			}
+			payment.Method = models.PaymentStoreCredit
+			payment.Code = ""
+			payment.AccountID = &account.ID
+		}
+	}
+
+	// Points redeemed as a discount come back in proportion to the amount
+	// refunded so far, all of them once the whole sale is refunded.
+	var refundedBefore, restoredBefore, paidPoints int
+	err = tx.QueryRow("SELECT COALESCE(SUM(total_amount), 0), COALESCE(SUM(points_restored), 0) FROM refunds WHERE transaction_id = $1", transactionID).Scan(&refundedBefore, &restoredBefore)
+	if err != nil {
+		return nil, err
+	}
+	err = tx.QueryRow("SELECT COALESCE(SUM(points), 0) FROM transaction_payments WHERE transaction_id = $1", transactionID).Scan(&paidPoints)
+	if err != nil {
+		return nil, err
+	}
+	if discountPoints := pointsRedeemed - paidPoints; discountPoints > 0 && saleAmount > 0 {
+		restored := discountPoints * (refundedBefore + refund.TotalAmount) / saleAmount
+		if refundedBefore+refund.TotalAmount >= saleAmount {
+			restored = discountPoints
+		}
+		refund.PointsRestored = max(restored-restoredBefore, 0)
+	}
+	pointsBack := refund.PointsRestored
+	for _, payment := range refund.Payments {
+		pointsBack += payment.Points
+	}
+
+	// Earned points are taken back only as far as the customer still holds
+	// them once redeemed points are back: points they have already spent
+	// stay spent rather than driving the balance below zero. A sale whose
+	// customer has since been deleted has no balance left to change.
+	if customerID == nil {
+		refund.PointsReversed, refund.PointsRestored, pointsBack = 0, 0, 0
+	} else {
+		if err := expirePoints(tx, *customerID, meta.ActorName()); err != nil {
+			return nil, err
+		}
+		balance, err := pointsBalance(tx, *customerID)
+		if err != nil {
+			return nil, err
+		}
+		refund.PointsReversed = min(refund.PointsReversed, balance+pointsBack)
+	}
 
-		subtotal := productPrice * item.Quantity
-		totalAmount += subtotal
+	// Returned goods go back on the shelf of the outlet taking the return.
+	query = "INSERT INTO refunds (transaction_id, outlet_id, total_amount, points_reversed, points_restored, reason) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, created_at"
+	err = tx.QueryRow(query, transactionID, meta.OutletID, refund.TotalAmount, refund.PointsReversed, refund.PointsRestored, refund.Reason).Scan(&refund.ID, &refund.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
 
-		_, err = tx.Exec("UPDATE products SET stock = stock - $1 WHERE id = $2", item.Quantity, item.ProductID)
+	for _, payment := range refund.Payments {
+		_, err = tx.Exec("INSERT INTO refund_payments (refund_id, transaction_payment_id, method, amount, points, account_id) VALUES ($1, $2, $3, $4, $5, $6)", refund.ID, payment.ID, payment.Method, payment.Amount, payment.Points, payment.AccountID)
 		if err != nil {
 			return nil, err
 		}
+		if payment.AccountID != nil {
+			err := moveStoredValue(tx, &models.StoredValueEntry{
+				AccountID:     *payment.AccountID,
+				Amount:        payment.Amount,
+				Reason:        models.StoredValueReasonRefund,
+				TransactionID: &transactionID,
+				RefundID:      &refund.ID,
+				Note:          refund.Reason,
+				CreatedBy:     meta.ActorName(),
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
 
-		details = append(details, models.TransactionDetail{
-			ProductID:   item.ProductID,
-			ProductName: productName,
-			Quantity:    item.Quantity,
-			Subtotal:    subtotal,
+	if pointsBack > 0 {
+		loyalty, err := getLoyaltySettings(tx)
+		if err != nil {
+			return nil, err
+		}
+		err = addPoints(tx, &models.PointsEntry{
+			CustomerID:    *customerID,
+			Points:        pointsBack,
+			Reason:        models.PointsReasonRestore,
+			TransactionID: &transactionID,
+			RefundID:      &refund.ID,
+			CreatedBy:     meta.ActorName(),
+		}, loyalty.ExpiryDays)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if refund.PointsReversed > 0 {
+		err := spendPoints(tx, &models.PointsEntry{
+			CustomerID:    *customerID,
+			Points:        -refund.PointsReversed,
+			Reason:        models.PointsReasonReverse,
+			TransactionID: &transactionID,
+			RefundID:      &refund.ID,
+			CreatedBy:     meta.ActorName(),
 		})
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	var transactionID int
-	var createdAt time.Time
-	err = tx.QueryRow("INSERT INTO transactions (total_amount) VALUES ($1) RETURNING id, created_at", totalAmount).Scan(&transactionID, &createdAt)
+	for i := range refund.Items {
+		item := &refund.Items[i]
+		line := lines[item.TransactionDetailID]
+
+		// Gift cards sold are voided, as long as nothing has been spent
+		// from them.
+		if line.giftCard {
+			if err := voidGiftCards(tx, item.TransactionDetailID, item.Quantity.Ceil(), transactionID, refund.ID, meta.ActorName()); err != nil {
+				return nil, err
+			}
+		}
+
+		if line.madeToOrder || line.giftCard {
+			err = tx.QueryRow("INSERT INTO refund_items (refund_id, transaction_detail_id, product_id, quantity, amount, cost_amount, points_reversed) VALUES ($1, $2, $3, $4, $5, 0, $6) RETURNING id", refund.ID, item.TransactionDetailID, item.ProductID, item.Quantity, item.Amount, item.PointsReversed).Scan(&item.ID)
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		// Returned units go back into stock at the cost they were sold at,
+		// and into the lots they were sold from.
+		lots, err := returnedLots(tx, item.TransactionDetailID, item.Quantity)
+		if err != nil {
+			return nil, err
+		}
+		movement := models.StockMovement{
+			OutletID:      meta.OutletID,
+			ProductID:     item.ProductID,
+			Quantity:      item.Quantity,
+			CostAmount:    models.Prorate(line.cost, item.Quantity, line.quantity),
+			Reason:        models.StockReasonRefund,
+			ReferenceType: models.StockReferenceRefund,
+			ReferenceID:   refund.ID,
+			Note:          refund.Reason,
+			Actor:         meta.ActorName(),
+			Lots:          lots,
+		}
+		if err := applyStockMovement(tx, &movement); err != nil {
+			return nil, err
+		}
+		item.CostAmount = movement.CostAmount
+
+		err = tx.QueryRow("INSERT INTO refund_items (refund_id, transaction_detail_id, product_id, quantity, amount, cost_amount, points_reversed) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id", refund.ID, item.TransactionDetailID, item.ProductID, item.Quantity, item.Amount, item.CostAmount, item.PointsReversed).Scan(&item.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &refund, nil
+}
+
+// GetByID loads a sale with its lines, the bundles they were sold in, how
+// it was paid, the modifiers chosen on each line, the ingredients used, the
+// gift cards sold and the lots they were sold from.
+func (repo *TransactionRepository) GetByID(id int) (*models.Transaction, error) {
+	return getTransaction(repo.db, id)
+}
+
+func getTransaction(db queryer, id int) (*models.Transaction, error) {
+	var transaction models.Transaction
+	query := `
+		SELECT t.id, t.outlet_id, COALESCE(o.name, ''), t.customer_id, t.total_amount, t.discount_amount, t.change_amount, t.points_redeemed, t.points_earned, t.created_at
+		FROM transactions t
+		LEFT JOIN outlets o ON o.id = t.outlet_id
+		WHERE t.id = $1
+	`
+	var outletID sql.NullInt64
+	err := db.QueryRow(query, id).Scan(&transaction.ID, &outletID, &transaction.OutletName, &transaction.CustomerID, &transaction.TotalAmount, &transaction.DiscountAmount, &transaction.Change, &transaction.PointsRedeemed, &transaction.PointsEarned, &transaction.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, errors.New("Transaction not found")
+	}
 	if err != nil {
 		return nil, err
 	}
+	transaction.OutletID = int(outletID.Int64)
 
-	for i := range details {
-		var transactionDetailID int
+	rows, err := db.Query(`
+		SELECT td.id, td.product_id, COALESCE(p.name, 'Product #' || td.product_id), td.quantity, td.subtotal, td.cost_amount, td.transaction_bundle_id, td.price_list_id, COALESCE(pl.name, ''), td.discount_amount, td.points_earned, td.order_settlement_id
+		FROM transaction_details td
+		LEFT JOIN products p ON p.id = td.product_id
+		LEFT JOIN price_lists pl ON pl.id = td.price_list_id
+		WHERE td.transaction_id = $1
+		ORDER BY td.id
+	`, id)
+	if err != nil {
+		return nil, err
+	}
 
-		details[i].TransactionID = transactionID
+	transaction.Details = make([]models.TransactionDetail, 0)
+	index := make(map[int]int)
+	for rows.Next() {
+		detail := models.TransactionDetail{TransactionID: id}
+		if err := rows.Scan(&detail.ID, &detail.ProductID, &detail.ProductName, &detail.Quantity, &detail.Subtotal, &detail.CostAmount, &detail.TransactionBundleID, &detail.PriceListID, &detail.PriceListName, &detail.DiscountAmount, &detail.PointsEarned, &detail.OrderSettlementID); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		index[detail.ID] = len(transaction.Details)
+		transaction.Details = append(transaction.Details, detail)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
-		err = tx.QueryRow("INSERT INTO transaction_details (transaction_id, product_id, quantity, subtotal) VALUES ($1, $2, $3, $4) RETURNING id", transactionID, details[i].ProductID, details[i].Quantity, details[i].Subtotal).Scan(&transactionDetailID)
-		if err != nil {
+	rows, err = db.Query("SELECT id, bundle_id, name, price, quantity, subtotal FROM transaction_bundles WHERE transaction_id = $1 ORDER BY id", id)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var bundle models.TransactionBundle
+		if err := rows.Scan(&bundle.ID, &bundle.BundleID, &bundle.Name, &bundle.Price, &bundle.Quantity, &bundle.Subtotal); err != nil {
+			rows.Close()
 			return nil, err
 		}
+		transaction.Bundles = append(transaction.Bundles, bundle)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
-		details[i].ID = transactionDetailID
+	transaction.Payments = make([]models.Payment, 0)
+	rows, err = db.Query(`
+		SELECT tp.id, tp.method, tp.amount, tp.points, tp.account_id, COALESCE(sva.code, '')
+		FROM transaction_payments tp
+		LEFT JOIN stored_value_accounts sva ON sva.id = tp.account_id
+		WHERE tp.transaction_id = $1
+		ORDER BY tp.id
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var payment models.Payment
+		if err := rows.Scan(&payment.ID, &payment.Method, &payment.Amount, &payment.Points, &payment.AccountID, &payment.Code); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		transaction.Payments = append(transaction.Payments, payment)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
 	}
 
-	if err := tx.Commit(); err != nil {
+	rows, err = db.Query(`
+		SELECT tdm.transaction_detail_id, tdm.modifier_id, tdm.name, tdm.price, tdm.cost_amount
+		FROM transaction_detail_modifiers tdm
+		JOIN transaction_details td ON td.id = tdm.transaction_detail_id
+		WHERE td.transaction_id = $1
+		ORDER BY tdm.id
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var detailID int
+		var modifier models.TransactionDetailModifier
+		if err := rows.Scan(&detailID, &modifier.ModifierID, &modifier.Name, &modifier.Price, &modifier.CostAmount); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		detail := &transaction.Details[index[detailID]]
+		detail.Modifiers = append(detail.Modifiers, modifier)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
 		return nil, err
 	}
 
-	return &models.Transaction{
-		ID:          transactionID,
-		CreatedAt:   createdAt,
-		TotalAmount: totalAmount,
-		Details:     details,
-	}, nil
+	rows, err = db.Query(`
+		SELECT iu.transaction_detail_id, iu.product_id, iu.quantity, iu.cost_amount
+		FROM ingredient_usages iu
+		JOIN transaction_details td ON td.id = iu.transaction_detail_id
+		WHERE td.transaction_id = $1
+		ORDER BY iu.id
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var detailID int
+		var usage models.IngredientUsage
+		if err := rows.Scan(&detailID, &usage.ProductID, &usage.Quantity, &usage.CostAmount); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		detail := &transaction.Details[index[detailID]]
+		detail.Ingredients = append(detail.Ingredients, usage)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = db.Query(`
+		SELECT sva.transaction_detail_id, sva.id, sva.kind, COALESCE(sva.code, ''), sva.customer_id, sva.issued_amount, sva.balance, sva.status, sva.created_at
+		FROM stored_value_accounts sva
+		JOIN transaction_details td ON td.id = sva.transaction_detail_id
+		WHERE td.transaction_id = $1
+		ORDER BY sva.id
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var detailID int
+		var card models.StoredValueAccount
+		if err := rows.Scan(&detailID, &card.ID, &card.Kind, &card.Code, &card.CustomerID, &card.IssuedAmount, &card.Balance, &card.Status, &card.CreatedAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		card.TransactionID = &transaction.ID
+		detail := &transaction.Details[index[detailID]]
+		detail.GiftCards = append(detail.GiftCards, card)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = db.Query(`
+		SELECT tdl.transaction_detail_id, tdl.lot_id, l.batch_number, TO_CHAR(l.expiry_date, 'YYYY-MM-DD'), tdl.quantity
+		FROM transaction_detail_lots tdl
+		JOIN transaction_details td ON td.id = tdl.transaction_detail_id
+		JOIN lots l ON l.id = tdl.lot_id
+		WHERE td.transaction_id = $1
+		ORDER BY tdl.id
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var detailID int
+		var lot models.LotAllocation
+		if err := rows.Scan(&detailID, &lot.LotID, &lot.BatchNumber, &lot.ExpiryDate, &lot.Quantity); err != nil {
+			return nil, err
+		}
+		detail := &transaction.Details[index[detailID]]
+		detail.Lots = append(detail.Lots, lot)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return &transaction, nil
 }
 
-func (repo *TransactionRepository) GetReport() (*models.Report, error) {
+// GetReport summarises today's sales at one outlet, or across all outlets
+// when outletID is 0.
+// paidSale leaves out the sale t of a split order still being settled, with
+// the order status split as the query's second argument.
+const paidSale = " AND NOT EXISTS (SELECT 1 FROM orders o WHERE o.transaction_id = t.id AND o.status = $2)"
+
+// GetReport sums up today's sales, less what was refunded today. The sale of
+// a split order is left out until every share of it is paid.
+func (repo *TransactionRepository) GetReport(outletID int) (*models.Report, error) {
 	var report models.Report
 
-	query := "SELECT COALESCE(SUM(total_amount), 0) AS total_amount, COUNT(*) AS total_transaction FROM transactions WHERE DATE(created_at) = CURRENT_DATE"
-	err := repo.db.QueryRow(query).Scan(&report.TotalRevenue, &report.TotalTransaction)
+	query := "SELECT COALESCE(SUM(t.total_amount), 0) AS total_amount, COUNT(*) AS total_transaction FROM transactions t WHERE DATE(t.created_at) = CURRENT_DATE AND ($1 = 0 OR t.outlet_id = $1)" + paidSale
+	err := repo.db.QueryRow(query, outletID, models.OrderSplit).Scan(&report.TotalRevenue, &report.TotalTransaction)
 	if err == sql.ErrNoRows {
 		return nil, errors.New("Report not found")
 	}
+	if err != nil {
+		return nil, err
+	}
+
+	queryCost := "SELECT COALESCE(SUM(td.cost_amount), 0) FROM transaction_details td JOIN transactions t ON t.id = td.transaction_id WHERE DATE(t.created_at) = CURRENT_DATE AND ($1 = 0 OR t.outlet_id = $1)" + paidSale
+	err = repo.db.QueryRow(queryCost, outletID, models.OrderSplit).Scan(&report.TotalCost)
+	if err != nil {
+		return nil, err
+	}
+
+	// Refunds count against the day they are made at the outlet taking them.
+	var refunded, refundedCost int
+	queryRefunds := `
+		SELECT
+			COALESCE((SELECT SUM(r.total_amount) FROM refunds r WHERE DATE(r.created_at) = CURRENT_DATE AND ($1 = 0 OR r.outlet_id = $1)), 0),
+			COALESCE((SELECT SUM(ri.cost_amount) FROM refund_items ri JOIN refunds r ON r.id = ri.refund_id WHERE DATE(r.created_at) = CURRENT_DATE AND ($1 = 0 OR r.outlet_id = $1)), 0)
+	`
+	err = repo.db.QueryRow(queryRefunds, outletID).Scan(&refunded, &refundedCost)
+	if err != nil {
+		return nil, err
+	}
+	report.TotalRevenue -= refunded
+	report.TotalCost -= refundedCost
+	report.GrossProfit = report.TotalRevenue - report.TotalCost
+	report.MarginPercent = models.MarginPercent(report.GrossProfit, report.TotalRevenue)
 
 	queryTopProduct := `
 		SELECT
@@ -102,12 +762,12 @@ func (repo *TransactionRepository) GetReport() (*models.Report, error) {
 		FROM transaction_details td
 		JOIN transactions t ON t.id = td.transaction_id
 		JOIN products p ON td.product_id = p.id
-		WHERE DATE(t.created_at) = CURRENT_DATE
+		WHERE DATE(t.created_at) = CURRENT_DATE AND ($1 = 0 OR t.outlet_id = $1)` + paidSale + `
 		GROUP BY td.product_id, p.name
 		ORDER BY total_qty DESC
 		LIMIT 1
 	`
-	err = repo.db.QueryRow(queryTopProduct).Scan(&report.TopSellProduct.Name, &report.TopSellProduct.QuantitySell)
+	err = repo.db.QueryRow(queryTopProduct, outletID, models.OrderSplit).Scan(&report.TopSellProduct.Name, &report.TopSellProduct.QuantitySell)
 	if err == sql.ErrNoRows {
 		return nil, errors.New("Report not found")
 	}
@@ -118,3 +778,89 @@ func (repo *TransactionRepository) GetReport() (*models.Report, error) {
 
 	return &report, nil
 }
+
+func (repo *TransactionRepository) GetGrossProfitReport(filter models.GrossProfitFilter) (*models.GrossProfitReport, error) {
+	var key, name string
+	switch filter.GroupBy {
+	case models.GrossProfitByProduct:
+		key, name = "s.product_id", "COALESCE(p.name, 'Product #' || s.product_id)"
+	case models.GrossProfitByParent:
+		// Variants roll up into their parent; other products stand alone.
+		key, name = "COALESCE(p.parent_id, s.product_id)", "COALESCE(pp.name, p.name, 'Product #' || s.product_id)"
+	case models.GrossProfitByCategory:
+		key, name = "COALESCE(c.id, 0)", "COALESCE(c.name, 'Uncategorized')"
+	case models.GrossProfitByDay:
+		key, name = "0", "TO_CHAR(s.created_at, 'YYYY-MM-DD')"
+	case models.GrossProfitByMonth:
+		key, name = "0", "TO_CHAR(s.created_at, 'YYYY-MM')"
+	default:
+		return nil, fmt.Errorf("invalid group_by %q", filter.GroupBy)
+	}
+
+	args := []interface{}{}
+	saleWhere, refundWhere := "", ""
+	if filter.OutletID != 0 {
+		args = append(args, filter.OutletID)
+		saleWhere += fmt.Sprintf(" AND t.outlet_id = $%d", len(args))
+		refundWhere += fmt.Sprintf(" AND r.outlet_id = $%d", len(args))
+	}
+	if filter.From != nil {
+		args = append(args, *filter.From)
+		saleWhere += fmt.Sprintf(" AND t.created_at >= $%d", len(args))
+		refundWhere += fmt.Sprintf(" AND r.created_at >= $%d", len(args))
+	}
+	if filter.To != nil {
+		args = append(args, *filter.To)
+		saleWhere += fmt.Sprintf(" AND t.created_at < $%d", len(args))
+		refundWhere += fmt.Sprintf(" AND r.created_at < $%d", len(args))
+	}
+
+	query := `
+		WITH sales AS (
+			SELECT td.product_id, t.created_at, td.quantity, td.subtotal AS revenue, td.cost_amount AS cost
+			FROM transaction_details td
+			JOIN transactions t ON t.id = td.transaction_id
+			WHERE 1 = 1` + saleWhere + `
+			UNION ALL
+			SELECT ri.product_id, r.created_at, -ri.quantity, -ri.amount, -ri.cost_amount
+			FROM refund_items ri
+			JOIN refunds r ON r.id = ri.refund_id
+			WHERE 1 = 1` + refundWhere + `
+		)
+		SELECT ` + key + ` AS key, ` + name + ` AS name, SUM(s.quantity), SUM(s.revenue), SUM(s.cost)
+		FROM sales s
+		LEFT JOIN products p ON p.id = s.product_id
+		LEFT JOIN parent_products pp ON pp.id = p.parent_id
+		LEFT JOIN categories c ON c.id = p.category_id
+		GROUP BY 1, 2
+		ORDER BY 2
+	`
+	rows, err := repo.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	report := models.GrossProfitReport{
+		GroupBy: filter.GroupBy,
+		Total:   models.GrossProfitRow{Name: "Total"},
+		Rows:    make([]models.GrossProfitRow, 0),
+	}
+	for rows.Next() {
+		var row models.GrossProfitRow
+		if err := rows.Scan(&row.ID, &row.Name, &row.Quantity, &row.Revenue, &row.Cost); err != nil {
+			return nil, err
+		}
+		row.GrossProfit = row.Revenue - row.Cost
+		row.MarginPercent = models.MarginPercent(row.GrossProfit, row.Revenue)
+		report.Rows = append(report.Rows, row)
+
+		report.Total.Quantity += row.Quantity
+		report.Total.Revenue += row.Revenue
+		report.Total.Cost += row.Cost
+	}
+	report.Total.GrossProfit = report.Total.Revenue - report.Total.Cost
+	report.Total.MarginPercent = models.MarginPercent(report.Total.GrossProfit, report.Total.Revenue)
+
+	return &report, rows.Err()
+}
@@ -0,0 +1,18 @@
+package services
+
+import (
+	"cashier-api/models"
+	"cashier-api/repositories"
+)
+
+type AuditLogService struct {
+	repo *repositories.AuditLogRepository
+}
+
+func NewAuditLogService(repo *repositories.AuditLogRepository) *AuditLogService {
+	return &AuditLogService{repo: repo}
+}
+
+func (s *AuditLogService) GetAll(filter models.AuditLogFilter) ([]models.AuditLog, error) {
+	return s.repo.GetAll(filter)
+}
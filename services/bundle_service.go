@@ -0,0 +1,38 @@
+package services
+
+import (
+	"cashier-api/models"
+	"cashier-api/repositories"
+)
+
+type BundleService struct {
+	repo *repositories.BundleRepository
+}
+
+func NewBundleService(repo *repositories.BundleRepository) *BundleService {
+	return &BundleService{repo: repo}
+}
+
+func (s *BundleService) GetAll(outletID int) ([]models.Bundle, error) {
+	return s.repo.GetAll(outletID)
+}
+
+func (s *BundleService) GetByID(id, outletID int) (*models.Bundle, error) {
+	return s.repo.GetByID(id, outletID)
+}
+
+func (s *BundleService) Create(bundle *models.Bundle, meta models.RequestMeta) error {
+	return s.repo.Create(bundle, meta)
+}
+
+func (s *BundleService) Update(bundle *models.Bundle, meta models.RequestMeta) error {
+	return s.repo.Update(bundle, meta)
+}
+
+func (s *BundleService) Delete(id int, meta models.RequestMeta) error {
+	return s.repo.Delete(id, meta)
+}
+
+func (s *BundleService) GetSalesReport(filter models.BundleSalesFilter) (*models.BundleSalesReport, error) {
+	return s.repo.GetSalesReport(filter)
+}
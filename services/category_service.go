@@ -17,18 +17,18 @@ func (s *CategoryService) GetAll() ([]models.Category, error) {
 	return s.repo.GetAll()
 }
 
-func (s *CategoryService) Create(data *models.Category) error {
-	return s.repo.Create(data)
+func (s *CategoryService) Create(data *models.Category, meta models.RequestMeta) error {
+	return s.repo.Create(data, meta)
 }
 
 func (s *CategoryService) GetByID(id int) (*models.Category, error) {
 	return s.repo.GetByID(id)
 }
 
-func (s *CategoryService) Update(category *models.Category) error {
-	return s.repo.Update(category)
+func (s *CategoryService) Update(category *models.Category, meta models.RequestMeta) error {
+	return s.repo.Update(category, meta)
 }
 
-func (s *CategoryService) Delete(id int) error {
-	return s.repo.Delete(id)
+func (s *CategoryService) Delete(id int, meta models.RequestMeta) error {
+	return s.repo.Delete(id, meta)
 }
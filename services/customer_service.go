@@ -0,0 +1,58 @@
+package services
+
+import (
+	"cashier-api/models"
+	"cashier-api/repositories"
+)
+
+type CustomerService struct {
+	repo *repositories.CustomerRepository
+}
+
+func NewCustomerService(repo *repositories.CustomerRepository) *CustomerService {
+	return &CustomerService{repo: repo}
+}
+
+func (s *CustomerService) GetAll(filter models.CustomerFilter) ([]models.Customer, error) {
+	return s.repo.GetAll(filter)
+}
+
+func (s *CustomerService) GetByID(id int) (*models.Customer, error) {
+	return s.repo.GetByID(id)
+}
+
+func (s *CustomerService) GetHistory(id, limit int) (*models.CustomerHistory, error) {
+	return s.repo.GetHistory(id, limit)
+}
+
+func (s *CustomerService) Create(customer *models.Customer, meta models.RequestMeta) error {
+	return s.repo.Create(customer, meta)
+}
+
+func (s *CustomerService) Update(customer *models.Customer, meta models.RequestMeta) error {
+	return s.repo.Update(customer, meta)
+}
+
+func (s *CustomerService) Delete(id int, meta models.RequestMeta) error {
+	return s.repo.Delete(id, meta)
+}
+
+func (s *CustomerService) GetGroups() ([]models.CustomerGroup, error) {
+	return s.repo.GetGroups()
+}
+
+func (s *CustomerService) GetGroupByID(id int) (*models.CustomerGroup, error) {
+	return s.repo.GetGroupByID(id)
+}
+
+func (s *CustomerService) CreateGroup(group *models.CustomerGroup, meta models.RequestMeta) error {
+	return s.repo.CreateGroup(group, meta)
+}
+
+func (s *CustomerService) UpdateGroup(group *models.CustomerGroup, meta models.RequestMeta) error {
+	return s.repo.UpdateGroup(group, meta)
+}
+
+func (s *CustomerService) DeleteGroup(id int, meta models.RequestMeta) error {
+	return s.repo.DeleteGroup(id, meta)
+}
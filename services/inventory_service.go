@@ -0,0 +1,34 @@
+package services
+
+import (
+	"cashier-api/models"
+	"cashier-api/repositories"
+)
+
+type InventoryService struct {
+	repo *repositories.InventoryRepository
+}
+
+func NewInventoryService(repo *repositories.InventoryRepository) *InventoryService {
+	return &InventoryService{repo: repo}
+}
+
+func (s *InventoryService) GetLowStock(outletID int) ([]models.LowStockItem, error) {
+	return s.repo.GetLowStock(outletID)
+}
+
+func (s *InventoryService) CreateSuggestedPurchaseOrders(meta models.RequestMeta) (*models.SuggestedPurchaseOrders, error) {
+	return s.repo.CreateSuggestedPurchaseOrders(meta)
+}
+
+func (s *InventoryService) GetValuation() (*models.InventoryValuation, error) {
+	return s.repo.GetValuation()
+}
+
+func (s *InventoryService) GetLots(productID, outletID int) ([]models.Lot, error) {
+	return s.repo.GetLots(productID, outletID)
+}
+
+func (s *InventoryService) GetNearExpiry(days, outletID int) (*models.NearExpiryReport, error) {
+	return s.repo.GetNearExpiry(days, outletID)
+}
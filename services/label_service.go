@@ -0,0 +1,26 @@
+package services
+
+import (
+	"cashier-api/models"
+	"cashier-api/repositories"
+)
+
+type LabelService struct {
+	repo *repositories.LabelRepository
+}
+
+func NewLabelService(repo *repositories.LabelRepository) *LabelService {
+	return &LabelService{repo: repo}
+}
+
+func (s *LabelService) GetProducts(ids []int, outletID int) ([]models.Product, error) {
+	return s.repo.GetProducts(ids, outletID)
+}
+
+func (s *LabelService) GetChanged(outletID int) ([]models.LabelChange, error) {
+	return s.repo.GetChanged(outletID)
+}
+
+func (s *LabelService) CreatePrintRun(req models.LabelPrintRequest, meta models.RequestMeta) (*models.LabelPrintRun, error) {
+	return s.repo.CreatePrintRun(req, meta)
+}
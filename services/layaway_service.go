@@ -0,0 +1,61 @@
+package services
+
+import (
+	"cashier-api/models"
+	"cashier-api/notifiers"
+	"cashier-api/repositories"
+)
+
+type LayawayService struct {
+	repo     *repositories.LayawayRepository
+	notifier notifiers.Notifier
+}
+
+func NewLayawayService(repo *repositories.LayawayRepository, notifier notifiers.Notifier) *LayawayService {
+	return &LayawayService{repo: repo, notifier: notifier}
+}
+
+func (s *LayawayService) GetSettings() (*models.LayawaySettings, error) {
+	return s.repo.GetSettings()
+}
+
+func (s *LayawayService) UpdateSettings(settings *models.LayawaySettings, meta models.RequestMeta) error {
+	return s.repo.UpdateSettings(settings, meta)
+}
+
+func (s *LayawayService) GetAll(filter models.LayawayFilter) ([]models.Layaway, error) {
+	return s.repo.GetAll(filter)
+}
+
+func (s *LayawayService) GetByID(id int) (*models.Layaway, error) {
+	return s.repo.GetByID(id)
+}
+
+func (s *LayawayService) Create(req models.LayawayRequest, meta models.RequestMeta) (*models.Layaway, error) {
+	return s.repo.Create(req, meta)
+}
+
+func (s *LayawayService) AddPayment(id int, payment models.Payment, meta models.RequestMeta) (*models.Layaway, error) {
+	return s.repo.AddPayment(id, payment, meta)
+}
+
+func (s *LayawayService) Complete(id int, meta models.RequestMeta) (*models.Layaway, error) {
+	layaway, events, err := s.repo.Complete(id, meta)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, event := range events {
+		s.notifier.NotifyLowStock(event)
+	}
+
+	return layaway, nil
+}
+
+func (s *LayawayService) Cancel(id int, req models.LayawayCancelRequest, meta models.RequestMeta) (*models.Layaway, error) {
+	return s.repo.Cancel(id, req, meta)
+}
+
+func (s *LayawayService) GetReport(filter models.LayawayReportFilter) (*models.LayawayReport, error) {
+	return s.repo.GetReport(filter)
+}
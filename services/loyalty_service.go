@@ -0,0 +1,30 @@
+package services
+
+import (
+	"cashier-api/models"
+	"cashier-api/repositories"
+)
+
+type LoyaltyService struct {
+	repo *repositories.LoyaltyRepository
+}
+
+func NewLoyaltyService(repo *repositories.LoyaltyRepository) *LoyaltyService {
+	return &LoyaltyService{repo: repo}
+}
+
+func (s *LoyaltyService) GetSettings() (*models.LoyaltySettings, error) {
+	return s.repo.GetSettings()
+}
+
+func (s *LoyaltyService) UpdateSettings(settings *models.LoyaltySettings, meta models.RequestMeta) error {
+	return s.repo.UpdateSettings(settings, meta)
+}
+
+func (s *LoyaltyService) GetAccount(customerID, limit int, meta models.RequestMeta) (*models.PointsAccount, error) {
+	return s.repo.GetAccount(customerID, limit, meta)
+}
+
+func (s *LoyaltyService) Adjust(customerID int, adjustment models.PointsAdjustment, meta models.RequestMeta) (*models.PointsEntry, error) {
+	return s.repo.Adjust(customerID, adjustment, meta)
+}
@@ -0,0 +1,42 @@
+package services
+
+import (
+	"cashier-api/models"
+	"cashier-api/repositories"
+)
+
+type ModifierService struct {
+	repo *repositories.ModifierRepository
+}
+
+func NewModifierService(repo *repositories.ModifierRepository) *ModifierService {
+	return &ModifierService{repo: repo}
+}
+
+func (s *ModifierService) GetAll() ([]models.ModifierGroup, error) {
+	return s.repo.GetAll()
+}
+
+func (s *ModifierService) GetByID(id int) (*models.ModifierGroup, error) {
+	return s.repo.GetByID(id)
+}
+
+func (s *ModifierService) Create(group *models.ModifierGroup, meta models.RequestMeta) error {
+	return s.repo.Create(group, meta)
+}
+
+func (s *ModifierService) Update(group *models.ModifierGroup, meta models.RequestMeta) error {
+	return s.repo.Update(group, meta)
+}
+
+func (s *ModifierService) Delete(id int, meta models.RequestMeta) error {
+	return s.repo.Delete(id, meta)
+}
+
+func (s *ModifierService) GetProductGroups(productID int) ([]models.ModifierGroup, error) {
+	return s.repo.GetProductGroups(productID)
+}
+
+func (s *ModifierService) SetProductGroups(productID int, groupIDs []int) ([]models.ModifierGroup, error) {
+	return s.repo.SetProductGroups(productID, groupIDs)
+}
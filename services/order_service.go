@@ -0,0 +1,61 @@
+package services
+
+import (
+	"cashier-api/models"
+	"cashier-api/notifiers"
+	"cashier-api/repositories"
+)
+
+type OrderService struct {
+	repo     *repositories.OrderRepository
+	notifier notifiers.Notifier
+}
+
+func NewOrderService(repo *repositories.OrderRepository, notifier notifiers.Notifier) *OrderService {
+	return &OrderService{repo: repo, notifier: notifier}
+}
+
+func (s *OrderService) GetAll(filter models.OrderFilter) ([]models.Order, error) {
+	return s.repo.GetAll(filter)
+}
+
+func (s *OrderService) GetByID(id int) (*models.Order, error) {
+	return s.repo.GetByID(id)
+}
+
+func (s *OrderService) Create(req models.OrderRequest, meta models.RequestMeta) (*models.Order, error) {
+	return s.repo.Create(req, meta)
+}
+
+func (s *OrderService) AddItems(id int, req models.OrderItemsRequest, meta models.RequestMeta) (*models.Order, error) {
+	return s.repo.AddItems(id, req, meta)
+}
+
+func (s *OrderService) RemoveItem(id, itemID int, meta models.RequestMeta) (*models.Order, error) {
+	return s.repo.RemoveItem(id, itemID, meta)
+}
+
+func (s *OrderService) Split(id int, req models.SplitRequest, meta models.RequestMeta) (*models.Order, error) {
+	order, events, err := s.repo.Split(id, req, meta)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, event := range events {
+		s.notifier.NotifyLowStock(event)
+	}
+
+	return order, nil
+}
+
+func (s *OrderService) PaySettlement(id, settlementID int, req models.SettlementRequest, meta models.RequestMeta) (*models.Order, error) {
+	return s.repo.PaySettlement(id, settlementID, req, meta)
+}
+
+func (s *OrderService) Cancel(id int, req models.OrderCancelRequest, meta models.RequestMeta) (*models.Order, error) {
+	return s.repo.Cancel(id, req, meta)
+}
+
+func (s *OrderService) GetReceipt(id int) (*models.Order, *models.Transaction, error) {
+	return s.repo.GetReceipt(id)
+}
@@ -0,0 +1,55 @@
+package services
+
+import (
+	"cashier-api/models"
+	"cashier-api/repositories"
+	"time"
+)
+
+type OutletService struct {
+	repo *repositories.OutletRepository
+}
+
+func NewOutletService(repo *repositories.OutletRepository) *OutletService {
+	return &OutletService{repo: repo}
+}
+
+func (s *OutletService) GetAll() ([]models.Outlet, error) {
+	return s.repo.GetAll()
+}
+
+func (s *OutletService) Create(outlet *models.Outlet, meta models.RequestMeta) error {
+	return s.repo.Create(outlet, meta)
+}
+
+func (s *OutletService) GetByID(id int) (*models.Outlet, error) {
+	return s.repo.GetByID(id)
+}
+
+func (s *OutletService) Update(outlet *models.Outlet, meta models.RequestMeta) error {
+	return s.repo.Update(outlet, meta)
+}
+
+func (s *OutletService) GetTerminals(outletID int) ([]models.Terminal, error) {
+	return s.repo.GetTerminals(outletID)
+}
+
+func (s *OutletService) CreateTerminal(terminal *models.Terminal) error {
+	return s.repo.CreateTerminal(terminal)
+}
+
+func (s *OutletService) DeleteTerminal(outletID, terminalID int) error {
+	return s.repo.DeleteTerminal(outletID, terminalID)
+}
+
+func (s *OutletService) GetTerminalByAPIKey(key string) (*models.Terminal, error) {
+	return s.repo.GetTerminalByAPIKey(key)
+}
+
+func (s *OutletService) SetPrice(price *models.OutletPrice, meta models.RequestMeta) error {
+	return s.repo.SetPrice(price, meta)
+}
+
+func (s *OutletService) GetReport(from, to *time.Time) (*models.OutletReport, error) {
+	return s.repo.GetReport(from, to)
+}
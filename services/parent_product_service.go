@@ -0,0 +1,38 @@
+package services
+
+import (
+	"cashier-api/models"
+	"cashier-api/repositories"
+)
+
+type ParentProductService struct {
+	repo *repositories.ParentProductRepository
+}
+
+func NewParentProductService(repo *repositories.ParentProductRepository) *ParentProductService {
+	return &ParentProductService{repo: repo}
+}
+
+func (s *ParentProductService) GetAll() ([]models.ParentProduct, error) {
+	return s.repo.GetAll()
+}
+
+func (s *ParentProductService) GetByID(id, outletID int) (*models.ParentProduct, error) {
+	return s.repo.GetByID(id, outletID)
+}
+
+func (s *ParentProductService) Create(parent *models.ParentProduct, meta models.RequestMeta) error {
+	return s.repo.Create(parent, meta)
+}
+
+func (s *ParentProductService) Update(parent *models.ParentProduct, meta models.RequestMeta) error {
+	return s.repo.Update(parent, meta)
+}
+
+func (s *ParentProductService) Delete(id int, meta models.RequestMeta) error {
+	return s.repo.Delete(id, meta)
+}
+
+func (s *ParentProductService) GetCatalog(outletID int) (*models.Catalog, error) {
+	return s.repo.GetCatalog(outletID)
+}
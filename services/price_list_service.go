@@ -0,0 +1,34 @@
+package services
+
+import (
+	"cashier-api/models"
+	"cashier-api/repositories"
+)
+
+type PriceListService struct {
+	repo *repositories.PriceListRepository
+}
+
+func NewPriceListService(repo *repositories.PriceListRepository) *PriceListService {
+	return &PriceListService{repo: repo}
+}
+
+func (s *PriceListService) GetAll() ([]models.PriceList, error) {
+	return s.repo.GetAll()
+}
+
+func (s *PriceListService) GetByID(id int) (*models.PriceList, error) {
+	return s.repo.GetByID(id)
+}
+
+func (s *PriceListService) Create(list *models.PriceList, meta models.RequestMeta) error {
+	return s.repo.Create(list, meta)
+}
+
+func (s *PriceListService) Update(list *models.PriceList, meta models.RequestMeta) error {
+	return s.repo.Update(list, meta)
+}
+
+func (s *PriceListService) Delete(id int, meta models.RequestMeta) error {
+	return s.repo.Delete(id, meta)
+}
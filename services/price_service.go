@@ -0,0 +1,26 @@
+package services
+
+import (
+	"cashier-api/models"
+	"cashier-api/repositories"
+)
+
+type PriceService struct {
+	repo *repositories.PriceRepository
+}
+
+func NewPriceService(repo *repositories.PriceRepository) *PriceService {
+	return &PriceService{repo: repo}
+}
+
+func (s *PriceService) GetHistory(productID int) (*models.ProductPriceHistory, error) {
+	return s.repo.GetHistory(productID)
+}
+
+func (s *PriceService) Schedule(productID int, req models.ProductPriceRequest, meta models.RequestMeta) (*models.ProductPrice, error) {
+	return s.repo.Schedule(productID, req, meta)
+}
+
+func (s *PriceService) Cancel(productID, priceID int, meta models.RequestMeta) error {
+	return s.repo.Cancel(productID, priceID, meta)
+}
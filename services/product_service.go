@@ -13,22 +13,26 @@ func NewProductService(repo *repositories.ProductRepository) *ProductService {
 	return &ProductService{repo: repo}
 }
 
-func (s *ProductService) GetAll(name string) ([]models.Product, error) {
-	return s.repo.GetAll(name)
+func (s *ProductService) GetAll(name string, outletID int) ([]models.Product, error) {
+	return s.repo.GetAll(name, outletID)
 }
 
-func (s *ProductService) Create(data *models.Product) error {
-	return s.repo.Create(data)
+func (s *ProductService) Create(data *models.Product, meta models.RequestMeta) error {
+	return s.repo.Create(data, meta)
 }
 
-func (s *ProductService) GetByID(id int) (*models.Product, error) {
-	return s.repo.GetByID(id)
+func (s *ProductService) GetByID(id int, outletID int) (*models.Product, error) {
+	return s.repo.GetByID(id, outletID)
 }
 
-func (s *ProductService) Update(category *models.Product) error {
-	return s.repo.Update(category)
+func (s *ProductService) Update(category *models.Product, meta models.RequestMeta) error {
+	return s.repo.Update(category, meta)
 }
 
-func (s *ProductService) Delete(id int) error {
-	return s.repo.Delete(id)
+func (s *ProductService) Delete(id int, meta models.RequestMeta) error {
+	return s.repo.Delete(id, meta)
+}
+
+func (s *ProductService) Lookup(barcode string, outletID int) (*models.BarcodeMatch, error) {
+	return s.repo.Lookup(barcode, outletID)
 }
@@ -0,0 +1,50 @@
+package services
+
+import (
+	"cashier-api/models"
+	"cashier-api/repositories"
+)
+
+type PurchaseOrderService struct {
+	repo *repositories.PurchaseOrderRepository
+}
+
+func NewPurchaseOrderService(repo *repositories.PurchaseOrderRepository) *PurchaseOrderService {
+	return &PurchaseOrderService{repo: repo}
+}
+
+func (s *PurchaseOrderService) GetAll(filter models.PurchaseOrderFilter) ([]models.PurchaseOrder, error) {
+	return s.repo.GetAll(filter)
+}
+
+func (s *PurchaseOrderService) GetByID(id int) (*models.PurchaseOrder, error) {
+	return s.repo.GetByID(id)
+}
+
+func (s *PurchaseOrderService) Create(req models.PurchaseOrderRequest, meta models.RequestMeta) (*models.PurchaseOrder, error) {
+	return s.repo.Create(req, meta)
+}
+
+func (s *PurchaseOrderService) Update(id int, req models.PurchaseOrderRequest) (*models.PurchaseOrder, error) {
+	return s.repo.Update(id, req)
+}
+
+func (s *PurchaseOrderService) Delete(id int) error {
+	return s.repo.Delete(id)
+}
+
+func (s *PurchaseOrderService) Send(id int) (*models.PurchaseOrder, error) {
+	return s.repo.Send(id)
+}
+
+func (s *PurchaseOrderService) Close(id int) (*models.PurchaseOrder, error) {
+	return s.repo.Close(id)
+}
+
+func (s *PurchaseOrderService) Receive(id int, req models.GoodsReceiptRequest, meta models.RequestMeta) (*models.GoodsReceipt, error) {
+	return s.repo.Receive(id, req, meta)
+}
+
+func (s *PurchaseOrderService) GetOpenReport(supplierID, outletID int) (*models.OpenPurchaseOrderReport, error) {
+	return s.repo.GetOpenReport(supplierID, outletID)
+}
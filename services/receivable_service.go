@@ -0,0 +1,26 @@
+package services
+
+import (
+	"cashier-api/models"
+	"cashier-api/repositories"
+)
+
+type ReceivableService struct {
+	repo *repositories.ReceivableRepository
+}
+
+func NewReceivableService(repo *repositories.ReceivableRepository) *ReceivableService {
+	return &ReceivableService{repo: repo}
+}
+
+func (s *ReceivableService) GetReport() (*models.ReceivablesReport, error) {
+	return s.repo.GetReport()
+}
+
+func (s *ReceivableService) GetStatement(customerID int, filter models.StatementFilter) (*models.Statement, error) {
+	return s.repo.GetStatement(customerID, filter)
+}
+
+func (s *ReceivableService) CreateRepayment(customerID int, repayment *models.Repayment, meta models.RequestMeta) error {
+	return s.repo.CreateRepayment(customerID, repayment, meta)
+}
@@ -0,0 +1,26 @@
+package services
+
+import (
+	"cashier-api/models"
+	"cashier-api/repositories"
+)
+
+type RecipeService struct {
+	repo *repositories.RecipeRepository
+}
+
+func NewRecipeService(repo *repositories.RecipeRepository) *RecipeService {
+	return &RecipeService{repo: repo}
+}
+
+func (s *RecipeService) GetRecipe(productID int) ([]models.RecipeItem, error) {
+	return s.repo.GetRecipe(productID)
+}
+
+func (s *RecipeService) SetRecipe(productID int, items []models.RecipeItem, meta models.RequestMeta) ([]models.RecipeItem, error) {
+	return s.repo.SetRecipe(productID, items, meta)
+}
+
+func (s *RecipeService) GetIngredientUsage(filter models.IngredientUsageFilter) (*models.IngredientUsageReport, error) {
+	return s.repo.GetIngredientUsage(filter)
+}
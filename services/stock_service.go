@@ -0,0 +1,23 @@
+package services
+
+import (
+	"cashier-api/models"
+	"cashier-api/repositories"
+	"time"
+)
+
+type StockService struct {
+	repo *repositories.StockMovementRepository
+}
+
+func NewStockService(repo *repositories.StockMovementRepository) *StockService {
+	return &StockService{repo: repo}
+}
+
+func (s *StockService) GetStockCard(outletID, productID int, from, to *time.Time) (*models.StockCard, error) {
+	return s.repo.GetStockCard(outletID, productID, from, to)
+}
+
+func (s *StockService) Adjust(productID int, req models.StockAdjustmentRequest, meta models.RequestMeta) (*models.StockMovement, error) {
+	return s.repo.AdjustStock(productID, req, meta)
+}
@@ -0,0 +1,38 @@
+package services
+
+import (
+	"cashier-api/models"
+	"cashier-api/repositories"
+)
+
+type StockTransferService struct {
+	repo *repositories.StockTransferRepository
+}
+
+func NewStockTransferService(repo *repositories.StockTransferRepository) *StockTransferService {
+	return &StockTransferService{repo: repo}
+}
+
+func (s *StockTransferService) GetAll(filter models.StockTransferFilter) ([]models.StockTransfer, error) {
+	return s.repo.GetAll(filter)
+}
+
+func (s *StockTransferService) GetByID(id int) (*models.StockTransfer, error) {
+	return s.repo.GetByID(id)
+}
+
+func (s *StockTransferService) Create(req models.StockTransferRequest, meta models.RequestMeta) (*models.StockTransfer, error) {
+	return s.repo.Create(req, meta)
+}
+
+func (s *StockTransferService) Dispatch(id int, req models.StockTransferDispatchRequest, meta models.RequestMeta) (*models.StockTransfer, error) {
+	return s.repo.Dispatch(id, req, meta)
+}
+
+func (s *StockTransferService) Receive(id int, req models.StockTransferReceiveRequest, meta models.RequestMeta) (*models.StockTransfer, error) {
+	return s.repo.Receive(id, req, meta)
+}
+
+func (s *StockTransferService) Cancel(id int, meta models.RequestMeta) (*models.StockTransfer, error) {
+	return s.repo.Cancel(id, meta)
+}
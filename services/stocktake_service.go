@@ -0,0 +1,46 @@
+package services
+
+import (
+	"cashier-api/models"
+	"cashier-api/repositories"
+)
+
+type StocktakeService struct {
+	repo *repositories.StocktakeRepository
+}
+
+func NewStocktakeService(repo *repositories.StocktakeRepository) *StocktakeService {
+	return &StocktakeService{repo: repo}
+}
+
+func (s *StocktakeService) GetAll(outletID int) ([]models.Stocktake, error) {
+	return s.repo.GetAll(outletID)
+}
+
+func (s *StocktakeService) Create(stocktake *models.Stocktake, meta models.RequestMeta) error {
+	return s.repo.Create(stocktake, meta)
+}
+
+func (s *StocktakeService) GetByID(id int) (*models.Stocktake, error) {
+	return s.repo.GetByID(id)
+}
+
+func (s *StocktakeService) AddCounts(id int, req models.StocktakeCountRequest, meta models.RequestMeta) ([]models.StocktakeCount, error) {
+	return s.repo.AddCounts(id, req, meta)
+}
+
+func (s *StocktakeService) DeleteCount(id, countID int, meta models.RequestMeta) error {
+	return s.repo.DeleteCount(id, countID, meta)
+}
+
+func (s *StocktakeService) Finalize(id int, meta models.RequestMeta) (*models.StocktakeVarianceReport, error) {
+	return s.repo.Finalize(id, meta)
+}
+
+func (s *StocktakeService) Cancel(id int, meta models.RequestMeta) error {
+	return s.repo.Cancel(id, meta)
+}
+
+func (s *StocktakeService) GetVarianceReport(id int) (*models.StocktakeVarianceReport, error) {
+	return s.repo.GetVarianceReport(id)
+}
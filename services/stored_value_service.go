@@ -0,0 +1,26 @@
+package services
+
+import (
+	"cashier-api/models"
+	"cashier-api/repositories"
+)
+
+type StoredValueService struct {
+	repo *repositories.StoredValueRepository
+}
+
+func NewStoredValueService(repo *repositories.StoredValueRepository) *StoredValueService {
+	return &StoredValueService{repo: repo}
+}
+
+func (s *StoredValueService) GetGiftCard(code string, limit int) (*models.StoredValueAccount, error) {
+	return s.repo.GetGiftCard(code, limit)
+}
+
+func (s *StoredValueService) GetStoreCredit(customerID, limit int) (*models.StoredValueAccount, error) {
+	return s.repo.GetStoreCredit(customerID, limit)
+}
+
+func (s *StoredValueService) AdjustStoreCredit(customerID int, adjustment models.StoredValueAdjustment, meta models.RequestMeta) (*models.StoredValueEntry, error) {
+	return s.repo.AdjustStoreCredit(customerID, adjustment, meta)
+}
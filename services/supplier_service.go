@@ -0,0 +1,34 @@
+package services
+
+import (
+	"cashier-api/models"
+	"cashier-api/repositories"
+)
+
+type SupplierService struct {
+	repo *repositories.SupplierRepository
+}
+
+func NewSupplierService(repo *repositories.SupplierRepository) *SupplierService {
+	return &SupplierService{repo: repo}
+}
+
+func (s *SupplierService) GetAll() ([]models.Supplier, error) {
+	return s.repo.GetAll()
+}
+
+func (s *SupplierService) Create(data *models.Supplier, meta models.RequestMeta) error {
+	return s.repo.Create(data, meta)
+}
+
+func (s *SupplierService) GetByID(id int) (*models.Supplier, error) {
+	return s.repo.GetByID(id)
+}
+
+func (s *SupplierService) Update(supplier *models.Supplier, meta models.RequestMeta) error {
+	return s.repo.Update(supplier, meta)
+}
+
+func (s *SupplierService) Delete(id int, meta models.RequestMeta) error {
+	return s.repo.Delete(id, meta)
+}
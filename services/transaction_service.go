@@ -2,21 +2,44 @@ package services
 
 import (
 	"cashier-api/models"
+	"cashier-api/notifiers"
 	"cashier-api/repositories"
 )
 
 type TransactionService struct {
-	repo *repositories.TransactionRepository
+	repo     *repositories.TransactionRepository
+	notifier notifiers.Notifier
 }
 
-func NewTransactionService(repo *repositories.TransactionRepository) *TransactionService {
-	return &TransactionService{repo: repo}
+func NewTransactionService(repo *repositories.TransactionRepository, notifier notifiers.Notifier) *TransactionService {
+	return &TransactionService{repo: repo, notifier: notifier}
 }
 
-func (s *TransactionService) Checkout(items []models.CheckoutItem, useLock bool) (*models.Transaction, error) {
-	return s.repo.CreateTransaction(items)
+func (s *TransactionService) Checkout(req models.CheckoutRequest, useLock bool, meta models.RequestMeta) (*models.Transaction, error) {
+	transaction, events, err := s.repo.CreateTransaction(req, meta)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, event := range events {
+		s.notifier.NotifyLowStock(event)
+	}
+
+	return transaction, nil
+}
+
+func (s *TransactionService) Refund(transactionID int, req models.RefundRequest, meta models.RequestMeta) (*models.Refund, error) {
+	return s.repo.RefundTransaction(transactionID, req, meta)
+}
+
+func (s *TransactionService) GetByID(id int) (*models.Transaction, error) {
+	return s.repo.GetByID(id)
+}
+
+func (s *TransactionService) GetReport(outletID int) (*models.Report, error) {
+	return s.repo.GetReport(outletID)
 }
 
-func (s *TransactionService) GetReport() (*models.Report, error) {
-	return s.repo.GetReport()
+func (s *TransactionService) GetGrossProfitReport(filter models.GrossProfitFilter) (*models.GrossProfitReport, error) {
+	return s.repo.GetGrossProfitReport(filter)
 }